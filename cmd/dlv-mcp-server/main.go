@@ -1,32 +1,173 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
 
 	mcpdebug "github.com/roasbeef/mcp-debug"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/config"
 	"github.com/roasbeef/mcp-debug/internal/logging"
+	"github.com/roasbeef/mcp-debug/internal/metrics"
+	"github.com/roasbeef/mcp-debug/mcp"
 )
 
 func main() {
+	config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, configFile, err := config.Load(flag.CommandLine, os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	logging.SetDir(cfg.LogDir)
+
+	// Validated by config.Load.
+	transport, _ := mcp.ParseTransport(cfg.Transport)
+
 	// Initialize file logging
-	logFile, err := logging.InitFileLogger()
+	logFile, err := logging.InitFileLogger(cfg.LogOptions())
 	if err != nil {
-		log.Printf("Warning: Failed to initialize file logging: %v", err)
+		slog.Warn("Failed to initialize file logging", "err", err)
 		// Continue without file logging
 	} else {
 		defer logFile.Close()
 	}
 
-	log.Println("Starting Go DAP MCP Server...")
+	slog.Info("Starting Go DAP MCP Server...")
+	if configFile != "" {
+		slog.Info("Loaded config file", "path", configFile)
+	}
+	for _, line := range cfg.Summary() {
+		slog.Info("Config setting", "setting", line)
+	}
+	if _, err := exec.LookPath(cfg.DlvPath); err != nil {
+		slog.Warn("Sessions can't be created until dlv is installed",
+			"err", err)
+	}
+
+	// Clean up Delve servers left behind by a previous server that
+	// didn't shut down cleanly.
+	killed, err := debugger.SweepOrphanedDelve()
+	if err != nil {
+		slog.Warn("Failed to clean up orphaned dlv processes",
+			"err", err)
+	} else if killed > 0 {
+		slog.Info("Killed orphaned dlv processes", "count", killed)
+	}
+
+	opts := []mcp.ServerOption{
+		mcp.WithSessionLimits(cfg.SessionLimits()),
+		mcp.WithBuildFlags(cfg.BuildFlags),
+		mcp.WithPolicy(cfg.MCPPolicy()),
+	}
+
+	// Record every tool call so what agents did can be reviewed later.
+	auditLog, err := cfg.OpenAuditLog()
+	if err != nil {
+		fatal("Failed to open audit log", err)
+	}
+	if auditLog != nil {
+		defer auditLog.Close()
+		slog.Info("Recording tool calls", "audit_log", auditLog.Path())
+
+		opts = append(opts, mcp.WithAuditLog(auditLog, cfg.Audit.Redact))
+		go rotateOnHangup(auditLog)
+	}
+
+	if cfg.MetricsListen != "" {
+		stopMetrics, err := serveMetrics(cfg.MetricsListen)
+		if err != nil {
+			fatal("Failed to serve metrics", err)
+		}
+		defer stopMetrics()
+	}
 
 	// Create MCP server with service management
-	mcpServer, service := mcpdebug.NewMCPServer()
+	mcpServer, service := mcpdebug.NewMCPServerWithConfig(cfg.Delve(),
+		opts...)
 	defer service.Stop()
+	defer mcpServer.Stop()
 
 	// Start serving
-	if err := mcpServer.Serve(); err != nil {
-		log.Fatalf("MCP server error: %v", err)
-		os.Exit(1)
+	if transport == mcp.TransportStdio {
+		err = mcpServer.Serve()
+	} else {
+		err = serveHTTP(mcpServer, transport, cfg.Listen)
+	}
+	if err != nil {
+		fatal("MCP server error", err)
+	}
+}
+
+// fatal logs an error and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// serveHTTP serves MCP clients over an HTTP transport until the process is
+// interrupted.
+func serveHTTP(mcpServer *mcp.MCPDebugServer, transport mcp.Transport,
+	addr string) error {
+
+	ln, err := mcp.Listen(addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM,
+	)
+	defer stop()
+
+	return mcpServer.ServeListener(ctx, ln, transport)
+}
+
+// serveMetrics serves the server's metrics at /metrics on the given address
+// and returns a function that stops serving them.
+func serveMetrics(addr string) (func(), error) {
+	ln, err := mcp.Listen(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := srv.Serve(ln)
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics server failed", "err", err)
+		}
+	}()
+	slog.Info("Serving metrics", "address", ln.Addr().String())
+
+	return func() { srv.Close() }, nil
+}
+
+// rotateOnHangup rotates the audit log whenever the process receives SIGHUP,
+// for use with external log rotation.
+func rotateOnHangup(auditLog *logging.RotatingFile) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	for range hangup {
+		if err := auditLog.Rotate(); err != nil {
+			slog.Error("Failed to rotate audit log", "err", err)
+		}
 	}
-}
\ No newline at end of file
+}
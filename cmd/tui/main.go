@@ -1,17 +1,40 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
 	mcpdebug "github.com/roasbeef/mcp-debug"
+	"github.com/roasbeef/mcp-debug/mcp"
 )
 
 func main() {
+	connect := flag.String("connect", "", "monitor a running server "+
+		"listening on host:port or unix:<path> instead of starting "+
+		"one in-process")
+	transportName := flag.String("transport", string(mcp.TransportStreamableHTTP),
+		"transport of the server given by -connect: sse or http")
+	flag.Parse()
+
+	if *connect != "" {
+		transport, err := mcp.ParseTransport(*transportName)
+		if err != nil {
+			log.Fatalf("Invalid transport: %v", err)
+		}
+
+		log.Printf("Connecting MCP Debug Server TUI Console to %s...",
+			*connect)
+		if err := mcpdebug.ConnectTUI(*connect, transport); err != nil {
+			log.Fatalf("TUI failed: %v", err)
+		}
+		return
+	}
+
 	// Launch TUI with proper Bubble Tea components and actor router
 	log.Println("Starting MCP Debug Server TUI Console...")
 	if err := mcpdebug.RunTUI(); err != nil {
 		log.Fatalf("TUI failed: %v", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -1,6 +1,8 @@
 package mcpdebug
 
 import (
+	"context"
+
 	"github.com/lightningnetwork/lnd/actor"
 	"github.com/roasbeef/mcp-debug/debugger"
 	"github.com/roasbeef/mcp-debug/mcp"
@@ -11,14 +13,22 @@ import (
 type MCPDebugService struct {
 	actorSystem *actor.ActorSystem
 	debuggerKey actor.ServiceKey[*debugger.DebuggerCmd, *debugger.DebuggerResp]
+	delve       debugger.DelveConfig
 	initialized bool
 }
 
 // NewMCPDebugService creates a new MCP debug service.
 func NewMCPDebugService() *MCPDebugService {
+	return NewMCPDebugServiceWithConfig(debugger.DefaultDelveConfig())
+}
+
+// NewMCPDebugServiceWithConfig creates a new MCP debug service that starts
+// Delve with the given configuration.
+func NewMCPDebugServiceWithConfig(delve debugger.DelveConfig) *MCPDebugService {
 	return &MCPDebugService{
 		actorSystem: actor.NewActorSystem(),
 		debuggerKey: actor.NewServiceKey[*debugger.DebuggerCmd, *debugger.DebuggerResp]("debugger"),
+		delve:       delve,
 		initialized: false,
 	}
 }
@@ -30,7 +40,7 @@ func (s *MCPDebugService) Start() error {
 	}
 
 	// Create a new debugger actor.
-	debuggerActor := debugger.NewDebugger(s.actorSystem)
+	debuggerActor := debugger.NewDebuggerWithConfig(s.actorSystem, s.delve)
 
 	// Register the debugger actor with the actor system.
 	actor.RegisterWithSystem(
@@ -50,12 +60,12 @@ func (s *MCPDebugService) Stop() {
 }
 
 // GetMCPServer creates a new MCP server with the configured debugger.
-func (s *MCPDebugService) GetMCPServer() *mcp.MCPDebugServer {
+func (s *MCPDebugService) GetMCPServer(opts ...mcp.ServerOption) *mcp.MCPDebugServer {
 	if !s.initialized {
 		s.Start()
 	}
 	debuggerRef := actor.FindInReceptionist(s.actorSystem.Receptionist(), s.debuggerKey)[0]
-	return mcp.NewMCPDebugServer(s.actorSystem, debuggerRef)
+	return mcp.NewMCPDebugServer(s.actorSystem, debuggerRef, opts...)
 }
 
 // GetActorSystem returns the actor system for direct access.
@@ -78,9 +88,31 @@ func RunTUI() error {
 	return service.RunTUI()
 }
 
+// ConnectTUI runs the TUI application connected to a server running in another
+// process, which listens on addr with the given HTTP transport.
+func ConnectTUI(addr string, transport mcp.Transport) error {
+	remote, err := mcp.DialServer(context.Background(), addr, transport)
+	if err != nil {
+		return err
+	}
+	defer remote.Close()
+
+	return tui.RunRemoteTUI(remote)
+}
+
 // NewMCPServer creates a new MCP server with a new service instance.
-func NewMCPServer() (*mcp.MCPDebugServer, *MCPDebugService) {
+func NewMCPServer(opts ...mcp.ServerOption) (*mcp.MCPDebugServer, *MCPDebugService) {
 	service := NewMCPDebugService()
-	mcpServer := service.GetMCPServer()
+	mcpServer := service.GetMCPServer(opts...)
+	return mcpServer, service
+}
+
+// NewMCPServerWithConfig creates a new MCP server with a new service instance
+// that starts Delve with the given configuration.
+func NewMCPServerWithConfig(delve debugger.DelveConfig,
+	opts ...mcp.ServerOption) (*mcp.MCPDebugServer, *MCPDebugService) {
+
+	service := NewMCPDebugServiceWithConfig(delve)
+	mcpServer := service.GetMCPServer(opts...)
 	return mcpServer, service
 }
@@ -13,9 +13,9 @@ import (
 
 // launchDelve starts a Delve DAP server and returns connection.
 // Currently uses external process - can be switched to embedded later.
-func launchDelve() (net.Conn, func(), error) {
+func launchDelve(config DelveConfig) (net.Conn, func(), error) {
 	// For now, use the external approach that we know works
-	return launchDelveExternal()
+	return launchDelveExternal(config)
 }
 
 // launchDelveEmbedded starts an embedded Delve DAP server using the delve library.
@@ -73,6 +73,7 @@ func launchDelveEmbedded() (net.Conn, func(), error) {
 		InitialDelay: 50 * time.Millisecond,
 		MaxDelay:     500 * time.Millisecond,
 		Multiplier:   2.0,
+		Operation:    "connect_delve",
 	}, func() error {
 		var dialErr error
 		conn, dialErr = net.Dial("tcp", addr)
@@ -4,18 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"strings"
 
 	"github.com/google/go-dap"
 	"github.com/lightningnetwork/lnd/actor"
+	"github.com/roasbeef/mcp-debug/internal/logging"
 )
 
 // InitializeSession sends an InitializeRequest to a Session actor and returns 
 // the response. This establishes the DAP protocol connection and negotiates
 // capabilities between the client and the debug adapter.
-func InitializeSession(session actor.ActorRef[*DAPRequest, *DAPResponse], 
+func InitializeSession(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse], 
 	clientID string) (*dap.InitializeResponse, error) {
 
 	req := &dap.InitializeRequest{
@@ -34,9 +35,9 @@ func InitializeSession(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -53,38 +54,13 @@ func InitializeSession(session actor.ActorRef[*DAPRequest, *DAPResponse],
 // LaunchProgram launches a Go program for debugging using the provided
 // configuration. This provides a high-level interface for program launching
 // with comprehensive configuration options.
-func LaunchProgram(session actor.ActorRef[*DAPRequest, *DAPResponse], 
+func LaunchProgram(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse], 
 	config LaunchConfig) (*dap.LaunchResponse, error) {
 
-	// Determine launch mode based on program path
-	// If it's a pre-built binary (ends with .test or is executable), use "exec" mode
-	// Otherwise, use "test" or "debug" mode with appropriate build flags
-	mode := "debug"
-	
-	// Check if this is a test file or test binary
-	isTest := strings.HasSuffix(config.Program, "_test.go") || 
-	          strings.HasSuffix(config.Program, ".test") ||
-	          (len(config.Args) > 0 && strings.Contains(config.Args[0], "-test."))
-	
-	if strings.HasSuffix(config.Program, ".test") || 
-	   strings.Contains(config.Program, "__debug_bin") {
-		// Pre-built binary - use exec mode
-		mode = "exec"
-		log.Printf("[LaunchProgram] Using exec mode for pre-built binary: %s", config.Program)
-	} else if isTest || strings.HasSuffix(config.Program, "_test.go") {
-		// Test file - use test mode
-		mode = "test"
-		log.Printf("[LaunchProgram] Using test mode for test file: %s", config.Program)
-	} else if !strings.HasSuffix(config.Program, ".go") {
-		// Check if it's an executable file
-		if fileInfo, err := os.Stat(config.Program); err == nil {
-			if fileInfo.Mode()&0111 != 0 { // Check if executable
-				mode = "exec"
-			}
-		}
-	}
-	
-	log.Printf("[LaunchProgram] Using mode=%s for program=%s", mode, config.Program)
+	mode := DetectLaunchMode(config)
+	sessionLog(session).Info("Launching program", "mode", mode,
+		"program", config.Program)
 	
 	// Build launch arguments from configuration
 	launchArgs := map[string]interface{}{
@@ -112,6 +88,10 @@ func LaunchProgram(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		launchArgs["stopOnEntry"] = true
 	}
 
+	if config.Output != "" && mode != "exec" {
+		launchArgs["output"] = config.Output
+	}
+
 	// Handle build flags - automatically add debug flags if not in exec mode
 	buildFlags := config.BuildFlags
 	if mode != "exec" {
@@ -128,7 +108,8 @@ func LaunchProgram(session actor.ActorRef[*DAPRequest, *DAPResponse],
 			// Add flags to disable optimizations for better debugging
 			debugFlags := []string{"-gcflags", "all=-N -l"}
 			buildFlags = append(debugFlags, buildFlags...)
-			log.Printf("[LaunchProgram] Auto-adding debug build flags: %v", debugFlags)
+			sessionLog(session).Debug("Adding debug build flags",
+				"flags", debugFlags)
 		}
 	}
 	
@@ -153,9 +134,9 @@ func LaunchProgram(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		Arguments: json.RawMessage(launchArgsJSON),
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -174,10 +155,53 @@ func LaunchProgram(session actor.ActorRef[*DAPRequest, *DAPResponse],
 	return resp, nil
 }
 
+// DetectLaunchMode determines the Delve launch mode for the given
+// configuration. An explicitly configured mode is always used. Otherwise
+// pre-built binaries are launched in "exec" mode, test files and test binaries
+// in "test" mode, and everything else is built from source in "debug" mode.
+func DetectLaunchMode(config LaunchConfig) string {
+	if config.Mode != "" {
+		return config.Mode
+	}
+
+	// Determine launch mode based on program path
+	// If it's a pre-built binary (ends with .test or is executable), use "exec" mode
+	// Otherwise, use "test" or "debug" mode with appropriate build flags
+	mode := "debug"
+	
+	// Check if this is a test file or test binary
+	isTest := strings.HasSuffix(config.Program, "_test.go") || 
+	          strings.HasSuffix(config.Program, ".test") ||
+	          (len(config.Args) > 0 && strings.Contains(config.Args[0], "-test."))
+	
+	if strings.HasSuffix(config.Program, ".test") || 
+	   strings.Contains(config.Program, "__debug_bin") {
+		// Pre-built binary - use exec mode
+		mode = "exec"
+		logging.Component("dap").Debug("Using exec mode for pre-built "+
+			"binary", "program", config.Program)
+	} else if isTest || strings.HasSuffix(config.Program, "_test.go") {
+		// Test file - use test mode
+		mode = "test"
+		logging.Component("dap").Debug("Using test mode for test file",
+			"program", config.Program)
+	} else if !strings.HasSuffix(config.Program, ".go") {
+		// Check if it's an executable file
+		if fileInfo, err := os.Stat(config.Program); err == nil {
+			if fileInfo.Mode()&0111 != 0 { // Check if executable
+				mode = "exec"
+			}
+		}
+	}
+
+	return mode
+}
+
 // AttachToProcess attaches the debugger to an existing running process
 // using the provided configuration. This provides a high-level interface
 // for process attachment with comprehensive configuration options.
-func AttachToProcess(session actor.ActorRef[*DAPRequest, *DAPResponse], 
+func AttachToProcess(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse], 
 	config AttachConfig) (*dap.AttachResponse, error) {
 
 	// Build attach arguments from configuration
@@ -216,9 +240,9 @@ func AttachToProcess(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		Arguments: json.RawMessage(attachArgsJSON),
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -240,7 +264,7 @@ func AttachToProcess(session actor.ActorRef[*DAPRequest, *DAPResponse],
 // ConfigurationDone indicates that the client has finished sending
 // configuration requests and that the debug adapter should begin
 // debugging the target program.
-func ConfigurationDone(
+func ConfigurationDone(ctx context.Context,
 	session actor.ActorRef[*DAPRequest, *DAPResponse],
 ) (*dap.ConfigurationDoneResponse, error) {
 
@@ -254,9 +278,9 @@ func ConfigurationDone(
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -270,9 +294,49 @@ func ConfigurationDone(
 	return resp, nil
 }
 
+// Disconnect asks the debug adapter to end the debug session. If
+// terminateDebuggee is true, a launched or attached program is killed,
+// otherwise an attached program is left running.
+func Disconnect(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	terminateDebuggee bool) (*dap.DisconnectResponse, error) {
+
+	req := &dap.DisconnectRequest{
+		Request: dap.Request{
+			ProtocolMessage: dap.ProtocolMessage{
+				Type: "request",
+			},
+			Command: "disconnect",
+		},
+		Arguments: &dap.DisconnectArguments{
+			TerminateDebuggee: terminateDebuggee,
+		},
+	}
+
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, ok := result.Response.(*dap.DisconnectResponse)
+	if !ok {
+		if errResp, isErr := result.Response.(*dap.ErrorResponse); isErr {
+			return nil, fmt.Errorf("disconnect failed: %s",
+				errResp.Body.Error.Format)
+		}
+		return nil, fmt.Errorf("unexpected response type: %T",
+			result.Response)
+	}
+
+	return resp, nil
+}
+
 // SetSourceBreakpoints is a convenience function for setting line-based
 // breakpoints in a source file without complex configuration.
-func SetSourceBreakpoints(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func SetSourceBreakpoints(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	sourcePath string, lines []int) (*dap.SetBreakpointsResponse, error) {
 
 	// Convert simple line numbers to BreakpointLocation structs
@@ -284,12 +348,12 @@ func SetSourceBreakpoints(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		}
 	}
 
-	return SetBreakpoints(session, breakpoints)
+	return SetBreakpoints(ctx, session, breakpoints)
 }
 
 // SetSimpleFunctionBreakpoints is a convenience function for setting 
 // function breakpoints by name without complex configuration.
-func SetSimpleFunctionBreakpoints(
+func SetSimpleFunctionBreakpoints(ctx context.Context,
 	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	functionNames []string) (*dap.SetFunctionBreakpointsResponse, error) {
 
@@ -301,5 +365,5 @@ func SetSimpleFunctionBreakpoints(
 		}
 	}
 
-	return SetFunctionBreakpoints(session, breakpoints)
+	return SetFunctionBreakpoints(ctx, session, breakpoints)
 }
\ No newline at end of file
@@ -57,6 +57,8 @@ func (m *MockSession) Receive(actorCtx context.Context,
 		command = req.Command
 	case *dap.SetFunctionBreakpointsRequest:
 		command = req.Command
+	case *dap.SetExceptionBreakpointsRequest:
+		command = req.Command
 	case *dap.ContinueRequest:
 		command = req.Command
 	case *dap.NextRequest:
@@ -77,6 +79,8 @@ func (m *MockSession) Receive(actorCtx context.Context,
 		command = req.Command
 	case *dap.EvaluateRequest:
 		command = req.Command
+	case *dap.DisconnectRequest:
+		command = req.Command
 	default:
 		return fn.Err[*DAPResponse](
 			fmt.Errorf("unknown request type: %T", req))
@@ -129,7 +133,7 @@ func TestInitializeSession(t *testing.T) {
 		system.Receptionist(), sessionKey)[0]
 	
 	// Test InitializeSession
-	resp, err := InitializeSession(sessionRef, "test-client")
+	resp, err := InitializeSession(context.Background(), sessionRef, "test-client")
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "initialize", resp.Command)
@@ -195,7 +199,7 @@ func TestLaunchProgram(t *testing.T) {
 		BuildFlags:  []string{"-race"},
 	}
 	
-	resp, err := LaunchProgram(sessionRef, config)
+	resp, err := LaunchProgram(context.Background(), sessionRef, config)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "launch", resp.Command)
@@ -252,7 +256,7 @@ func TestAttachToProcess(t *testing.T) {
 		Mode:      "local",
 	}
 	
-	resp, err := AttachToProcess(sessionRef, config)
+	resp, err := AttachToProcess(context.Background(), sessionRef, config)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "attach", resp.Command)
@@ -303,7 +307,7 @@ func TestConfigurationDone(t *testing.T) {
 		system.Receptionist(), sessionKey)[0]
 	
 	// Test ConfigurationDone
-	resp, err := ConfigurationDone(sessionRef)
+	resp, err := ConfigurationDone(context.Background(), sessionRef)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "configurationDone", resp.Command)
@@ -321,6 +325,39 @@ func TestConfigurationDone(t *testing.T) {
 	system.Shutdown()
 }
 
+// TestDisconnect tests the Disconnect function.
+func TestDisconnect(t *testing.T) {
+	mockSession := NewMockSession()
+	mockSession.SetResponse("disconnect", &dap.DisconnectResponse{
+		Response: dap.Response{
+			Command: "disconnect",
+			Success: true,
+		},
+	})
+
+	system := actor.NewActorSystem()
+	defer system.Shutdown()
+
+	sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse]("session")
+	sessionRef := actor.RegisterWithSystem(
+		system, "session", sessionKey,
+		actor.NewFunctionBehavior[*DAPRequest, *DAPResponse](
+			mockSession.Receive),
+	)
+
+	resp, err := Disconnect(context.Background(), sessionRef, true)
+	require.NoError(t, err)
+	require.True(t, resp.Success)
+
+	requests := mockSession.GetRequests()
+	require.Len(t, requests, 1)
+
+	disconnectReq, ok := requests[0].(*dap.DisconnectRequest)
+	require.True(t, ok)
+	require.Equal(t, "disconnect", disconnectReq.Command)
+	require.True(t, disconnectReq.Arguments.TerminateDebuggee)
+}
+
 // TestLaunchConfig validates the LaunchConfig wrapper type.
 func TestLaunchConfig(t *testing.T) {
 	config := LaunchConfig{
@@ -343,6 +380,27 @@ func TestLaunchConfig(t *testing.T) {
 	require.Equal(t, []string{"-race", "-v"}, config.BuildFlags)
 }
 
+// TestDetectLaunchMode tests that the launch mode is detected from the program
+// unless it's configured explicitly.
+func TestDetectLaunchMode(t *testing.T) {
+	tests := []struct {
+		config LaunchConfig
+		mode   string
+	}{
+		{LaunchConfig{Program: "./cmd/server"}, "debug"},
+		{LaunchConfig{Program: "main.go"}, "debug"},
+		{LaunchConfig{Program: "pkg/foo_test.go"}, "test"},
+		{LaunchConfig{Program: "pkg/foo.test"}, "exec"},
+		{LaunchConfig{Program: "./pkg", Mode: "test"}, "test"},
+		{LaunchConfig{Program: "./bin/server", Mode: "exec"}, "exec"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.mode, DetectLaunchMode(test.config),
+			test.config.Program)
+	}
+}
+
 // TestAttachConfig validates the AttachConfig wrapper type.
 func TestAttachConfig(t *testing.T) {
 	config := AttachConfig{
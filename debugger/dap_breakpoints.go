@@ -3,6 +3,8 @@ package debugger
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/google/go-dap"
 	"github.com/lightningnetwork/lnd/actor"
@@ -11,7 +13,8 @@ import (
 // SetBreakpoints sets breakpoints using the provided breakpoint locations.
 // This function replaces any existing breakpoints for the file with the new
 // set of breakpoints and provides comprehensive breakpoint configuration.
-func SetBreakpoints(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func SetBreakpoints(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	breakpoints []BreakpointLocation) (*dap.SetBreakpointsResponse, error) {
 
 	if len(breakpoints) == 0 {
@@ -54,9 +57,9 @@ func SetBreakpoints(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +76,7 @@ func SetBreakpoints(session actor.ActorRef[*DAPRequest, *DAPResponse],
 // SetFunctionBreakpoints sets breakpoints on function names using the
 // provided function breakpoint configurations. This is useful for setting
 // breakpoints on functions without knowing their exact source location.
-func SetFunctionBreakpoints(
+func SetFunctionBreakpoints(ctx context.Context,
 	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	functionBreakpoints []FunctionBreakpoint) (*dap.SetFunctionBreakpointsResponse, error) {
 
@@ -99,9 +102,9 @@ func SetFunctionBreakpoints(
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -113,4 +116,300 @@ func SetFunctionBreakpoints(
 	}
 
 	return resp, nil
-}
\ No newline at end of file
+}
+
+// SetExceptionBreakpoints configures which exceptions the program should stop
+// on using the filter IDs advertised by the debug adapter. Delve accepts the
+// request but always stops on panics and fatal errors regardless of the
+// filters.
+func SetExceptionBreakpoints(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	filters []string) (*dap.SetExceptionBreakpointsResponse, error) {
+
+	if filters == nil {
+		filters = []string{}
+	}
+
+	req := &dap.SetExceptionBreakpointsRequest{
+		Request: dap.Request{
+			ProtocolMessage: dap.ProtocolMessage{
+				Type: "request",
+			},
+			Command: "setExceptionBreakpoints",
+		},
+		Arguments: dap.SetExceptionBreakpointsArguments{
+			Filters: filters,
+		},
+	}
+
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, ok := result.Response.(*dap.SetExceptionBreakpointsResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response type: %T",
+			result.Response)
+	}
+
+	return resp, nil
+}
+
+// SetBreakpointsStatus sets breakpoints using the provided breakpoint
+// locations, like SetBreakpoints, and returns the status reported by the debug
+// adapter for each of them in request order.
+func SetBreakpointsStatus(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	breakpoints []BreakpointLocation) ([]BreakpointStatus, error) {
+
+	resp, err := SetBreakpoints(ctx, session, breakpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	return breakpointStatuses(breakpoints, resp.Body.Breakpoints), nil
+}
+
+// breakpointStatuses pairs the requested breakpoint locations with the
+// breakpoints returned by the debug adapter, which the DAP specification
+// guarantees are in the same order as the request.
+func breakpointStatuses(requested []BreakpointLocation,
+	actual []dap.Breakpoint) []BreakpointStatus {
+
+	statuses := make([]BreakpointStatus, len(requested))
+	for i, bp := range requested {
+		statuses[i] = BreakpointStatus{
+			File:          bp.File,
+			RequestedLine: bp.Line,
+		}
+
+		// A well behaved adapter returns one breakpoint per request,
+		// but treat any missing entries as unverified.
+		if i >= len(actual) {
+			statuses[i].Message = "no breakpoint returned by debug " +
+				"adapter"
+			continue
+		}
+
+		statuses[i].ID = actual[i].Id
+		statuses[i].Verified = actual[i].Verified
+		statuses[i].Line = actual[i].Line
+		statuses[i].Message = actual[i].Message
+	}
+
+	return statuses
+}
+
+// BreakpointTracker keeps track of the source breakpoints of a session along
+// with their verification status. The status is updated both from the
+// responses to breakpoint requests and from asynchronous breakpoint events, as
+// the debug adapter may verify, move or remove breakpoints at any time.
+type BreakpointTracker struct {
+	mu sync.Mutex
+
+	// files maps each source file to the statuses of its breakpoints in
+	// request order. Setting breakpoints for a file replaces all of them.
+	files map[string][]BreakpointStatus
+
+	// functions is the set of function breakpoints last requested. Like
+	// source breakpoints, each request replaces the previous set.
+	functions []FunctionBreakpoint
+
+	// requested maps each source file to the breakpoints last requested
+	// for it, so they can be set again in a later session.
+	requested map[string][]BreakpointLocation
+
+	// exceptionFilters are the exception filters last requested.
+	exceptionFilters []string
+
+	unsubscribe func()
+}
+
+// NewBreakpointTracker creates a new tracker that follows the breakpoint
+// events published on the given bus. A nil bus disables event tracking.
+func NewBreakpointTracker(events *EventBus) *BreakpointTracker {
+	t := &BreakpointTracker{
+		files:       make(map[string][]BreakpointStatus),
+		requested:   make(map[string][]BreakpointLocation),
+		unsubscribe: func() {},
+	}
+
+	if events != nil {
+		t.unsubscribe = events.Subscribe(t.handleEvent)
+	}
+
+	return t
+}
+
+// SetBreakpoints sets the given breakpoints on the session, replacing any
+// previous breakpoints in the same file, and records their status.
+func (t *BreakpointTracker) SetBreakpoints(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	breakpoints []BreakpointLocation) ([]BreakpointStatus, error) {
+
+	statuses, err := SetBreakpointsStatus(ctx, session, breakpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	recorded := make([]BreakpointStatus, len(statuses))
+	copy(recorded, statuses)
+	t.files[breakpoints[0].File] = recorded
+	t.requested[breakpoints[0].File] = append(
+		[]BreakpointLocation(nil), breakpoints...,
+	)
+
+	return statuses, nil
+}
+
+// SetFunctionBreakpoints sets the given function breakpoints on the session,
+// replacing any previous function breakpoints, and records them so they can be
+// restored after temporary breakpoints are used.
+func (t *BreakpointTracker) SetFunctionBreakpoints(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	breakpoints []FunctionBreakpoint,
+) (*dap.SetFunctionBreakpointsResponse, error) {
+
+	resp, err := SetFunctionBreakpoints(ctx, session, breakpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.functions = append([]FunctionBreakpoint(nil), breakpoints...)
+
+	return resp, nil
+}
+
+// FunctionBreakpoints returns the function breakpoints last set through the
+// tracker.
+func (t *BreakpointTracker) FunctionBreakpoints() []FunctionBreakpoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]FunctionBreakpoint(nil), t.functions...)
+}
+
+// SetExceptionBreakpoints sets the exception filters on the session and
+// records them.
+func (t *BreakpointTracker) SetExceptionBreakpoints(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	filters []string) error {
+
+	_, err := SetExceptionBreakpoints(ctx, session, filters)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.exceptionFilters = append([]string(nil), filters...)
+
+	return nil
+}
+
+// ExceptionFilters returns the exception filters last set through the tracker.
+func (t *BreakpointTracker) ExceptionFilters() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.exceptionFilters...)
+}
+
+// SourceBreakpoints returns the source breakpoints last requested through the
+// tracker, ordered by file and then by line. Unlike Breakpoints, it returns
+// the breakpoints as requested, including their conditions and log messages.
+func (t *BreakpointTracker) SourceBreakpoints() []BreakpointLocation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var breakpoints []BreakpointLocation
+	for _, fileBreakpoints := range t.requested {
+		breakpoints = append(breakpoints, fileBreakpoints...)
+	}
+
+	sort.Slice(breakpoints, func(i, j int) bool {
+		if breakpoints[i].File != breakpoints[j].File {
+			return breakpoints[i].File < breakpoints[j].File
+		}
+		return breakpoints[i].Line < breakpoints[j].Line
+	})
+
+	return breakpoints
+}
+
+// Breakpoints returns the current status of all tracked breakpoints, ordered
+// by file and then by requested line.
+func (t *BreakpointTracker) Breakpoints() []BreakpointStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var statuses []BreakpointStatus
+	for _, fileStatuses := range t.files {
+		statuses = append(statuses, fileStatuses...)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].File != statuses[j].File {
+			return statuses[i].File < statuses[j].File
+		}
+		return statuses[i].RequestedLine < statuses[j].RequestedLine
+	})
+
+	return statuses
+}
+
+// Stop stops tracking breakpoint events.
+func (t *BreakpointTracker) Stop() {
+	t.unsubscribe()
+}
+
+// handleEvent applies a breakpoint event from the debug adapter to the
+// matching tracked breakpoint.
+func (t *BreakpointTracker) handleEvent(event dap.EventMessage) {
+	bpEvent, ok := event.(*dap.BreakpointEvent)
+	if !ok {
+		return
+	}
+
+	bp := bpEvent.Body.Breakpoint
+	if bp.Id == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for file, statuses := range t.files {
+		for i := range statuses {
+			if statuses[i].ID != bp.Id {
+				continue
+			}
+
+			switch bpEvent.Body.Reason {
+			case "removed":
+				t.files[file] = append(
+					statuses[:i:i], statuses[i+1:]...,
+				)
+
+			default:
+				statuses[i].Verified = bp.Verified
+				statuses[i].Message = bp.Message
+				if bp.Line != 0 {
+					statuses[i].Line = bp.Line
+				}
+			}
+
+			return
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package debugger
 
 import (
+	"context"
 	"testing"
 
 	"github.com/google/go-dap"
@@ -77,7 +78,7 @@ func TestSetBreakpoints(t *testing.T) {
 		},
 	}
 	
-	resp, err := SetBreakpoints(sessionRef, breakpoints)
+	resp, err := SetBreakpoints(context.Background(), sessionRef, breakpoints)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "setBreakpoints", resp.Command)
@@ -133,7 +134,7 @@ func TestSetBreakpointsValidation(t *testing.T) {
 		system.Receptionist(), sessionKey)[0]
 	
 	// Test with empty breakpoints slice
-	_, err := SetBreakpoints(sessionRef, []BreakpointLocation{})
+	_, err := SetBreakpoints(context.Background(), sessionRef, []BreakpointLocation{})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "no breakpoints provided")
 	
@@ -142,7 +143,7 @@ func TestSetBreakpointsValidation(t *testing.T) {
 		{File: "/path/to/file1.go", Line: 10},
 		{File: "/path/to/file2.go", Line: 20},
 	}
-	_, err = SetBreakpoints(sessionRef, breakpoints)
+	_, err = SetBreakpoints(context.Background(), sessionRef, breakpoints)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "all breakpoints must be for the same file")
 	
@@ -206,7 +207,7 @@ func TestSetFunctionBreakpoints(t *testing.T) {
 		},
 	}
 	
-	resp, err := SetFunctionBreakpoints(sessionRef, functionBreakpoints)
+	resp, err := SetFunctionBreakpoints(context.Background(), sessionRef, functionBreakpoints)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "setFunctionBreakpoints", resp.Command)
@@ -281,7 +282,7 @@ func TestSetSourceBreakpoints(t *testing.T) {
 	sourcePath := "/path/to/main.go"
 	lines := []int{10, 20, 30}
 	
-	resp, err := SetSourceBreakpoints(sessionRef, sourcePath, lines)
+	resp, err := SetSourceBreakpoints(context.Background(), sessionRef, sourcePath, lines)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "setBreakpoints", resp.Command)
@@ -348,7 +349,7 @@ func TestSetSimpleFunctionBreakpoints(t *testing.T) {
 	// Test SetSimpleFunctionBreakpoints convenience function
 	functionNames := []string{"main", "processData"}
 	
-	resp, err := SetSimpleFunctionBreakpoints(sessionRef, functionNames)
+	resp, err := SetSimpleFunctionBreakpoints(context.Background(), sessionRef, functionNames)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "setFunctionBreakpoints", resp.Command)
@@ -405,4 +406,209 @@ func TestFunctionBreakpoint(t *testing.T) {
 	require.Equal(t, "calculateSum", fbp.Name)
 	require.Equal(t, "count > 0", fbp.Condition)
 	require.Equal(t, "== 1", fbp.HitCondition)
-}
\ No newline at end of file
+}
+
+// TestSetBreakpointsStatus tests that the adapter's breakpoints are paired
+// with the requested lines, including moved and unverified breakpoints.
+func TestSetBreakpointsStatus(t *testing.T) {
+	mockSession := NewMockSession()
+	mockSession.SetResponse("setBreakpoints", &dap.SetBreakpointsResponse{
+		Response: dap.Response{
+			Command: "setBreakpoints",
+			Success: true,
+		},
+		Body: dap.SetBreakpointsResponseBody{
+			Breakpoints: []dap.Breakpoint{
+				{Id: 1, Verified: true, Line: 10},
+				{Id: 2, Verified: true, Line: 14},
+				{
+					Verified: false,
+					Message:  "could not find statement",
+				},
+			},
+		},
+	})
+
+	system := actor.NewActorSystem()
+	defer system.Shutdown()
+
+	sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse]("session")
+	sessionRef := actor.RegisterWithSystem(
+		system, "session", sessionKey,
+		actor.NewFunctionBehavior[*DAPRequest, *DAPResponse](
+			mockSession.Receive),
+	)
+
+	statuses, err := SetBreakpointsStatus(context.Background(), sessionRef, []BreakpointLocation{
+		{File: "/path/to/file.go", Line: 10},
+		{File: "/path/to/file.go", Line: 12},
+		{File: "/path/to/file.go", Line: 99},
+		{File: "/path/to/file.go", Line: 100},
+	})
+	require.NoError(t, err)
+	require.Len(t, statuses, 4)
+
+	// An exact match.
+	require.True(t, statuses[0].Verified)
+	require.False(t, statuses[0].Moved())
+	require.Equal(t, 1, statuses[0].ID)
+
+	// The adapter moved this one to the next line with code.
+	require.True(t, statuses[1].Verified)
+	require.True(t, statuses[1].Moved())
+	require.Equal(t, 12, statuses[1].RequestedLine)
+	require.Equal(t, 14, statuses[1].Line)
+
+	// No code on this line.
+	require.False(t, statuses[2].Verified)
+	require.False(t, statuses[2].Moved())
+	require.Equal(t, "could not find statement", statuses[2].Message)
+
+	// The adapter returned fewer breakpoints than requested.
+	require.False(t, statuses[3].Verified)
+	require.NotEmpty(t, statuses[3].Message)
+}
+
+// TestBreakpointTrackerEvents tests that the tracker replaces a file's
+// breakpoints on each request and applies asynchronous breakpoint events.
+func TestBreakpointTrackerEvents(t *testing.T) {
+	mockSession := NewMockSession()
+	mockSession.SetResponse("setBreakpoints", &dap.SetBreakpointsResponse{
+		Response: dap.Response{
+			Command: "setBreakpoints",
+			Success: true,
+		},
+		Body: dap.SetBreakpointsResponseBody{
+			Breakpoints: []dap.Breakpoint{
+				{Id: 1, Verified: false, Message: "pending"},
+				{Id: 2, Verified: true, Line: 20},
+			},
+		},
+	})
+
+	system := actor.NewActorSystem()
+	defer system.Shutdown()
+
+	sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse]("session")
+	sessionRef := actor.RegisterWithSystem(
+		system, "session", sessionKey,
+		actor.NewFunctionBehavior[*DAPRequest, *DAPResponse](
+			mockSession.Receive),
+	)
+
+	bus := NewEventBus()
+	tracker := NewBreakpointTracker(bus)
+	defer tracker.Stop()
+
+	locations := []BreakpointLocation{
+		{File: "/path/to/file.go", Line: 10},
+		{File: "/path/to/file.go", Line: 20},
+	}
+	_, err := tracker.SetBreakpoints(context.Background(), sessionRef, locations)
+	require.NoError(t, err)
+
+	// Setting the same file again should replace, not append.
+	_, err = tracker.SetBreakpoints(context.Background(), sessionRef, locations)
+	require.NoError(t, err)
+	require.Len(t, tracker.Breakpoints(), 2)
+	require.False(t, tracker.Breakpoints()[0].Verified)
+
+	// The adapter later verifies the first breakpoint on another line.
+	bus.Publish(&dap.BreakpointEvent{
+		Body: dap.BreakpointEventBody{
+			Reason: "changed",
+			Breakpoint: dap.Breakpoint{
+				Id: 1, Verified: true, Line: 11,
+			},
+		},
+	})
+
+	statuses := tracker.Breakpoints()
+	require.True(t, statuses[0].Verified)
+	require.Equal(t, 11, statuses[0].Line)
+	require.True(t, statuses[0].Moved())
+	require.Empty(t, statuses[0].Message)
+
+	// And then removes the second one.
+	bus.Publish(&dap.BreakpointEvent{
+		Body: dap.BreakpointEventBody{
+			Reason:     "removed",
+			Breakpoint: dap.Breakpoint{Id: 2},
+		},
+	})
+
+	statuses = tracker.Breakpoints()
+	require.Len(t, statuses, 1)
+	require.Equal(t, 1, statuses[0].ID)
+
+	// After stopping, events no longer affect the tracker.
+	tracker.Stop()
+	bus.Publish(&dap.BreakpointEvent{
+		Body: dap.BreakpointEventBody{
+			Reason:     "removed",
+			Breakpoint: dap.Breakpoint{Id: 1},
+		},
+	})
+	require.Len(t, tracker.Breakpoints(), 1)
+}
+
+// TestBreakpointTrackerRequested tests that the tracker records the source
+// breakpoints and exception filters as requested so they can be reapplied.
+func TestBreakpointTrackerRequested(t *testing.T) {
+	mockSession := NewMockSession()
+	mockSession.SetResponse("setBreakpoints", &dap.SetBreakpointsResponse{
+		Response: dap.Response{
+			Command: "setBreakpoints",
+			Success: true,
+		},
+	})
+	mockSession.SetResponse(
+		"setExceptionBreakpoints", &dap.SetExceptionBreakpointsResponse{
+			Response: dap.Response{
+				Command: "setExceptionBreakpoints",
+				Success: true,
+			},
+		},
+	)
+
+	system := actor.NewActorSystem()
+	defer system.Shutdown()
+
+	sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse]("session")
+	sessionRef := actor.RegisterWithSystem(
+		system, "session", sessionKey,
+		actor.NewFunctionBehavior[*DAPRequest, *DAPResponse](
+			mockSession.Receive),
+	)
+
+	tracker := NewBreakpointTracker(nil)
+	defer tracker.Stop()
+
+	_, err := tracker.SetBreakpoints(context.Background(), sessionRef, []BreakpointLocation{
+		{File: "/b.go", Line: 30, LogMessage: "x={x}"},
+		{File: "/b.go", Line: 10, Condition: "x > 1"},
+	})
+	require.NoError(t, err)
+
+	_, err = tracker.SetBreakpoints(context.Background(), sessionRef, []BreakpointLocation{
+		{File: "/a.go", Line: 5, HitCondition: "3"},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []BreakpointLocation{
+		{File: "/a.go", Line: 5, HitCondition: "3"},
+		{File: "/b.go", Line: 10, Condition: "x > 1"},
+		{File: "/b.go", Line: 30, LogMessage: "x={x}"},
+	}, tracker.SourceBreakpoints())
+
+	require.Empty(t, tracker.ExceptionFilters())
+	err = tracker.SetExceptionBreakpoints(context.Background(), sessionRef, []string{"panic"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"panic"}, tracker.ExceptionFilters())
+
+	requests := mockSession.GetRequests()
+	exceptionReq, ok := requests[len(requests)-1].(
+		*dap.SetExceptionBreakpointsRequest)
+	require.True(t, ok)
+	require.Equal(t, []string{"panic"}, exceptionReq.Arguments.Filters)
+}
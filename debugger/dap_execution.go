@@ -11,7 +11,8 @@ import (
 // Continue resumes execution of the debugged program. If the program was
 // stopped at a breakpoint, this will continue execution until the next
 // breakpoint is hit or the program terminates.
-func Continue(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func Continue(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	threadID int) (*dap.ContinueResponse, error) {
 
 	req := &dap.ContinueRequest{
@@ -26,9 +27,9 @@ func Continue(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +50,8 @@ func Continue(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // Next performs a step over operation. This executes the next line of code
 // but does not step into function calls.
-func Next(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func Next(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	threadID int) (*dap.NextResponse, error) {
 
 	req := &dap.NextRequest{
@@ -64,9 +66,9 @@ func Next(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +84,8 @@ func Next(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // StepIn performs a step into operation. This steps into function calls
 // rather than stepping over them.
-func StepIn(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func StepIn(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	threadID int) (*dap.StepInResponse, error) {
 
 	req := &dap.StepInRequest{
@@ -97,9 +100,9 @@ func StepIn(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +118,8 @@ func StepIn(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // StepOut performs a step out operation. This continues execution until
 // the current function returns.
-func StepOut(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func StepOut(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	threadID int) (*dap.StepOutResponse, error) {
 
 	req := &dap.StepOutRequest{
@@ -130,9 +134,9 @@ func StepOut(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +152,8 @@ func StepOut(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // Pause pauses execution of the debugged program. This is useful for
 // interrupting a running program to examine its state.
-func Pause(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func Pause(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	threadID int) (*dap.PauseResponse, error) {
 
 	req := &dap.PauseRequest{
@@ -163,9 +168,9 @@ func Pause(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
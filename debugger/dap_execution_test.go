@@ -94,7 +94,7 @@ func TestContinue(t *testing.T) {
 	
 	// Test Continue
 	threadID := 1
-	resp, err := Continue(sessionRef, threadID)
+	resp, err := Continue(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "continue", resp.Command)
@@ -148,7 +148,7 @@ func TestNext(t *testing.T) {
 	
 	// Test Next
 	threadID := 1
-	resp, err := Next(sessionRef, threadID)
+	resp, err := Next(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "next", resp.Command)
@@ -201,7 +201,7 @@ func TestStepIn(t *testing.T) {
 	
 	// Test StepIn
 	threadID := 1
-	resp, err := StepIn(sessionRef, threadID)
+	resp, err := StepIn(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "stepIn", resp.Command)
@@ -254,7 +254,7 @@ func TestStepOut(t *testing.T) {
 	
 	// Test StepOut
 	threadID := 1
-	resp, err := StepOut(sessionRef, threadID)
+	resp, err := StepOut(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "stepOut", resp.Command)
@@ -307,7 +307,7 @@ func TestPause(t *testing.T) {
 	
 	// Test Pause
 	threadID := 1
-	resp, err := Pause(sessionRef, threadID)
+	resp, err := Pause(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "pause", resp.Command)
@@ -365,28 +365,28 @@ func TestExecutionCommands(t *testing.T) {
 	threadID := 1
 	
 	// Test Continue
-	continueResp, err := Continue(sessionRef, threadID)
+	continueResp, err := Continue(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.True(t, continueResp.Success)
 	require.True(t, continueResp.Body.AllThreadsContinued)
 	
 	// Test Next
-	nextResp, err := Next(sessionRef, threadID)
+	nextResp, err := Next(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.True(t, nextResp.Success)
 	
 	// Test StepIn
-	stepInResp, err := StepIn(sessionRef, threadID)
+	stepInResp, err := StepIn(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.True(t, stepInResp.Success)
 	
 	// Test StepOut
-	stepOutResp, err := StepOut(sessionRef, threadID)
+	stepOutResp, err := StepOut(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.True(t, stepOutResp.Success)
 	
 	// Test Pause
-	pauseResp, err := Pause(sessionRef, threadID)
+	pauseResp, err := Pause(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.True(t, pauseResp.Success)
 	
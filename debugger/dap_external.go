@@ -8,18 +8,50 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/roasbeef/mcp-debug/internal/metrics"
 )
 
+// DelveConfig configures how Delve servers are started for new sessions.
+type DelveConfig struct {
+	// Path is the dlv executable to run, either a path or a name looked
+	// up in PATH.
+	Path string
+
+	// Retry configures how often starting Delve is attempted before
+	// creating the session fails.
+	Retry RetryConfig
+
+	// Timeouts bounds how long sessions wait for Delve to answer DAP
+	// requests.
+	Timeouts RequestTimeouts
+}
+
+// DefaultDelveConfig returns the configuration that runs the dlv found in
+// PATH with DefaultRetryConfig and DefaultRequestTimeouts.
+func DefaultDelveConfig() DelveConfig {
+	return DelveConfig{
+		Path:     "dlv",
+		Retry:    DefaultRetryConfig,
+		Timeouts: DefaultRequestTimeouts(),
+	}
+}
+
 // launchDelveExternal starts a new Delve DAP process with retry logic.
 // This is a working implementation using external dlv process.
-func launchDelveExternal() (net.Conn, func(), error) {
+func launchDelveExternal(config DelveConfig) (net.Conn, func(), error) {
 	// Use retry logic to launch Delve
 	var conn net.Conn
 	var cleanup func()
 	
-	err := RetryWithBackoff(context.Background(), DefaultRetryConfig, func() error {
+	retry := config.Retry
+	retry.Operation = "spawn_delve"
+	err := RetryWithBackoff(context.Background(), retry, func() error {
 		var retryErr error
-		conn, cleanup, retryErr = launchDelveOnceExternal()
+		conn, cleanup, retryErr = launchDelveOnceExternal(config.Path)
+		if retryErr != nil {
+			metrics.DelveSpawnFailures.Inc()
+		}
 		return retryErr
 	})
 	
@@ -31,11 +63,13 @@ func launchDelveExternal() (net.Conn, func(), error) {
 }
 
 // launchDelveOnceExternal performs a single attempt to launch external Delve
-func launchDelveOnceExternal() (net.Conn, func(), error) {
+// using the given dlv executable.
+func launchDelveOnceExternal(dlv string) (net.Conn, func(), error) {
 	// Find the path to the dlv executable.
-	dlvPath, err := exec.LookPath("dlv")
+	dlvPath, err := exec.LookPath(dlv)
 	if err != nil {
-		return nil, nil, fmt.Errorf("could not find 'dlv' executable: %w", err)
+		return nil, nil, fmt.Errorf("could not find '%s' executable: %w",
+			dlv, err)
 	}
 
 	// Start the Delve DAP server. It will listen on a random free port.
@@ -50,10 +84,15 @@ func launchDelveOnceExternal() (net.Conn, func(), error) {
 		return nil, nil, fmt.Errorf("could not start dlv process: %w", err)
 	}
 
+	// Record the process so that it can be cleaned up by a later server if
+	// we exit without running the cleanup function.
+	removePIDFile := recordDelvePID(cmd.Process.Pid, dlvPath)
+
 	// The cleanup function will be returned to the caller to be executed when
 	// the session is over.
 	cleanup := func() {
 		_ = cmd.Process.Kill()
+		removePIDFile()
 	}
 
 	// Delve prints the listening address to stdout. We need to read it.
@@ -101,6 +140,7 @@ func launchDelveOnceExternal() (net.Conn, func(), error) {
 		InitialDelay: 50 * time.Millisecond,
 		MaxDelay:     200 * time.Millisecond,
 		Multiplier:   2.0,
+		Operation:    "connect_delve",
 	}, func() error {
 		var dialErr error
 		conn, dialErr = net.Dial("tcp", addr)
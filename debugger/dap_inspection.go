@@ -10,10 +10,11 @@ import (
 
 // GetThreadsInfo retrieves information about all threads in the debugged
 // program, returning a slice of ThreadInfo wrapper types for easier handling.
-func GetThreadsInfo(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func GetThreadsInfo(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 ) ([]ThreadInfo, error) {
 
-	resp, err := GetThreads(session)
+	resp, err := GetThreads(ctx, session)
 	if err != nil {
 		return nil, err
 	}
@@ -32,10 +33,11 @@ func GetThreadsInfo(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // GetStackFrames retrieves the call stack for the specified thread,
 // returning a slice of StackFrame wrapper types for easier handling.
-func GetStackFrames(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func GetStackFrames(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	threadID int) ([]StackFrame, error) {
 
-	resp, err := GetStackTrace(session, threadID)
+	resp, err := GetStackTrace(ctx, session, threadID)
 	if err != nil {
 		return nil, err
 	}
@@ -60,10 +62,11 @@ func GetStackFrames(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // GetVariableScopes retrieves the variable scopes for the specified frame,
 // returning a slice of VariableScope wrapper types for easier handling.
-func GetVariableScopes(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func GetVariableScopes(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	frameID int) ([]VariableScope, error) {
 
-	resp, err := GetScopes(session, frameID)
+	resp, err := GetScopes(ctx, session, frameID)
 	if err != nil {
 		return nil, err
 	}
@@ -83,10 +86,11 @@ func GetVariableScopes(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // GetVariableList retrieves variables for the specified scope or variable
 // reference, returning a slice of Variable wrapper types for easier handling.
-func GetVariableList(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func GetVariableList(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	variablesReference int) ([]Variable, error) {
 
-	resp, err := GetVariables(session, variablesReference)
+	resp, err := GetVariables(ctx, session, variablesReference)
 	if err != nil {
 		return nil, err
 	}
@@ -109,10 +113,11 @@ func GetVariableList(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // EvaluateExpressionResult evaluates an expression and returns the result
 // as an EvaluationResult wrapper type for easier handling.
-func EvaluateExpressionResult(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func EvaluateExpressionResult(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	expression string, frameID int) (*EvaluationResult, error) {
 
-	resp, err := EvaluateExpression(session, expression, frameID)
+	resp, err := EvaluateExpression(ctx, session, expression, frameID)
 	if err != nil {
 		return nil, err
 	}
@@ -132,7 +137,8 @@ func EvaluateExpressionResult(session actor.ActorRef[*DAPRequest, *DAPResponse],
 // GetThreads retrieves information about all threads in the debugged program.
 // This is useful for understanding the program's execution state and for
 // targeting specific threads with debugging operations.
-func GetThreads(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func GetThreads(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 ) (*dap.ThreadsResponse, error) {
 
 	req := &dap.ThreadsRequest{
@@ -144,9 +150,9 @@ func GetThreads(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -162,10 +168,23 @@ func GetThreads(session actor.ActorRef[*DAPRequest, *DAPResponse],
 
 // GetStackTrace retrieves the call stack for the specified thread. This
 // provides information about the current execution stack including function
-// names, source locations, and frame IDs for variable inspection.
-func GetStackTrace(session actor.ActorRef[*DAPRequest, *DAPResponse],
+// names, source locations, and frame IDs for variable inspection. Only the
+// debugger's default number of frames is returned, see GetStackTracePage for
+// deeper stacks.
+func GetStackTrace(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	threadID int) (*dap.StackTraceResponse, error) {
 
+	return GetStackTracePage(ctx, session, threadID, 0, 0)
+}
+
+// GetStackTracePage retrieves up to levels frames of the call stack for the
+// specified thread, starting at the frame at depth startFrame. A levels of
+// zero lets the debugger choose how many frames to return.
+func GetStackTracePage(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	threadID, startFrame, levels int) (*dap.StackTraceResponse, error) {
+
 	req := &dap.StackTraceRequest{
 		Request: dap.Request{
 			ProtocolMessage: dap.ProtocolMessage{
@@ -174,13 +193,15 @@ func GetStackTrace(session actor.ActorRef[*DAPRequest, *DAPResponse],
 			Command: "stackTrace",
 		},
 		Arguments: dap.StackTraceArguments{
-			ThreadId: threadID,
+			ThreadId:   threadID,
+			StartFrame: startFrame,
+			Levels:     levels,
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -197,7 +218,8 @@ func GetStackTrace(session actor.ActorRef[*DAPRequest, *DAPResponse],
 // GetScopes retrieves the variable scopes available in the specified stack
 // frame. Scopes typically include local variables, function arguments, and
 // global variables.
-func GetScopes(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func GetScopes(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	frameID int) (*dap.ScopesResponse, error) {
 
 	req := &dap.ScopesRequest{
@@ -212,9 +234,9 @@ func GetScopes(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -231,7 +253,8 @@ func GetScopes(session actor.ActorRef[*DAPRequest, *DAPResponse],
 // GetVariables retrieves the variables available in the specified scope or
 // variable reference. This is used to inspect variable values and their
 // properties during debugging.
-func GetVariables(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func GetVariables(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	variablesReference int) (*dap.VariablesResponse, error) {
 
 	req := &dap.VariablesRequest{
@@ -246,9 +269,9 @@ func GetVariables(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +288,8 @@ func GetVariables(session actor.ActorRef[*DAPRequest, *DAPResponse],
 // EvaluateExpression evaluates an expression in the context of the specified
 // frame and returns the result. This is useful for inspecting complex
 // expressions or calling functions during debugging.
-func EvaluateExpression(session actor.ActorRef[*DAPRequest, *DAPResponse],
+func EvaluateExpression(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
 	expression string, frameID int) (*dap.EvaluateResponse, error) {
 
 	req := &dap.EvaluateRequest{
@@ -282,9 +306,9 @@ func EvaluateExpression(session actor.ActorRef[*DAPRequest, *DAPResponse],
 		},
 	}
 
-	dapReq := &DAPRequest{Request: req}
-	future := session.Ask(context.Background(), dapReq)
-	result, err := future.Await(context.Background()).Unpack()
+	dapReq := &DAPRequest{Ctx: ctx, Request: req}
+	future := session.Ask(ctx, dapReq)
+	result, err := future.Await(ctx).Unpack()
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +96,7 @@ func TestGetThreads(t *testing.T) {
 		system.Receptionist(), sessionKey)[0]
 	
 	// Test GetThreads
-	resp, err := GetThreads(sessionRef)
+	resp, err := GetThreads(context.Background(), sessionRef)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "threads", resp.Command)
@@ -144,7 +144,7 @@ func TestGetThreadsInfo(t *testing.T) {
 		system.Receptionist(), sessionKey)[0]
 	
 	// Test GetThreadsInfo wrapper function
-	threads, err := GetThreadsInfo(sessionRef)
+	threads, err := GetThreadsInfo(context.Background(), sessionRef)
 	require.NoError(t, err)
 	require.Len(t, threads, 3)
 	
@@ -213,7 +213,7 @@ func TestGetStackTrace(t *testing.T) {
 	
 	// Test GetStackTrace
 	threadID := 1
-	resp, err := GetStackTrace(sessionRef, threadID)
+	resp, err := GetStackTrace(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "stackTrace", resp.Command)
@@ -286,7 +286,7 @@ func TestGetStackFrames(t *testing.T) {
 	
 	// Test GetStackFrames wrapper function
 	threadID := 1
-	frames, err := GetStackFrames(sessionRef, threadID)
+	frames, err := GetStackFrames(context.Background(), sessionRef, threadID)
 	require.NoError(t, err)
 	require.Len(t, frames, 2)
 	
@@ -352,7 +352,7 @@ func TestGetScopes(t *testing.T) {
 	
 	// Test GetScopes
 	frameID := 1
-	resp, err := GetScopes(sessionRef, frameID)
+	resp, err := GetScopes(context.Background(), sessionRef, frameID)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "scopes", resp.Command)
@@ -420,7 +420,7 @@ func TestGetVariableScopes(t *testing.T) {
 	
 	// Test GetVariableScopes wrapper function
 	frameID := 1
-	scopes, err := GetVariableScopes(sessionRef, frameID)
+	scopes, err := GetVariableScopes(context.Background(), sessionRef, frameID)
 	require.NoError(t, err)
 	require.Len(t, scopes, 3)
 	
@@ -487,7 +487,7 @@ func TestGetVariables(t *testing.T) {
 	
 	// Test GetVariables
 	variablesReference := 2001
-	resp, err := GetVariables(sessionRef, variablesReference)
+	resp, err := GetVariables(context.Background(), sessionRef, variablesReference)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "variables", resp.Command)
@@ -560,7 +560,7 @@ func TestGetVariableList(t *testing.T) {
 	
 	// Test GetVariableList wrapper function
 	variablesReference := 2001
-	variables, err := GetVariableList(sessionRef, variablesReference)
+	variables, err := GetVariableList(context.Background(), sessionRef, variablesReference)
 	require.NoError(t, err)
 	require.Len(t, variables, 3)
 	
@@ -625,7 +625,7 @@ func TestEvaluateExpression(t *testing.T) {
 	// Test EvaluateExpression
 	expression := "x + 10"
 	frameID := 1
-	resp, err := EvaluateExpression(sessionRef, expression, frameID)
+	resp, err := EvaluateExpression(context.Background(), sessionRef, expression, frameID)
 	require.NoError(t, err)
 	require.NotNil(t, resp)
 	require.Equal(t, "evaluate", resp.Command)
@@ -684,7 +684,7 @@ func TestEvaluateExpressionResult(t *testing.T) {
 	// Test EvaluateExpressionResult wrapper function
 	expression := "config.GetAll()"
 	frameID := 1
-	result, err := EvaluateExpressionResult(sessionRef, expression, frameID)
+	result, err := EvaluateExpressionResult(context.Background(), sessionRef, expression, frameID)
 	require.NoError(t, err)
 	require.NotNil(t, result)
 	
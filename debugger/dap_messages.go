@@ -1,6 +1,8 @@
 package debugger
 
 import (
+	"context"
+
 	"github.com/google/go-dap"
 	"github.com/lightningnetwork/lnd/actor"
 )
@@ -10,6 +12,11 @@ import (
 type DAPRequest struct {
 	actor.BaseMessage
 	Request dap.Message
+
+	// Ctx is the caller's context. The session stops waiting for the
+	// response, and cancels the request, once it's done. A nil Ctx only
+	// applies the session's request timeout.
+	Ctx context.Context
 }
 
 // MessageType returns the string identifier for this message type.
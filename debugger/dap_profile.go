@@ -0,0 +1,488 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
+)
+
+// DefaultProfileDuration is how long ProfileFunctions lets the program run if
+// no duration is configured.
+const DefaultProfileDuration = 5 * time.Second
+
+// ProfileHistogramBounds are the upper bounds of the call duration histogram
+// buckets. Durations include the overhead of stopping at the entry breakpoint
+// and stepping out, so the buckets start at a millisecond.
+var ProfileHistogramBounds = []time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// ProfileEndReason describes why ProfileFunctions stopped collecting.
+type ProfileEndReason string
+
+const (
+	// ProfileDeadline means the configured duration elapsed and the
+	// program was paused.
+	ProfileDeadline ProfileEndReason = "deadline"
+
+	// ProfileOtherStop means the program stopped for a reason other than
+	// a profiled function being called, such as a source breakpoint.
+	ProfileOtherStop ProfileEndReason = "stopped"
+
+	// ProfileTerminated means the program exited.
+	ProfileTerminated ProfileEndReason = "terminated"
+)
+
+// ProfileConfig configures a ProfileFunctions run.
+type ProfileConfig struct {
+	// Functions are the fully qualified names of the functions to count.
+	Functions []string
+
+	// Duration is how long to let the program run. If zero,
+	// DefaultProfileDuration is used.
+	Duration time.Duration
+
+	// Timing enables measuring the wall-clock duration of each call by
+	// stepping out of the function after every entry. This makes the
+	// program run considerably slower.
+	Timing bool
+}
+
+// CallerCount is the number of calls to a function from a given caller.
+type CallerCount struct {
+	// Caller is the name of the calling function.
+	Caller string
+
+	// Calls is the number of calls made by the caller.
+	Calls int
+}
+
+// DurationBucket is a bucket of the call duration histogram.
+type DurationBucket struct {
+	// UpperBound is the inclusive upper bound of the bucket. The last
+	// bucket has an upper bound of zero and holds all longer calls.
+	UpperBound time.Duration
+
+	// Count is the number of calls that fell into the bucket.
+	Count int
+}
+
+// FunctionProfile holds the statistics collected for one function.
+type FunctionProfile struct {
+	// Name is the fully qualified function name.
+	Name string
+
+	// Calls is the number of times the function was entered.
+	Calls int
+
+	// Callers breaks down the calls by calling function, most frequent
+	// first.
+	Callers []CallerCount
+
+	// Returns is the number of calls whose return was observed. It is
+	// only set if timing was enabled.
+	Returns int
+
+	// Total, Min and Max summarise the observed call durations.
+	Total time.Duration
+	Min   time.Duration
+	Max   time.Duration
+
+	// Histogram holds the distribution of the observed call durations
+	// using ProfileHistogramBounds. It is nil if timing was disabled.
+	Histogram []DurationBucket
+}
+
+// Mean returns the mean duration of the calls whose return was observed.
+func (p *FunctionProfile) Mean() time.Duration {
+	if p.Returns == 0 {
+		return 0
+	}
+
+	return p.Total / time.Duration(p.Returns)
+}
+
+// ProfileResult is the outcome of ProfileFunctions.
+type ProfileResult struct {
+	// Reason is why collection ended.
+	Reason ProfileEndReason
+
+	// StopReason is the debugger's reason for the final stop if the
+	// program stopped for another reason.
+	StopReason string
+
+	// Elapsed is how long the program was profiled for.
+	Elapsed time.Duration
+
+	// Functions holds the statistics for each profiled function, most
+	// frequently called first.
+	Functions []FunctionProfile
+
+	// Unresolved lists the requested functions the debugger could not set
+	// a breakpoint on, along with its explanation.
+	Unresolved map[string]string
+
+	// Unmatched counts the function breakpoint hits that couldn't be
+	// attributed to a profiled function, by the name of the function the
+	// program stopped in. Hits are normally attributed by breakpoint ID,
+	// so these only occur if the debugger doesn't report it.
+	Unmatched map[string]int
+}
+
+// profileStop is a stop event along with the time it was received.
+type profileStop struct {
+	event dap.EventMessage
+	at    time.Time
+}
+
+// pendingCall is a call whose return is being waited for by stepping out.
+type pendingCall struct {
+	function string
+	entered  time.Time
+}
+
+// ProfileFunctions counts the calls to the configured functions while the
+// program runs for the configured duration. It replaces the session's function
+// breakpoints with breakpoints on the profiled functions, records the caller
+// of each hit and immediately resumes the program, optionally measuring the
+// time until the call returns. Afterwards the program is left paused and the
+// previous function breakpoints recorded by the tracker are restored. The
+// program must be stopped when ProfileFunctions is called.
+func ProfileFunctions(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	events *EventBus, breakpoints *BreakpointTracker,
+	config ProfileConfig) (*ProfileResult, error) {
+
+	if len(config.Functions) == 0 {
+		return nil, fmt.Errorf("no functions to profile")
+	}
+	if config.Duration <= 0 {
+		config.Duration = DefaultProfileDuration
+	}
+
+	var existing []FunctionBreakpoint
+	if breakpoints != nil {
+		existing = breakpoints.FunctionBreakpoints()
+	}
+
+	profiled := make([]FunctionBreakpoint, len(config.Functions))
+	for i, name := range config.Functions {
+		profiled[i] = FunctionBreakpoint{Name: name}
+	}
+
+	resp, err := SetFunctionBreakpoints(ctx, session, profiled)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set profiling breakpoints: %w",
+			err)
+	}
+	defer func() {
+		// The breakpoints are restored even if the caller gave up.
+		_, err := SetFunctionBreakpoints(
+			context.WithoutCancel(ctx), session, existing,
+		)
+		if err != nil {
+			sessionLog(session).Error("Failed to restore "+
+				"function breakpoints after profiling",
+				"err", err)
+		}
+	}()
+
+	result := &ProfileResult{
+		Unresolved: make(map[string]string),
+		Unmatched:  make(map[string]int),
+	}
+	profiles := make(map[string]*FunctionProfile)
+
+	// byID maps the breakpoint IDs to the requested names, as the name
+	// the debugger reports for a frame may be spelled differently, e.g.
+	// for methods or generic functions.
+	byID := make(map[int]string)
+	for i, name := range config.Functions {
+		if i < len(resp.Body.Breakpoints) {
+			bp := resp.Body.Breakpoints[i]
+			if !bp.Verified {
+				result.Unresolved[name] = bp.Message
+				continue
+			}
+			if bp.Id != 0 {
+				byID[bp.Id] = name
+			}
+		}
+
+		profiles[name] = &FunctionProfile{Name: name}
+		if config.Timing {
+			profiles[name].Histogram = newHistogram()
+		}
+	}
+	if len(profiles) == 0 {
+		return result, fmt.Errorf("none of the functions could be " +
+			"resolved")
+	}
+
+	// Stops are buffered so that the read loop never blocks on us. The
+	// program halts at every stop until we resume it, so only a handful
+	// can ever be outstanding.
+	stops := make(chan profileStop, 16)
+	unsubscribe := events.Subscribe(func(event dap.EventMessage) {
+		switch event.(type) {
+		case *dap.StoppedEvent, *dap.ExitedEvent, *dap.TerminatedEvent:
+		default:
+			return
+		}
+
+		select {
+		case stops <- profileStop{event: event, at: time.Now()}:
+		default:
+			sessionLog(session).Warn("Dropping stop event while " +
+				"profiling, buffer full")
+		}
+	})
+	defer unsubscribe()
+
+	start := time.Now()
+	deadline := time.NewTimer(config.Duration)
+	defer deadline.Stop()
+
+	// pending tracks, per thread, the calls we're stepping out of. Another
+	// breakpoint may interrupt a step out, in which case Delve resumes it
+	// on the next continue, so calls can nest.
+	pending := make(map[int][]pendingCall)
+
+	if _, err := Continue(ctx, session, 0); err != nil {
+		return nil, err
+	}
+
+	for {
+		var stop profileStop
+		select {
+		case stop = <-stops:
+
+		case <-deadline.C:
+			result.Reason = ProfileDeadline
+			result.Elapsed = time.Since(start)
+			pauseAndDrain(ctx, session, stops)
+			result.Functions = sortProfiles(profiles)
+
+			return result, nil
+
+		case <-ctx.Done():
+			// Leave the program paused, as it would be after the
+			// deadline.
+			pauseAndDrain(
+				context.WithoutCancel(ctx), session, stops,
+			)
+
+			return nil, ctx.Err()
+		}
+
+		stopped, ok := stop.event.(*dap.StoppedEvent)
+		if !ok {
+			result.Reason = ProfileTerminated
+			result.Elapsed = time.Since(start)
+			result.Functions = sortProfiles(profiles)
+
+			return result, nil
+		}
+
+		threadID := stopped.Body.ThreadId
+		switch stopped.Body.Reason {
+		case "function breakpoint":
+			function, caller, err := callSite(
+				ctx, session, threadID,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			function = profiledFunction(
+				stopped, byID, profiles, function,
+			)
+			profile, ok := profiles[function]
+			if !ok {
+				result.Unmatched[function]++
+				break
+			}
+			profile.Calls++
+			addCaller(profile, caller)
+
+			if config.Timing {
+				pending[threadID] = append(
+					pending[threadID], pendingCall{
+						function: function,
+						entered:  stop.at,
+					},
+				)
+				_, err := StepOut(ctx, session, threadID)
+				if err != nil {
+					return nil, err
+				}
+
+				continue
+			}
+
+		case "step":
+			calls := pending[threadID]
+			if len(calls) == 0 {
+				break
+			}
+			call := calls[len(calls)-1]
+			pending[threadID] = calls[:len(calls)-1]
+
+			recordDuration(
+				profiles[call.function], stop.at.Sub(call.entered),
+			)
+
+		default:
+			// Leave the program stopped wherever something else
+			// wanted it to stop.
+			result.Reason = ProfileOtherStop
+			result.StopReason = stopped.Body.Reason
+			result.Elapsed = time.Since(start)
+			result.Functions = sortProfiles(profiles)
+
+			return result, nil
+		}
+
+		if _, err := Continue(ctx, session, threadID); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// profiledFunction returns the requested name of the profiled function a
+// function breakpoint stop is for, using the IDs of the breakpoints that were
+// hit. Without a known ID it falls back to the name of the function the
+// thread stopped in.
+func profiledFunction(stopped *dap.StoppedEvent, byID map[int]string,
+	profiles map[string]*FunctionProfile, function string) string {
+
+	for _, id := range stopped.Body.HitBreakpointIds {
+		if name, ok := byID[id]; ok && profiles[name] != nil {
+			return name
+		}
+	}
+
+	return function
+}
+
+// callSite returns the function a thread is stopped in and its caller.
+func callSite(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	threadID int) (string, string, error) {
+
+	frames, err := GetStackFrames(ctx, session, threadID)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch len(frames) {
+	case 0:
+		return "", "", fmt.Errorf("thread %d has no stack frames",
+			threadID)
+
+	case 1:
+		return frames[0].Name, "<none>", nil
+	}
+
+	return frames[0].Name, frames[1].Name, nil
+}
+
+// pauseAndDrain pauses the program and waits briefly for the resulting stop so
+// it doesn't leak into later operations.
+func pauseAndDrain(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	stops <-chan profileStop) {
+
+	if _, err := Pause(ctx, session, 0); err != nil {
+		sessionLog(session).Error("Failed to pause program after "+
+			"profiling", "err", err)
+		return
+	}
+
+	select {
+	case <-stops:
+	case <-time.After(DefaultStopTimeout):
+	}
+}
+
+// addCaller counts a call from the given caller.
+func addCaller(profile *FunctionProfile, caller string) {
+	for i := range profile.Callers {
+		if profile.Callers[i].Caller == caller {
+			profile.Callers[i].Calls++
+			return
+		}
+	}
+
+	profile.Callers = append(profile.Callers, CallerCount{
+		Caller: caller,
+		Calls:  1,
+	})
+}
+
+// newHistogram returns an empty histogram using ProfileHistogramBounds.
+func newHistogram() []DurationBucket {
+	histogram := make([]DurationBucket, len(ProfileHistogramBounds)+1)
+	for i, bound := range ProfileHistogramBounds {
+		histogram[i].UpperBound = bound
+	}
+
+	return histogram
+}
+
+// recordDuration adds an observed call duration to a profile.
+func recordDuration(profile *FunctionProfile, d time.Duration) {
+	if profile == nil {
+		return
+	}
+
+	profile.Returns++
+	profile.Total += d
+	if profile.Min == 0 || d < profile.Min {
+		profile.Min = d
+	}
+	if d > profile.Max {
+		profile.Max = d
+	}
+
+	for i := range profile.Histogram {
+		bound := profile.Histogram[i].UpperBound
+		if bound == 0 || d <= bound {
+			profile.Histogram[i].Count++
+			return
+		}
+	}
+}
+
+// sortProfiles returns the profiles ordered by call count, then name, with
+// each profile's callers ordered the same way.
+func sortProfiles(profiles map[string]*FunctionProfile) []FunctionProfile {
+	sorted := make([]FunctionProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		sort.Slice(profile.Callers, func(i, j int) bool {
+			a, b := profile.Callers[i], profile.Callers[j]
+			if a.Calls != b.Calls {
+				return a.Calls > b.Calls
+			}
+			return a.Caller < b.Caller
+		})
+		sorted = append(sorted, *profile)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Calls != sorted[j].Calls {
+			return sorted[i].Calls > sorted[j].Calls
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return sorted
+}
@@ -0,0 +1,340 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
+	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedStop is a stop the mock profiled program reports when resumed.
+type scriptedStop struct {
+	reason   string
+	function string
+	caller   string
+}
+
+// mockProfiledProgram is a scripted debug session that reports a fixed
+// sequence of stops each time it is resumed. Once the script is exhausted it
+// either terminates or keeps running until paused.
+type mockProfiledProgram struct {
+	events *EventBus
+	script []scriptedStop
+
+	// runForever keeps the program running once the script is exhausted
+	// instead of terminating.
+	runForever bool
+
+	// unresolved is a function the mock can't set a breakpoint on.
+	unresolved string
+
+	// spellings maps requested function names to the names reported in
+	// stack frames, where they differ.
+	spellings map[string]string
+
+	// hitIDs maps the names reported in stack frames to the IDs of the
+	// breakpoints set on them, which are reported with each stop.
+	hitIDs map[string]int
+
+	current  scriptedStop
+	requests []dap.Message
+}
+
+// Receive implements the actor Receive method for the scripted program.
+func (m *mockProfiledProgram) Receive(actorCtx context.Context,
+	msg *DAPRequest) fn.Result[*DAPResponse] {
+
+	m.requests = append(m.requests, msg.Request)
+
+	var resp dap.Message
+	switch req := msg.Request.(type) {
+	case *dap.ContinueRequest:
+		resp = &dap.ContinueResponse{
+			Response: dap.Response{Success: true},
+		}
+		m.resume()
+
+	case *dap.StepOutRequest:
+		resp = &dap.StepOutResponse{
+			Response: dap.Response{Success: true},
+		}
+		m.resume()
+
+	case *dap.PauseRequest:
+		resp = &dap.PauseResponse{Response: dap.Response{Success: true}}
+		m.events.Publish(&dap.StoppedEvent{
+			Body: dap.StoppedEventBody{Reason: "pause", ThreadId: 1},
+		})
+
+	case *dap.SetFunctionBreakpointsRequest:
+		bps := make([]dap.Breakpoint, len(req.Arguments.Breakpoints))
+		m.hitIDs = make(map[string]int)
+		for i, bp := range req.Arguments.Breakpoints {
+			bps[i] = dap.Breakpoint{Id: i + 1, Verified: true}
+			if bp.Name == m.unresolved {
+				bps[i] = dap.Breakpoint{
+					Message: "could not find function",
+				}
+				continue
+			}
+
+			name := bp.Name
+			if spelling, ok := m.spellings[name]; ok {
+				name = spelling
+			}
+			m.hitIDs[name] = bps[i].Id
+		}
+		resp = &dap.SetFunctionBreakpointsResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.SetFunctionBreakpointsResponseBody{
+				Breakpoints: bps,
+			},
+		}
+
+	case *dap.StackTraceRequest:
+		resp = &dap.StackTraceResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.StackTraceResponseBody{
+				StackFrames: []dap.StackFrame{
+					{
+						Id:     1,
+						Name:   m.current.function,
+						Source: &dap.Source{},
+					},
+					{
+						Id:     2,
+						Name:   m.current.caller,
+						Source: &dap.Source{},
+					},
+				},
+			},
+		}
+
+	default:
+		return fn.Err[*DAPResponse](
+			fmt.Errorf("unknown request type: %T", req))
+	}
+
+	return fn.Ok(&DAPResponse{Response: resp})
+}
+
+// resume publishes the next scripted stop.
+func (m *mockProfiledProgram) resume() {
+	if len(m.script) == 0 {
+		if !m.runForever {
+			m.events.Publish(&dap.TerminatedEvent{})
+		}
+		return
+	}
+
+	m.current, m.script = m.script[0], m.script[1:]
+	stop := &dap.StoppedEvent{
+		Body: dap.StoppedEventBody{
+			Reason:   m.current.reason,
+			ThreadId: 1,
+		},
+	}
+	if id, ok := m.hitIDs[m.current.function]; ok &&
+		m.current.reason == "function breakpoint" {
+
+		stop.Body.HitBreakpointIds = []int{id}
+	}
+	m.events.Publish(stop)
+}
+
+// startMockProfiledProgram registers the scripted program as a session actor.
+func startMockProfiledProgram(t *testing.T,
+	program *mockProfiledProgram) actor.ActorRef[*DAPRequest, *DAPResponse] {
+
+	system := actor.NewActorSystem()
+	t.Cleanup(func() { _ = system.Shutdown() })
+
+	sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse]("session")
+
+	return actor.RegisterWithSystem(
+		system, "session", sessionKey,
+		actor.NewFunctionBehavior[*DAPRequest, *DAPResponse](
+			program.Receive),
+	)
+}
+
+// TestProfileFunctionsCounts tests that calls are counted per function and per
+// caller, and that unresolved functions are reported.
+func TestProfileFunctionsCounts(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProfiledProgram{
+		events: bus,
+		script: []scriptedStop{
+			{"function breakpoint", "main.work", "main.main"},
+			{"function breakpoint", "main.work", "main.loop"},
+			{"function breakpoint", "main.helper", "main.work"},
+			{"function breakpoint", "main.work", "main.loop"},
+		},
+		unresolved: "main.missing",
+	}
+	session := startMockProfiledProgram(t, program)
+
+	result, err := ProfileFunctions(context.Background(), session, bus, nil, ProfileConfig{
+		Functions: []string{"main.work", "main.helper", "main.missing"},
+		Duration:  time.Minute,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ProfileTerminated, result.Reason)
+	require.Equal(t, map[string]string{
+		"main.missing": "could not find function",
+	}, result.Unresolved)
+
+	require.Len(t, result.Functions, 2)
+	work := result.Functions[0]
+	require.Equal(t, "main.work", work.Name)
+	require.Equal(t, 3, work.Calls)
+	require.Equal(t, []CallerCount{
+		{Caller: "main.loop", Calls: 2},
+		{Caller: "main.main", Calls: 1},
+	}, work.Callers)
+	require.Nil(t, work.Histogram)
+
+	require.Equal(t, "main.helper", result.Functions[1].Name)
+	require.Equal(t, 1, result.Functions[1].Calls)
+
+	// The original (empty) set of function breakpoints is restored.
+	last := program.requests[len(program.requests)-1]
+	restore, ok := last.(*dap.SetFunctionBreakpointsRequest)
+	require.True(t, ok)
+	require.Empty(t, restore.Arguments.Breakpoints)
+}
+
+// TestProfileFunctionsSpelling tests that hits are attributed by breakpoint ID
+// when the debugger names the function differently than requested, and that
+// hits that can't be attributed are reported.
+func TestProfileFunctionsSpelling(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProfiledProgram{
+		events: bus,
+		script: []scriptedStop{
+			{"function breakpoint", "main.(*Server).handle", "main.main"},
+			{"function breakpoint", "main.(*Server).handle", "main.loop"},
+			{"function breakpoint", "main.other", "main.main"},
+		},
+		spellings: map[string]string{
+			"main.Server.handle": "main.(*Server).handle",
+		},
+	}
+	session := startMockProfiledProgram(t, program)
+
+	result, err := ProfileFunctions(context.Background(), session, bus, nil, ProfileConfig{
+		Functions: []string{"main.Server.handle"},
+		Duration:  time.Minute,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Functions, 1)
+	require.Equal(t, "main.Server.handle", result.Functions[0].Name)
+	require.Equal(t, 2, result.Functions[0].Calls)
+	require.Equal(t, map[string]int{"main.other": 1}, result.Unmatched)
+}
+
+// TestProfileFunctionsTiming tests that call durations are measured by
+// stepping out of each call.
+func TestProfileFunctionsTiming(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProfiledProgram{
+		events: bus,
+		script: []scriptedStop{
+			{"function breakpoint", "main.work", "main.main"},
+			{"step", "main.main", ""},
+			{"function breakpoint", "main.work", "main.main"},
+			{"step", "main.main", ""},
+		},
+	}
+	session := startMockProfiledProgram(t, program)
+
+	result, err := ProfileFunctions(context.Background(), session, bus, nil, ProfileConfig{
+		Functions: []string{"main.work"},
+		Duration:  time.Minute,
+		Timing:    true,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Functions, 1)
+
+	work := result.Functions[0]
+	require.Equal(t, 2, work.Calls)
+	require.Equal(t, 2, work.Returns)
+	require.LessOrEqual(t, work.Min, work.Max)
+
+	var histogramCalls int
+	for _, bucket := range work.Histogram {
+		histogramCalls += bucket.Count
+	}
+	require.Equal(t, 2, histogramCalls)
+
+	var stepOuts int
+	for _, req := range program.requests {
+		if _, ok := req.(*dap.StepOutRequest); ok {
+			stepOuts++
+		}
+	}
+	require.Equal(t, 2, stepOuts)
+}
+
+// TestProfileFunctionsDeadline tests that the program is paused once the
+// duration elapses, and that other stops end the profile early.
+func TestProfileFunctionsDeadline(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProfiledProgram{
+		events: bus,
+		script: []scriptedStop{
+			{"function breakpoint", "main.work", "main.main"},
+		},
+		runForever: true,
+	}
+	session := startMockProfiledProgram(t, program)
+
+	result, err := ProfileFunctions(context.Background(), session, bus, nil, ProfileConfig{
+		Functions: []string{"main.work"},
+		Duration:  50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ProfileDeadline, result.Reason)
+	require.Equal(t, 1, result.Functions[0].Calls)
+
+	var paused bool
+	for _, req := range program.requests {
+		if _, ok := req.(*dap.PauseRequest); ok {
+			paused = true
+		}
+	}
+	require.True(t, paused)
+
+	program.script = []scriptedStop{
+		{"breakpoint", "main.other", "main.main"},
+	}
+	result, err = ProfileFunctions(context.Background(), session, bus, nil, ProfileConfig{
+		Functions: []string{"main.work"},
+		Duration:  time.Minute,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ProfileOtherStop, result.Reason)
+	require.Equal(t, "breakpoint", result.StopReason)
+}
+
+// TestRecordDuration tests the duration statistics and histogram buckets.
+func TestRecordDuration(t *testing.T) {
+	profile := &FunctionProfile{Histogram: newHistogram()}
+
+	recordDuration(profile, 500*time.Microsecond)
+	recordDuration(profile, 5*time.Millisecond)
+	recordDuration(profile, time.Minute)
+
+	require.Equal(t, 3, profile.Returns)
+	require.Equal(t, 500*time.Microsecond, profile.Min)
+	require.Equal(t, time.Minute, profile.Max)
+	require.Equal(t, 1, profile.Histogram[0].Count)
+	require.Equal(t, 1, profile.Histogram[1].Count)
+	require.Equal(t, 1, profile.Histogram[len(profile.Histogram)-1].Count)
+}
@@ -0,0 +1,418 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
+)
+
+const (
+	// DefaultRunUntilMaxSteps is the number of steps RunUntil takes before
+	// giving up if no limit is configured.
+	DefaultRunUntilMaxSteps = 100
+
+	// DefaultStopTimeout is how long to wait for the program to stop
+	// after resuming it before assuming it is blocked.
+	DefaultStopTimeout = 10 * time.Second
+)
+
+// RunUntilReason describes why RunUntil finished.
+type RunUntilReason string
+
+const (
+	// RunUntilConditionMet means the condition evaluated to true.
+	RunUntilConditionMet RunUntilReason = "condition_met"
+
+	// RunUntilStepLimit means the step limit was reached before the
+	// condition became true.
+	RunUntilStepLimit RunUntilReason = "step_limit"
+
+	// RunUntilOtherStop means the program stopped for another reason, such
+	// as hitting a breakpoint or panicking.
+	RunUntilOtherStop RunUntilReason = "stopped"
+
+	// RunUntilTerminated means the program exited.
+	RunUntilTerminated RunUntilReason = "terminated"
+
+	// RunUntilTimeout means the program didn't stop in time after being
+	// resumed, and was paused.
+	RunUntilTimeout RunUntilReason = "timeout"
+)
+
+// RunUntilConfig configures a RunUntil operation.
+type RunUntilConfig struct {
+	// ThreadID is the thread (goroutine) to step.
+	ThreadID int
+
+	// Condition is the Go expression that should become true.
+	Condition string
+
+	// Function, if set, switches from line stepping to running the
+	// program until a temporary breakpoint on this function is hit with
+	// the condition true. The condition is then evaluated by the debugger
+	// itself in the context of the function.
+	Function string
+
+	// MaxSteps is the maximum number of line steps to take. If zero,
+	// DefaultRunUntilMaxSteps is used. It is ignored in function mode.
+	MaxSteps int
+
+	// StopTimeout is how long to wait for each step or continue to stop.
+	// If zero, DefaultStopTimeout is used.
+	StopTimeout time.Duration
+}
+
+// RunUntilResult describes the outcome of a RunUntil operation.
+type RunUntilResult struct {
+	// Reason is why the operation finished.
+	Reason RunUntilReason
+
+	// Steps is the number of line steps taken.
+	Steps int
+
+	// StopReason is the debugger's reason for the final stop, e.g. "step",
+	// "breakpoint" or "exception".
+	StopReason string
+
+	// Location is where the program is stopped. It is nil if the program
+	// terminated.
+	Location *StopLocation
+
+	// LastError is the most recent error from evaluating the condition,
+	// e.g. because a variable was out of scope after stepping.
+	LastError string
+}
+
+// RunUntil repeatedly steps the given thread by line until the configured
+// condition evaluates to true in the top stack frame, the step limit is
+// reached, or the program stops for another reason. In function mode it
+// instead runs to a temporary conditional breakpoint on the configured
+// function. The program must be stopped when RunUntil is called.
+func RunUntil(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	events *EventBus, breakpoints *BreakpointTracker,
+	config RunUntilConfig) (*RunUntilResult, error) {
+
+	if config.Condition == "" {
+		return nil, fmt.Errorf("no condition provided")
+	}
+	if config.MaxSteps <= 0 {
+		config.MaxSteps = DefaultRunUntilMaxSteps
+	}
+	if config.StopTimeout <= 0 {
+		config.StopTimeout = DefaultStopTimeout
+	}
+
+	if config.Function != "" {
+		return runUntilFunction(
+			ctx, session, events, breakpoints, config,
+		)
+	}
+
+	return runUntilStepping(ctx, session, events, config)
+}
+
+// runUntilStepping implements the line stepping mode of RunUntil.
+func runUntilStepping(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	events *EventBus, config RunUntilConfig) (*RunUntilResult, error) {
+
+	result := &RunUntilResult{}
+	threadID := config.ThreadID
+
+	// The condition may already hold, in which case there is nothing to
+	// do.
+	location, err := TopStopLocation(ctx, session, threadID)
+	if err != nil {
+		return nil, err
+	}
+	met, evalErr := evaluateCondition(
+		ctx, session, config.Condition, location.FrameID,
+	)
+	if met {
+		result.Reason = RunUntilConditionMet
+		result.Location = location
+		return result, nil
+	}
+	if evalErr != nil {
+		result.LastError = evalErr.Error()
+	}
+
+	for result.Steps < config.MaxSteps {
+		stop, err := StepAndWait(
+			ctx, session, events, threadID, StepOver,
+			config.StopTimeout,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result.Steps++
+
+		done, err := finishOnStop(ctx, session, result, stop, "step")
+		if err != nil || done {
+			return result, err
+		}
+
+		threadID = result.Location.ThreadID
+		met, evalErr := evaluateCondition(
+			ctx, session, config.Condition,
+			result.Location.FrameID,
+		)
+		if met {
+			result.Reason = RunUntilConditionMet
+			result.LastError = ""
+			return result, nil
+		}
+		if evalErr != nil {
+			result.LastError = evalErr.Error()
+		}
+	}
+
+	result.Reason = RunUntilStepLimit
+
+	return result, nil
+}
+
+// runUntilFunction implements the function breakpoint mode of RunUntil. The
+// temporary breakpoint is added alongside the existing function breakpoints,
+// which are restored afterwards.
+func runUntilFunction(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	events *EventBus, breakpoints *BreakpointTracker,
+	config RunUntilConfig) (*RunUntilResult, error) {
+
+	var existing []FunctionBreakpoint
+	if breakpoints != nil {
+		existing = breakpoints.FunctionBreakpoints()
+	}
+	temporary := append(existing[:len(existing):len(existing)],
+		FunctionBreakpoint{
+			Name:      config.Function,
+			Condition: config.Condition,
+		},
+	)
+
+	resp, err := SetFunctionBreakpoints(ctx, session, temporary)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set temporary breakpoint: %w",
+			err)
+	}
+	if bps := resp.Body.Breakpoints; len(bps) == len(temporary) &&
+		!bps[len(bps)-1].Verified {
+
+		// Restore the original set before bailing out.
+		_, _ = SetFunctionBreakpoints(
+			context.WithoutCancel(ctx), session, existing,
+		)
+
+		return nil, fmt.Errorf("unable to set breakpoint on function "+
+			"%s: %s", config.Function, bps[len(bps)-1].Message)
+	}
+
+	defer func() {
+		// The breakpoints are restored even if the caller gave up.
+		_, err := SetFunctionBreakpoints(
+			context.WithoutCancel(ctx), session, existing,
+		)
+		if err != nil {
+			sessionLog(session).Error("Failed to restore "+
+				"function breakpoints after run_until",
+				"err", err)
+		}
+	}()
+
+	stop, err := resumeAndWait(ctx, session, events, config.StopTimeout,
+		func() error {
+			_, err := Continue(ctx, session, config.ThreadID)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RunUntilResult{}
+	done, err := finishOnStop(
+		ctx, session, result, stop, "function breakpoint",
+	)
+	if err != nil || done {
+		return result, err
+	}
+
+	// Any function breakpoint could have been hit, so make sure it's the
+	// temporary one by checking where we are.
+	if result.Location.Function != config.Function {
+		result.Reason = RunUntilOtherStop
+		return result, nil
+	}
+
+	result.Reason = RunUntilConditionMet
+
+	return result, nil
+}
+
+// finishOnStop records the stop in the result and reports whether it ends the
+// operation, which is the case if the program terminated, timed out, or
+// stopped for a reason other than the expected one.
+func finishOnStop(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	result *RunUntilResult, stop dap.EventMessage,
+	expectedReason string) (bool, error) {
+
+	if stop == nil {
+		result.Reason = RunUntilTimeout
+		return true, nil
+	}
+
+	stopped, ok := stop.(*dap.StoppedEvent)
+	if !ok {
+		result.Reason = RunUntilTerminated
+		result.Location = nil
+		return true, nil
+	}
+
+	result.StopReason = stopped.Body.Reason
+
+	location, err := TopStopLocation(
+		ctx, session, stopped.Body.ThreadId,
+	)
+	if err != nil {
+		return true, err
+	}
+	result.Location = location
+
+	if stopped.Body.Reason != expectedReason {
+		result.Reason = RunUntilOtherStop
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// evaluateCondition evaluates a boolean Go expression in the given frame.
+func evaluateCondition(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	condition string, frameID int) (bool, error) {
+
+	result, err := EvaluateExpressionResult(
+		ctx, session, condition, frameID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.Result == "true", nil
+}
+
+// StepKind selects the kind of step taken by StepAndWait.
+type StepKind int
+
+const (
+	// StepOver steps to the next line without entering calls.
+	StepOver StepKind = iota
+
+	// StepInto steps into function calls.
+	StepInto
+
+	// StepOutOf steps out of the current function.
+	StepOutOf
+)
+
+// StepAndWait performs a single step of the given kind on a thread and waits
+// for the resulting stopped, exited or terminated event. If the program
+// doesn't stop within the timeout it is paused and a nil event is returned.
+func StepAndWait(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	events *EventBus, threadID int, kind StepKind,
+	timeout time.Duration) (dap.EventMessage, error) {
+
+	return resumeAndWait(ctx, session, events, timeout, func() error {
+		var err error
+		switch kind {
+		case StepInto:
+			_, err = StepIn(ctx, session, threadID)
+
+		case StepOutOf:
+			_, err = StepOut(ctx, session, threadID)
+
+		default:
+			_, err = Next(ctx, session, threadID)
+		}
+
+		return err
+	})
+}
+
+// resumeAndWait subscribes to the next stop, resumes execution using the given
+// function and waits for the program to stop. If it doesn't stop within the
+// timeout, the program is paused and a nil event is returned.
+func resumeAndWait(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	events *EventBus, timeout time.Duration,
+	resume func() error) (dap.EventMessage, error) {
+
+	stops, unsubscribe := events.NextStop()
+	defer unsubscribe()
+
+	if err := resume(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case stop := <-stops:
+		return stop, nil
+
+	case <-time.After(timeout):
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	// The program is most likely blocked. Pause it so the caller regains
+	// control, and swallow the resulting stop so it isn't mistaken for
+	// the end of a later step.
+	sessionLog(session).Info("Program did not stop in time, pausing",
+		"timeout", timeout)
+
+	if _, err := Pause(ctx, session, 0); err != nil {
+		return nil, fmt.Errorf("program did not stop within %v and "+
+			"could not be paused: %w", timeout, err)
+	}
+
+	wait, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	select {
+	case <-stops:
+	case <-wait.Done():
+	}
+
+	return nil, nil
+}
+
+// TopStopLocation returns the location of the top stack frame of the given
+// thread.
+func TopStopLocation(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	threadID int) (*StopLocation, error) {
+
+	frames, err := GetStackFrames(ctx, session, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("thread %d has no stack frames",
+			threadID)
+	}
+
+	return &StopLocation{
+		ThreadID: threadID,
+		FrameID:  frames[0].ID,
+		Function: frames[0].Name,
+		File:     frames[0].Source.Path,
+		Line:     frames[0].Line,
+	}, nil
+}
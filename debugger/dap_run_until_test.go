@@ -0,0 +1,282 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
+	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProgram is a scripted debug session that simulates a program stepping
+// through consecutive lines of a single function. Each step publishes a
+// stopped event on the bus like the real session's read loop would.
+type mockProgram struct {
+	events *EventBus
+
+	// line is the current line of the program.
+	line int
+
+	// trueFrom is the first line at which conditions evaluate to true.
+	trueFrom int
+
+	// exitAt, if non-zero, is the line at which the program exits.
+	exitAt int
+
+	// function is the function reported in the top stack frame.
+	function string
+
+	// returnAt, if non-zero, is the line at which function returns to
+	// main.main.
+	returnAt int
+
+	// recursion is the number of main.recurse frames above function, as
+	// if it had recursed. Like Delve, only the innermost 50 frames are
+	// returned unless more are requested.
+	recursion int
+
+	requests []dap.Message
+}
+
+// Receive implements the actor Receive method for the scripted program.
+func (m *mockProgram) Receive(actorCtx context.Context,
+	msg *DAPRequest) fn.Result[*DAPResponse] {
+
+	m.requests = append(m.requests, msg.Request)
+
+	var resp dap.Message
+	switch req := msg.Request.(type) {
+	case *dap.NextRequest:
+		m.line++
+		resp = &dap.NextResponse{Response: dap.Response{Success: true}}
+		m.publishStep(req.Arguments.ThreadId)
+
+	case *dap.StepInRequest:
+		m.line++
+		resp = &dap.StepInResponse{
+			Response: dap.Response{Success: true},
+		}
+		m.publishStep(req.Arguments.ThreadId)
+
+	case *dap.ContinueRequest:
+		m.line = m.trueFrom
+		resp = &dap.ContinueResponse{
+			Response: dap.Response{Success: true},
+		}
+		m.events.Publish(&dap.StoppedEvent{
+			Body: dap.StoppedEventBody{
+				Reason:   "function breakpoint",
+				ThreadId: req.Arguments.ThreadId,
+			},
+		})
+
+	case *dap.SetFunctionBreakpointsRequest:
+		bps := make([]dap.Breakpoint, len(req.Arguments.Breakpoints))
+		for i := range bps {
+			bps[i] = dap.Breakpoint{Id: i + 1, Verified: true}
+		}
+		resp = &dap.SetFunctionBreakpointsResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.SetFunctionBreakpointsResponseBody{
+				Breakpoints: bps,
+			},
+		}
+
+	case *dap.StackTraceRequest:
+		frames := []dap.StackFrame{{
+			Id:     1000 + m.line,
+			Name:   m.function,
+			Line:   m.line,
+			Source: &dap.Source{Path: "/src/main.go"},
+		}}
+		if m.returnAt != 0 && m.line >= m.returnAt {
+			frames[0].Name = "main.main"
+		} else if m.returnAt != 0 {
+			frames = append(frames, dap.StackFrame{
+				Id:     2000,
+				Name:   "main.main",
+				Source: &dap.Source{Path: "/src/main.go"},
+			})
+		}
+		for i := 0; i < m.recursion; i++ {
+			frames = append([]dap.StackFrame{{
+				Id:     3000 + i,
+				Name:   "main.recurse",
+				Line:   m.line,
+				Source: &dap.Source{Path: "/src/main.go"},
+			}}, frames...)
+		}
+
+		levels := req.Arguments.Levels
+		if levels == 0 {
+			levels = 50
+		}
+		frames = frames[min(req.Arguments.StartFrame, len(frames)):]
+		frames = frames[:min(levels, len(frames))]
+		resp = &dap.StackTraceResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.StackTraceResponseBody{
+				StackFrames: frames,
+			},
+		}
+
+	case *dap.EvaluateRequest:
+		resp = &dap.EvaluateResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.EvaluateResponseBody{
+				Result: fmt.Sprintf("%v", m.line >= m.trueFrom),
+			},
+		}
+
+	default:
+		return fn.Err[*DAPResponse](
+			fmt.Errorf("unknown request type: %T", req))
+	}
+
+	return fn.Ok(&DAPResponse{Response: resp})
+}
+
+// publishStep publishes the event that ends a step, which is a terminated
+// event once the program reaches exitAt.
+func (m *mockProgram) publishStep(threadID int) {
+	if m.exitAt != 0 && m.line >= m.exitAt {
+		m.events.Publish(&dap.TerminatedEvent{})
+		return
+	}
+
+	m.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{
+			Reason:   "step",
+			ThreadId: threadID,
+		},
+	})
+}
+
+// startMockProgram registers the scripted program as a session actor.
+func startMockProgram(t *testing.T,
+	program *mockProgram) actor.ActorRef[*DAPRequest, *DAPResponse] {
+
+	system := actor.NewActorSystem()
+	t.Cleanup(func() { _ = system.Shutdown() })
+
+	sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse]("session")
+
+	return actor.RegisterWithSystem(
+		system, "session", sessionKey,
+		actor.NewFunctionBehavior[*DAPRequest, *DAPResponse](
+			program.Receive),
+	)
+}
+
+// TestRunUntilStepping tests the line stepping mode of RunUntil.
+func TestRunUntilStepping(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProgram{
+		events: bus, line: 10, trueFrom: 14, function: "main.main",
+	}
+	session := startMockProgram(t, program)
+
+	result, err := RunUntil(context.Background(), session, bus, nil, RunUntilConfig{
+		ThreadID:    1,
+		Condition:   "i == 4",
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, RunUntilConditionMet, result.Reason)
+	require.Equal(t, 4, result.Steps)
+	require.Equal(t, 14, result.Location.Line)
+	require.Equal(t, 1014, result.Location.FrameID)
+
+	// A condition that already holds shouldn't step at all.
+	result, err = RunUntil(context.Background(), session, bus, nil, RunUntilConfig{
+		ThreadID:    1,
+		Condition:   "i == 4",
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, RunUntilConditionMet, result.Reason)
+	require.Zero(t, result.Steps)
+
+	// The step limit stops the search.
+	program.line, program.trueFrom = 10, 100
+	result, err = RunUntil(context.Background(), session, bus, nil, RunUntilConfig{
+		ThreadID:    1,
+		Condition:   "i == 90",
+		MaxSteps:    5,
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, RunUntilStepLimit, result.Reason)
+	require.Equal(t, 5, result.Steps)
+	require.Equal(t, 15, result.Location.Line)
+
+	// The program exiting ends the search.
+	program.line, program.exitAt = 10, 12
+	result, err = RunUntil(context.Background(), session, bus, nil, RunUntilConfig{
+		ThreadID:    1,
+		Condition:   "i == 90",
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, RunUntilTerminated, result.Reason)
+	require.Equal(t, 2, result.Steps)
+	require.Nil(t, result.Location)
+
+	_, err = RunUntil(context.Background(), session, bus, nil, RunUntilConfig{ThreadID: 1})
+	require.ErrorContains(t, err, "no condition")
+}
+
+// TestRunUntilFunction tests that the function mode of RunUntil sets a
+// temporary conditional breakpoint and restores the previous ones afterwards.
+func TestRunUntilFunction(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProgram{
+		events: bus, line: 10, trueFrom: 42,
+		function: "main.process",
+	}
+	session := startMockProgram(t, program)
+
+	tracker := NewBreakpointTracker(bus)
+	defer tracker.Stop()
+
+	_, err := tracker.SetFunctionBreakpoints(context.Background(), session, []FunctionBreakpoint{
+		{Name: "main.main"},
+	})
+	require.NoError(t, err)
+
+	result, err := RunUntil(context.Background(), session, bus, tracker, RunUntilConfig{
+		ThreadID:    1,
+		Condition:   "task.ID == 3",
+		Function:    "main.process",
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, RunUntilConditionMet, result.Reason)
+	require.Equal(t, "function breakpoint", result.StopReason)
+	require.Equal(t, 42, result.Location.Line)
+
+	// The temporary breakpoint was set alongside the existing one, and
+	// the existing set was restored afterwards.
+	var sets []*dap.SetFunctionBreakpointsRequest
+	for _, req := range program.requests {
+		if set, ok := req.(*dap.SetFunctionBreakpointsRequest); ok {
+			sets = append(sets, set)
+		}
+	}
+	require.Len(t, sets, 3)
+
+	temporary := sets[1].Arguments.Breakpoints
+	require.Len(t, temporary, 2)
+	require.Equal(t, "main.process", temporary[1].Name)
+	require.Equal(t, "task.ID == 3", temporary[1].Condition)
+
+	restored := sets[2].Arguments.Breakpoints
+	require.Len(t, restored, 1)
+	require.Equal(t, "main.main", restored[0].Name)
+	require.Equal(t, []FunctionBreakpoint{{Name: "main.main"}},
+		tracker.FunctionBreakpoints())
+}
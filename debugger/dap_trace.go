@@ -0,0 +1,275 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
+)
+
+// DefaultTraceMaxSteps is the number of steps TraceExecution records if no
+// limit is configured.
+const DefaultTraceMaxSteps = 50
+
+// traceStackPage is the number of frames requested at a time when searching
+// a stack deeper than the debugger returns by default for the traced
+// function.
+const traceStackPage = 1000
+
+// TraceEndReason describes why TraceExecution stopped recording.
+type TraceEndReason string
+
+const (
+	// TraceStepLimit means the configured number of steps was recorded.
+	TraceStepLimit TraceEndReason = "step_limit"
+
+	// TraceLeftFunction means the traced function returned.
+	TraceLeftFunction TraceEndReason = "left_function"
+
+	// TraceOtherStop means the program stopped for another reason, such as
+	// hitting a breakpoint.
+	TraceOtherStop TraceEndReason = "stopped"
+
+	// TraceTerminated means the program exited.
+	TraceTerminated TraceEndReason = "terminated"
+
+	// TraceTimeout means a step didn't complete in time and the program
+	// was paused.
+	TraceTimeout TraceEndReason = "timeout"
+)
+
+// TraceConfig configures an execution trace.
+type TraceConfig struct {
+	// ThreadID is the thread (goroutine) to trace.
+	ThreadID int
+
+	// MaxSteps is the maximum number of steps to take. If zero,
+	// DefaultTraceMaxSteps is used.
+	MaxSteps int
+
+	// StepIn makes the trace step into function calls rather than over
+	// them.
+	StepIn bool
+
+	// Function, if set, ends the trace once this function is no longer on
+	// the thread's stack.
+	Function string
+
+	// Expressions are evaluated in the top frame at every step.
+	Expressions []string
+
+	// StopTimeout is how long to wait for each step to complete. If zero,
+	// DefaultStopTimeout is used.
+	StopTimeout time.Duration
+}
+
+// TraceStep is a single line visited during a trace.
+type TraceStep struct {
+	// Step is the number of steps taken to reach this line. The initial
+	// location is step zero.
+	Step int
+
+	// Function is the function being executed.
+	Function string
+
+	// File is the path to the source file.
+	File string
+
+	// Line is the line number in the source file (1-based).
+	Line int
+
+	// Values holds the value of each configured expression, in order.
+	// Expressions that fail to evaluate, e.g. because a variable is not in
+	// scope, are recorded as "<error: ...>".
+	Values []string
+}
+
+// TraceResult is the outcome of TraceExecution.
+type TraceResult struct {
+	// Expressions are the expressions evaluated at each step.
+	Expressions []string
+
+	// Steps are the recorded steps, starting at the initial location.
+	Steps []TraceStep
+
+	// Reason is why recording stopped.
+	Reason TraceEndReason
+
+	// StopReason is the debugger's reason for the final stop if the trace
+	// ended on an unexpected stop.
+	StopReason string
+}
+
+// TraceExecution single-steps the given thread, recording each visited line
+// and the values of the configured expressions, until the step limit is
+// reached, the configured function returns, or the program stops for another
+// reason. The program must be stopped when TraceExecution is called.
+func TraceExecution(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	events *EventBus, config TraceConfig) (*TraceResult, error) {
+
+	if config.MaxSteps <= 0 {
+		config.MaxSteps = DefaultTraceMaxSteps
+	}
+	if config.StopTimeout <= 0 {
+		config.StopTimeout = DefaultStopTimeout
+	}
+
+	stepKind := StepOver
+	if config.StepIn {
+		stepKind = StepInto
+	}
+
+	result := &TraceResult{Expressions: config.Expressions}
+	threadID := config.ThreadID
+
+	step, inFunction, err := recordTraceStep(
+		ctx, session, threadID, 0, config,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !inFunction {
+		return nil, fmt.Errorf("function %s is not on the stack of "+
+			"thread %d", config.Function, threadID)
+	}
+	result.Steps = append(result.Steps, *step)
+
+	for i := 1; i <= config.MaxSteps; i++ {
+		stop, err := StepAndWait(
+			ctx, session, events, threadID, stepKind,
+			config.StopTimeout,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		switch stop := stop.(type) {
+		case nil:
+			result.Reason = TraceTimeout
+			return result, nil
+
+		case *dap.StoppedEvent:
+			threadID = stop.Body.ThreadId
+			if stop.Body.Reason != "step" {
+				result.Reason = TraceOtherStop
+				result.StopReason = stop.Body.Reason
+			}
+
+		default:
+			result.Reason = TraceTerminated
+			return result, nil
+		}
+
+		step, inFunction, err := recordTraceStep(
+			ctx, session, threadID, i, config,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Stepping out of the traced function lands in its caller,
+		// which isn't part of the trace.
+		if !inFunction {
+			result.Reason = TraceLeftFunction
+			return result, nil
+		}
+		result.Steps = append(result.Steps, *step)
+
+		if result.Reason == TraceOtherStop {
+			return result, nil
+		}
+	}
+
+	result.Reason = TraceStepLimit
+
+	return result, nil
+}
+
+// recordTraceStep captures the current location of a thread and evaluates the
+// trace expressions there. It also reports whether the traced function, if
+// any, is still on the stack.
+func recordTraceStep(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse],
+	threadID, stepNum int, config TraceConfig) (*TraceStep, bool, error) {
+
+	frames, err := GetStackFrames(ctx, session, threadID)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(frames) == 0 {
+		return nil, false, fmt.Errorf("thread %d has no stack frames",
+			threadID)
+	}
+
+	// The debugger only returns the innermost frames by default, so the
+	// rest of a deep stack, e.g. of a recursive function, is searched
+	// page by page.
+	inFunction := config.Function == ""
+	for _, frame := range frames {
+		if frame.Name == config.Function {
+			inFunction = true
+			break
+		}
+	}
+	if !inFunction {
+		inFunction, err = onStack(
+			ctx, session, threadID, config.Function, len(frames),
+		)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	top := frames[0]
+	step := &TraceStep{
+		Step:     stepNum,
+		Function: top.Name,
+		File:     top.Source.Path,
+		Line:     top.Line,
+		Values:   make([]string, len(config.Expressions)),
+	}
+	for i, expr := range config.Expressions {
+		value, err := EvaluateExpressionResult(
+			ctx, session, expr, top.ID,
+		)
+		if err != nil {
+			step.Values[i] = fmt.Sprintf("<error: %v>", err)
+			continue
+		}
+		step.Values[i] = value.Result
+	}
+
+	return step, inFunction, nil
+}
+
+// onStack reports whether a function has a frame on a thread's stack at or
+// below the given depth.
+func onStack(ctx context.Context,
+	session actor.ActorRef[*DAPRequest, *DAPResponse], threadID int,
+	function string, depth int) (bool, error) {
+
+	for {
+		resp, err := GetStackTracePage(
+			ctx, session, threadID, depth, traceStackPage,
+		)
+		if err != nil {
+			return false, err
+		}
+
+		frames := resp.Body.StackFrames
+		for _, frame := range frames {
+			if frame.Name == function {
+				return true, nil
+			}
+		}
+
+		// A short page is the bottom of the stack.
+		if len(frames) < traceStackPage {
+			return false, nil
+		}
+		depth += len(frames)
+	}
+}
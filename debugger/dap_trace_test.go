@@ -0,0 +1,102 @@
+package debugger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTraceExecution tests that each visited line is recorded along with the
+// trace expressions, and that the trace ends at the step limit or when the
+// traced function returns.
+func TestTraceExecution(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProgram{
+		events: bus, line: 10, trueFrom: 12,
+		function: "main.process",
+	}
+	session := startMockProgram(t, program)
+
+	result, err := TraceExecution(context.Background(), session, bus, TraceConfig{
+		ThreadID:    1,
+		MaxSteps:    3,
+		Expressions: []string{"done"},
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TraceStepLimit, result.Reason)
+	require.Len(t, result.Steps, 4)
+
+	for i, step := range result.Steps {
+		require.Equal(t, i, step.Step)
+		require.Equal(t, 10+i, step.Line)
+		require.Equal(t, "main.process", step.Function)
+	}
+	require.Equal(t, []string{"false"}, result.Steps[1].Values)
+	require.Equal(t, []string{"true"}, result.Steps[2].Values)
+
+	// When tracing a function, the trace ends once it returns and the
+	// caller's line isn't recorded. Stepping in uses StepIn requests.
+	program.line, program.returnAt = 10, 13
+	result, err = TraceExecution(context.Background(), session, bus, TraceConfig{
+		ThreadID:    1,
+		StepIn:      true,
+		Function:    "main.process",
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TraceLeftFunction, result.Reason)
+	require.Len(t, result.Steps, 3)
+	require.Equal(t, 12, result.Steps[2].Line)
+
+	var stepIns int
+	for _, req := range program.requests {
+		if _, ok := req.(*dap.StepInRequest); ok {
+			stepIns++
+		}
+	}
+	require.Equal(t, 3, stepIns)
+
+	// Tracing a function that isn't on the stack is an error.
+	_, err = TraceExecution(context.Background(), session, bus, TraceConfig{
+		ThreadID:    1,
+		Function:    "main.other",
+		StopTimeout: time.Second,
+	})
+	require.ErrorContains(t, err, "not on the stack")
+}
+
+// TestTraceExecutionDeepStack tests that a traced function below the frames
+// the debugger returns by default is still found on the stack.
+func TestTraceExecutionDeepStack(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProgram{
+		events: bus, line: 10, trueFrom: 100,
+		function: "main.process", recursion: 60,
+	}
+	session := startMockProgram(t, program)
+
+	result, err := TraceExecution(context.Background(), session, bus, TraceConfig{
+		ThreadID:    1,
+		MaxSteps:    2,
+		Function:    "main.process",
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TraceStepLimit, result.Reason)
+	require.Len(t, result.Steps, 3)
+	require.Equal(t, "main.recurse", result.Steps[2].Function)
+
+	// Once the recursion unwinds past the function, the trace ends.
+	program.line, program.returnAt, program.recursion = 10, 11, 0
+	result, err = TraceExecution(context.Background(), session, bus, TraceConfig{
+		ThreadID:    1,
+		Function:    "main.process",
+		StopTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TraceLeftFunction, result.Reason)
+}
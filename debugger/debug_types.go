@@ -5,31 +5,41 @@ package debugger
 // arguments.
 type LaunchConfig struct {
 	// Name is a human-readable name for the debug session.
-	Name string
+	Name string `json:"name,omitempty"`
 
 	// Program specifies the path to the Go program to debug. This can be
 	// either a path to a Go source file or a directory containing a main
 	// package.
-	Program string
+	Program string `json:"program"`
+
+	// Mode is the Delve launch mode: "debug" to build and debug a main
+	// package, "test" to build and debug a test binary, or "exec" to debug
+	// a pre-built binary. If empty, it's detected from the program.
+	Mode string `json:"mode,omitempty"`
 
 	// Args contains the command-line arguments to pass to the program
 	// being debugged.
-	Args []string
+	Args []string `json:"args,omitempty"`
 
 	// Env contains environment variables to set for the debugged program.
 	// Each entry should be in the format "KEY=value".
-	Env []string
+	Env []string `json:"env,omitempty"`
 
 	// WorkingDir specifies the working directory for the debugged program.
 	// If empty, the current directory is used.
-	WorkingDir string
+	WorkingDir string `json:"working_dir,omitempty"`
 
 	// StopOnEntry determines whether to stop at the program's entry point.
-	StopOnEntry bool
+	StopOnEntry bool `json:"stop_on_entry,omitempty"`
 
 	// BuildFlags contains additional flags to pass to the Go compiler
 	// when building the program for debugging.
-	BuildFlags []string
+	BuildFlags []string `json:"build_flags,omitempty"`
+
+	// Output is the path Delve should write the compiled debug binary to
+	// when the program is built from source. If empty, Delve picks a
+	// temporary location. It is ignored for pre-built binaries.
+	Output string `json:"output,omitempty"`
 }
 
 // AttachConfig represents the configuration for attaching to an existing
@@ -53,40 +63,73 @@ type AttachConfig struct {
 // BreakpointLocation represents a location where a breakpoint can be set.
 type BreakpointLocation struct {
 	// File is the path to the source file.
-	File string
+	File string `json:"file"`
 
 	// Line is the line number (1-based) where the breakpoint should be set.
-	Line int
+	Line int `json:"line"`
 
 	// Column is the column number (1-based) where the breakpoint should be
 	// set. This is optional and can be 0 for line-based breakpoints.
-	Column int
+	Column int `json:"column,omitempty"`
 
 	// Condition is an optional condition that must be true for the
 	// breakpoint to be hit.
-	Condition string
+	Condition string `json:"condition,omitempty"`
 
 	// HitCondition specifies when the breakpoint should be hit based on
 	// hit count (e.g., ">= 5" to break after 5 hits).
-	HitCondition string
+	HitCondition string `json:"hit_condition,omitempty"`
 
 	// LogMessage specifies a message to log when the breakpoint is hit
 	// instead of stopping execution.
-	LogMessage string
+	LogMessage string `json:"log_message,omitempty"`
+}
+
+// BreakpointStatus describes a breakpoint as reported back by the debug
+// adapter. The adapter may move a breakpoint to the nearest line with code, or
+// refuse to verify it entirely, so this can differ from what was requested.
+type BreakpointStatus struct {
+	// ID is the identifier assigned by the debug adapter. It is used to
+	// correlate later breakpoint events and is zero if none was assigned.
+	ID int
+
+	// File is the path to the source file of the breakpoint.
+	File string
+
+	// RequestedLine is the line the breakpoint was requested on.
+	RequestedLine int
+
+	// Line is the line the breakpoint was actually placed on. This is zero
+	// if the breakpoint could not be placed.
+	Line int
+
+	// Verified indicates whether the debug adapter was able to set the
+	// breakpoint. Unverified breakpoints will never be hit.
+	Verified bool
+
+	// Message is an optional explanation from the debug adapter, typically
+	// describing why a breakpoint could not be verified.
+	Message string
+}
+
+// Moved returns true if the debug adapter placed the breakpoint on a different
+// line than the one requested.
+func (b BreakpointStatus) Moved() bool {
+	return b.Verified && b.Line != 0 && b.Line != b.RequestedLine
 }
 
 // FunctionBreakpoint represents a breakpoint set on a function name.
 type FunctionBreakpoint struct {
 	// Name is the name of the function to break on.
-	Name string
+	Name string `json:"name"`
 
 	// Condition is an optional condition that must be true for the
 	// breakpoint to be hit.
-	Condition string
+	Condition string `json:"condition,omitempty"`
 
 	// HitCondition specifies when the breakpoint should be hit based on
 	// hit count.
-	HitCondition string
+	HitCondition string `json:"hit_condition,omitempty"`
 }
 
 // ThreadInfo represents information about a thread in the debugged program.
@@ -116,6 +159,25 @@ type StackFrame struct {
 	Column int
 }
 
+// StopLocation describes where a thread is stopped.
+type StopLocation struct {
+	// ThreadID is the thread (goroutine) that stopped.
+	ThreadID int
+
+	// FrameID is the ID of the top stack frame. Like all frame IDs it is
+	// only valid until execution resumes.
+	FrameID int
+
+	// Function is the name of the function the thread is stopped in.
+	Function string
+
+	// File is the path to the source file.
+	File string
+
+	// Line is the line number in the source file (1-based).
+	Line int
+}
+
 // SourceInfo represents information about a source file.
 type SourceInfo struct {
 	// Path is the full path to the source file.
@@ -181,4 +243,4 @@ type EvaluationResult struct {
 
 	// NamedVariables is the number of named child variables.
 	NamedVariables int
-}
\ No newline at end of file
+}
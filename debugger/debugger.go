@@ -6,6 +6,7 @@ import (
 
 	"github.com/lightningnetwork/lnd/actor"
 	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/roasbeef/mcp-debug/internal/logging"
 )
 
 // debugger is an actor that is responsible for creating and managing debugger
@@ -13,77 +14,119 @@ import (
 type debugger struct {
 	nextSessionID int
 	system        *actor.ActorSystem
+
+	// delve configures the Delve server started for each session.
+	delve DelveConfig
+
+	// sessions holds the live sessions keyed by their actor ID so they can
+	// be shut down on request.
+	sessions map[string]*Session
 }
 
 // newDebugger creates a new debugger actor factory.
-func newDebugger(system *actor.ActorSystem) *debugger {
+func newDebugger(system *actor.ActorSystem, delve DelveConfig) *debugger {
 	return &debugger{
-		system: system,
+		system:   system,
+		delve:    delve,
+		sessions: make(map[string]*Session),
 	}
 }
 
 // NewDebugger creates a new debugger actor that doesn't require the system
 // to be passed in (it will get it from the actor context).
 func NewDebugger(system *actor.ActorSystem) *debugger {
-	return &debugger{
-		system: system,
-	}
+	return newDebugger(system, DefaultDelveConfig())
+}
+
+// NewDebuggerWithConfig creates a new debugger actor that starts Delve
+// servers with the given configuration.
+func NewDebuggerWithConfig(system *actor.ActorSystem,
+	delve DelveConfig) *debugger {
+
+	return newDebugger(system, delve)
 }
 
 // Receive is the message handler for the debugger actor.
 func (d *debugger) Receive(actorCtx context.Context, msg *DebuggerCmd) fn.Result[*DebuggerResp] {
 	switch cmd := msg.Cmd.(type) {
 	case *StartDebuggerCmd:
-		// Create a new session.
-		session, err := NewSession()
+		resp, err := d.createSession("")
 		if err != nil {
-			return fn.Err[*DebuggerResp](fmt.Errorf("could not create session: %w", err))
+			return fn.Err[*DebuggerResp](err)
 		}
 
-		// Create a unique service key for this session.
-		d.nextSessionID++
-		sessionID := fmt.Sprintf("session-%d", d.nextSessionID)
-		sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse](sessionID)
-
-		// Register the session actor with the system.
-		actor.RegisterWithSystem(
-			d.system, sessionID, sessionKey, actor.NewFunctionBehavior(session.Receive),
-		)
-
-		// Get the session reference from the receptionist
-		sessionRef := actor.FindInReceptionist(d.system.Receptionist(), sessionKey)[0]
-		
-		// Return the session reference to the caller
-		return fn.Ok(&DebuggerResp{
-			Resp: &CreateSessionResp{Session: sessionRef},
-		})
+		return fn.Ok(&DebuggerResp{Resp: resp})
 
 	case *CreateSessionCmd:
-		// Create a new session.
-		session, err := NewSession()
+		resp, err := d.createSession(cmd.Name)
 		if err != nil {
-			return fn.Err[*DebuggerResp](fmt.Errorf("could not create session: %w", err))
+			return fn.Err[*DebuggerResp](err)
 		}
 
-		// Create a unique service key for this session.
-		d.nextSessionID++
-		sessionID := fmt.Sprintf("session-%d", d.nextSessionID)
-		sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse](sessionID)
+		return fn.Ok(&DebuggerResp{Resp: resp})
 
-		// Register the session actor with the system.
-		actor.RegisterWithSystem(
-			d.system, sessionID, sessionKey, actor.NewFunctionBehavior(session.Receive),
-		)
+	case *CloseSessionCmd:
+		if err := d.closeSession(cmd.ID); err != nil {
+			return fn.Err[*DebuggerResp](err)
+		}
 
-		// Get the session reference from the receptionist
-		sessionRef := actor.FindInReceptionist(d.system.Receptionist(), sessionKey)[0]
-		
-		// Return the session reference to the caller
-		return fn.Ok(&DebuggerResp{
-			Resp: &CreateSessionResp{Session: sessionRef},
-		})
+		return fn.Ok(&DebuggerResp{Resp: &CloseSessionResp{}})
 
 	default:
 		return fn.Err[*DebuggerResp](fmt.Errorf("unknown command type: %T", cmd))
 	}
 }
+
+// createSession launches a new Delve session and registers it as an actor. The
+// name, if given, is the caller's ID for the session and is added to its log
+// messages.
+func (d *debugger) createSession(name string) (*CreateSessionResp, error) {
+	d.nextSessionID++
+	sessionID := fmt.Sprintf("session-%d", d.nextSessionID)
+
+	logger := logging.Component("dap").With(
+		logging.KeyDAPSession, sessionID,
+	)
+	if name != "" {
+		logger = logger.With(logging.KeySession, name)
+	}
+
+	// Create a new session.
+	session, err := NewSession(d.delve, logger)
+	if err != nil {
+		return nil, fmt.Errorf("could not create session: %w", err)
+	}
+
+	// Create a unique service key for this session.
+	sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse](sessionID)
+
+	// Register the session actor with the system.
+	sessionRef := actor.RegisterWithSystem(
+		d.system, sessionID, sessionKey, actor.NewFunctionBehavior(session.Receive),
+	)
+	d.sessions[sessionID] = session
+
+	// Return the session reference to the caller
+	return &CreateSessionResp{
+		ID:      sessionID,
+		Session: sessionRef,
+		Events:  session.Events(),
+	}, nil
+}
+
+// closeSession stops the session actor with the given ID, unregisters it and
+// shuts down its Delve server.
+func (d *debugger) closeSession(sessionID string) error {
+	session, ok := d.sessions[sessionID]
+	if !ok {
+		return fmt.Errorf("unknown session: %s", sessionID)
+	}
+	delete(d.sessions, sessionID)
+
+	sessionKey := actor.NewServiceKey[*DAPRequest, *DAPResponse](sessionID)
+	sessionKey.UnregisterAll(d.system)
+
+	session.Stop()
+
+	return nil
+}
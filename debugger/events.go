@@ -0,0 +1,85 @@
+package debugger
+
+import (
+	"sync"
+
+	"github.com/google/go-dap"
+)
+
+// EventHandler is a callback invoked for each DAP event published on an
+// EventBus.
+type EventHandler func(event dap.EventMessage)
+
+// EventBus fans out the DAP events received by a session to any number of
+// subscribers. Handlers are invoked synchronously from the session's read
+// loop, so they must return quickly and must never issue requests to the
+// session themselves, as the response could then never be read.
+type EventBus struct {
+	mu       sync.RWMutex
+	nextID   uint64
+	handlers map[uint64]EventHandler
+}
+
+// NewEventBus creates a new event bus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers: make(map[uint64]EventHandler),
+	}
+}
+
+// Subscribe registers a handler for all future events. The returned function
+// removes the subscription and is safe to call more than once.
+func (b *EventBus) Subscribe(handler EventHandler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.handlers, id)
+	}
+}
+
+// Publish delivers an event to every current subscriber.
+func (b *EventBus) Publish(event dap.EventMessage) {
+	b.mu.RLock()
+	handlers := make([]EventHandler, 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+// NextStop returns a channel that receives the next stopped, exited or
+// terminated event published on the bus, along with a function that cancels
+// the subscription. Callers should subscribe before issuing the request that
+// resumes execution so the resulting stop can't be missed.
+func (b *EventBus) NextStop() (<-chan dap.EventMessage, func()) {
+	stops := make(chan dap.EventMessage, 1)
+
+	unsubscribe := b.Subscribe(func(event dap.EventMessage) {
+		switch event.(type) {
+		case *dap.StoppedEvent, *dap.ExitedEvent, *dap.TerminatedEvent:
+		default:
+			return
+		}
+
+		// Only the first stop is of interest, drop any that follow
+		// before the caller unsubscribes.
+		select {
+		case stops <- event:
+		default:
+		}
+	})
+
+	return stops, unsubscribe
+}
@@ -0,0 +1,37 @@
+package debugger
+
+import (
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEventBus tests that events are delivered to all subscribers until they
+// unsubscribe.
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+
+	var first, second []dap.EventMessage
+	unsubFirst := bus.Subscribe(func(event dap.EventMessage) {
+		first = append(first, event)
+	})
+	bus.Subscribe(func(event dap.EventMessage) {
+		second = append(second, event)
+	})
+
+	stopped := &dap.StoppedEvent{}
+	bus.Publish(stopped)
+	require.Equal(t, []dap.EventMessage{stopped}, first)
+	require.Equal(t, []dap.EventMessage{stopped}, second)
+
+	// Once unsubscribed, the first handler should no longer see events.
+	// Unsubscribing twice must be harmless.
+	unsubFirst()
+	unsubFirst()
+
+	output := &dap.OutputEvent{}
+	bus.Publish(output)
+	require.Len(t, first, 1)
+	require.Equal(t, []dap.EventMessage{stopped, output}, second)
+}
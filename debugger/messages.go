@@ -20,10 +20,24 @@ type StopDebuggerCmd struct{}
 func (c *StopDebuggerCmd) isDebuggerCommand() {}
 
 // CreateSessionCmd is a command to create a new debug session.
-type CreateSessionCmd struct{}
+type CreateSessionCmd struct {
+	// Name is the caller's ID for the session, used in log messages. It
+	// may be empty.
+	Name string
+}
 
 func (c *CreateSessionCmd) isDebuggerCommand() {}
 
+// CloseSessionCmd is a command to shut down a debug session created by the
+// debugger. Callers should disconnect the session first so that Delve can
+// clean up the debugged program.
+type CloseSessionCmd struct {
+	// ID is the session ID returned in CreateSessionResp.
+	ID string
+}
+
+func (c *CloseSessionCmd) isDebuggerCommand() {}
+
 // DebuggerCmd is the message sent to the debugger actor.
 type DebuggerCmd struct {
 	actor.BaseMessage
@@ -42,11 +56,23 @@ type DebuggerResponse interface {
 
 // CreateSessionResp is the response from creating a session.
 type CreateSessionResp struct {
+	// ID identifies the session to the debugger, e.g. when closing it.
+	ID string
+
 	Session actor.ActorRef[*DAPRequest, *DAPResponse]
+
+	// Events is the bus on which the session publishes the DAP events it
+	// receives, such as stopped, output and breakpoint events.
+	Events *EventBus
 }
 
 func (r *CreateSessionResp) isDebuggerResponse() {}
 
+// CloseSessionResp is the response from closing a session.
+type CloseSessionResp struct{}
+
+func (r *CloseSessionResp) isDebuggerResponse() {}
+
 // DebuggerResp is the response from the debugger actor.
 type DebuggerResp struct {
 	actor.BaseMessage
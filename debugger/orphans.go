@@ -0,0 +1,228 @@
+package debugger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/roasbeef/mcp-debug/internal/logging"
+)
+
+// delveRecord is the content of the PID file written for each Delve server we
+// start, which lets a later server instance clean it up if we crash.
+type delveRecord struct {
+	// PID is the process ID of the Delve server.
+	PID int `json:"pid"`
+
+	// Owner is the process ID of the MCP server that started it.
+	Owner int `json:"owner"`
+
+	// Path is the path of the dlv executable, used to make sure a
+	// recycled PID isn't mistaken for the Delve server.
+	Path string `json:"path"`
+
+	// Started is when the Delve server started, as reported by ps. It
+	// identifies the process on systems without /proc, where its
+	// executable can't be read.
+	Started string `json:"started,omitempty"`
+}
+
+// procDir is where the executables of processes are looked up.
+var procDir = "/proc"
+
+// delvePIDDir returns the directory holding the PID files of running Delve
+// servers.
+func delvePIDDir() (string, error) {
+	dir, err := logging.DefaultDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "dlv"), nil
+}
+
+// recordDelvePID writes a PID file for a Delve server started by this process
+// and returns a function that removes it again. Failures are logged but not
+// fatal, as the file is only needed for cleaning up after a crash.
+func recordDelvePID(pid int, path string) func() {
+	dir, err := delvePIDDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		logging.Component("delve").Warn("Unable to record PID",
+			"pid", pid, "err", err)
+		return func() {}
+	}
+
+	// The start time is only needed without /proc, so failing to read
+	// it leaves the record to be verified by executable alone.
+	started, _ := psField(pid, "lstart")
+
+	record, err := json.Marshal(delveRecord{
+		PID:     pid,
+		Owner:   os.Getpid(),
+		Path:    path,
+		Started: started,
+	})
+	if err != nil {
+		logging.Component("delve").Warn("Unable to record PID",
+			"pid", pid, "err", err)
+		return func() {}
+	}
+
+	file := filepath.Join(dir, fmt.Sprintf("%d.json", pid))
+	if err := os.WriteFile(file, record, 0644); err != nil {
+		logging.Component("delve").Warn("Unable to record PID",
+			"pid", pid, "err", err)
+		return func() {}
+	}
+
+	return func() {
+		_ = os.Remove(file)
+	}
+}
+
+// SweepOrphanedDelve kills Delve servers left behind by MCP server processes
+// that are no longer running, e.g. because they crashed, and returns the
+// number of processes killed. It should be called once at startup.
+func SweepOrphanedDelve() (int, error) {
+	// Without signal 0 we can't tell whether the owner is still alive,
+	// so we'd risk killing another server's sessions.
+	if runtime.GOOS == "windows" {
+		return 0, nil
+	}
+
+	dir, err := delvePIDDir()
+	if err != nil {
+		return 0, err
+	}
+
+	return sweepOrphanedDelve(dir)
+}
+
+// sweepOrphanedDelve implements SweepOrphanedDelve for the given PID file
+// directory.
+func sweepOrphanedDelve(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	var killed int
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+
+		var record delveRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			logging.Component("delve").Warn("Removing unreadable "+
+				"PID file", "file", file, "err", err)
+			_ = os.Remove(file)
+			continue
+		}
+
+		// Leave servers whose owner is still running alone.
+		if record.Owner == os.Getpid() || processAlive(record.Owner) {
+			continue
+		}
+
+		if processAlive(record.PID) && isRecordedProcess(record) {
+
+			proc, err := os.FindProcess(record.PID)
+			if err == nil {
+				err = proc.Kill()
+			}
+			if err != nil {
+				logging.Component("delve").Error("Unable to "+
+					"kill orphaned dlv process",
+					"pid", record.PID, "err", err)
+				continue
+			}
+
+			logging.Component("delve").Info("Killed orphaned "+
+				"dlv process", "pid", record.PID,
+				"owner", record.Owner)
+			killed++
+		}
+
+		_ = os.Remove(file)
+	}
+
+	return killed, nil
+}
+
+// processAlive reports whether a process with the given PID exists.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = proc.Signal(syscall.Signal(0))
+
+	// EPERM means the process exists but belongs to someone else.
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// isRecordedProcess reports whether the process with the recorded PID is
+// still the recorded Delve server, rather than an unrelated process that was
+// given the same PID after a reboot or PID reuse. Its executable is compared
+// where /proc is available, and otherwise its name and start time as reported
+// by ps. If neither can be checked, it isn't considered the Delve server, so
+// that it is never killed by mistake.
+func isRecordedProcess(record delveRecord) bool {
+	exe, err := os.Readlink(
+		filepath.Join(procDir, fmt.Sprint(record.PID), "exe"),
+	)
+	if err == nil {
+		resolved, err := filepath.EvalSymlinks(record.Path)
+		if err != nil {
+			resolved = record.Path
+		}
+
+		return exe == resolved || exe == record.Path
+	}
+
+	if record.Started == "" {
+		return false
+	}
+
+	name, err := psField(record.PID, "comm")
+	if err != nil || filepath.Base(name) != filepath.Base(record.Path) {
+		return false
+	}
+	started, err := psField(record.PID, "lstart")
+
+	return err == nil && started == record.Started
+}
+
+// psField returns a field of the process with the given PID as reported by
+// ps, such as its name (comm) or start time (lstart).
+func psField(pid int, field string) (string, error) {
+	out, err := exec.Command(
+		"ps", "-o", field+"=", "-p", fmt.Sprint(pid),
+	).Output()
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSpace(string(out))
+	if value == "" {
+		return "", fmt.Errorf("no %s for process %d", field, pid)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,157 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeDelveRecord writes a PID file as recordDelvePID would.
+func writeDelveRecord(t *testing.T, dir string, record delveRecord) string {
+	t.Helper()
+
+	data, err := json.Marshal(record)
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, fmt.Sprintf("%d.json", record.PID))
+	require.NoError(t, os.WriteFile(file, data, 0644))
+
+	return file
+}
+
+// TestSweepOrphanedDelve tests that only processes whose owner has exited and
+// that still run the recorded executable are killed.
+func TestSweepOrphanedDelve(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process executable checks require /proc")
+	}
+
+	sleepPath, err := exec.LookPath("sleep")
+	require.NoError(t, err)
+	sleepPath, err = filepath.EvalSymlinks(sleepPath)
+	require.NoError(t, err)
+
+	// An owner that has already exited.
+	owner := exec.Command("true")
+	require.NoError(t, owner.Run())
+	deadOwner := owner.Process.Pid
+
+	startSleep := func() *exec.Cmd {
+		cmd := exec.Command(sleepPath, "30")
+		require.NoError(t, cmd.Start())
+		t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+		return cmd
+	}
+
+	dir := t.TempDir()
+
+	orphan := startSleep()
+	orphanFile := writeDelveRecord(t, dir, delveRecord{
+		PID: orphan.Process.Pid, Owner: deadOwner, Path: sleepPath,
+	})
+
+	// A process whose owner is still running is left alone.
+	owned := startSleep()
+	ownedFile := writeDelveRecord(t, dir, delveRecord{
+		PID: owned.Process.Pid, Owner: os.Getpid(), Path: sleepPath,
+	})
+
+	// A recycled PID running something else isn't killed either.
+	recycled := startSleep()
+	recycledFile := writeDelveRecord(t, dir, delveRecord{
+		PID: recycled.Process.Pid, Owner: deadOwner,
+		Path: "/usr/local/bin/dlv",
+	})
+
+	killed, err := sweepOrphanedDelve(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, killed)
+
+	exited := make(chan error, 1)
+	go func() { exited <- orphan.Wait() }()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("orphaned process was not killed")
+	}
+
+	require.NoFileExists(t, orphanFile)
+	require.FileExists(t, ownedFile)
+	require.NoFileExists(t, recycledFile)
+	require.True(t, processAlive(owned.Process.Pid))
+	require.True(t, processAlive(recycled.Process.Pid))
+}
+
+// TestSweepUnverifiableProcess tests that without /proc a process is only
+// killed if its name and start time match the record, so that a process
+// that can't be verified is left alone.
+func TestSweepUnverifiableProcess(t *testing.T) {
+	if _, err := exec.LookPath("ps"); err != nil {
+		t.Skip("ps is not available")
+	}
+
+	procDir = t.TempDir()
+	t.Cleanup(func() { procDir = "/proc" })
+
+	sleepPath, err := exec.LookPath("sleep")
+	require.NoError(t, err)
+
+	owner := exec.Command("true")
+	require.NoError(t, owner.Run())
+	deadOwner := owner.Process.Pid
+
+	startSleep := func() *exec.Cmd {
+		cmd := exec.Command(sleepPath, "30")
+		require.NoError(t, cmd.Start())
+		t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+		return cmd
+	}
+
+	dir := t.TempDir()
+
+	// A record without a start time can't be verified.
+	unverified := startSleep()
+	writeDelveRecord(t, dir, delveRecord{
+		PID: unverified.Process.Pid, Owner: deadOwner, Path: sleepPath,
+	})
+
+	// Neither can a process that started at another time.
+	restarted := startSleep()
+	writeDelveRecord(t, dir, delveRecord{
+		PID: restarted.Process.Pid, Owner: deadOwner, Path: sleepPath,
+		Started: "Thu Jan  1 00:00:00 1970",
+	})
+
+	// A process whose name and start time match is killed.
+	orphan := startSleep()
+	started, err := psField(orphan.Process.Pid, "lstart")
+	require.NoError(t, err)
+	writeDelveRecord(t, dir, delveRecord{
+		PID: orphan.Process.Pid, Owner: deadOwner, Path: sleepPath,
+		Started: started,
+	})
+
+	killed, err := sweepOrphanedDelve(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, killed)
+
+	require.True(t, processAlive(unverified.Process.Pid))
+	require.True(t, processAlive(restarted.Process.Pid))
+
+	exited := make(chan error, 1)
+	go func() { exited <- orphan.Wait() }()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("orphaned process was not killed")
+	}
+}
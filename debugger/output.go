@@ -0,0 +1,134 @@
+package debugger
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/go-dap"
+)
+
+// DefaultOutputLimit is the number of bytes of program output kept when no
+// limit is given.
+const DefaultOutputLimit = 64 * 1024
+
+// OutputBuffer keeps the most recent output of a debugged program, as
+// reported by output events. Output from the debug adapter itself, such as
+// Delve's own log messages, is kept too but can be told apart by category.
+type OutputBuffer struct {
+	mu sync.Mutex
+
+	// chunks holds the output in the order it was received, and size is
+	// the total length of their text.
+	chunks []OutputChunk
+	size   int
+
+	// limit is the maximum total length of the kept output.
+	limit int
+
+	// dropped is the number of bytes discarded to stay within the limit.
+	dropped int
+
+	unsubscribe func()
+}
+
+// OutputChunk is a piece of output from a single output event.
+type OutputChunk struct {
+	// Category is the kind of output, such as "stdout", "stderr" or
+	// "console" for messages from the debugger.
+	Category string `json:"category"`
+
+	// Output is the text.
+	Output string `json:"output"`
+}
+
+// NewOutputBuffer creates a buffer that collects the output events published
+// on the given bus, keeping up to limit bytes. A non-positive limit uses
+// DefaultOutputLimit.
+func NewOutputBuffer(events *EventBus, limit int) *OutputBuffer {
+	if limit <= 0 {
+		limit = DefaultOutputLimit
+	}
+
+	b := &OutputBuffer{
+		limit:       limit,
+		unsubscribe: func() {},
+	}
+
+	if events != nil {
+		b.unsubscribe = events.Subscribe(b.handleEvent)
+	}
+
+	return b
+}
+
+// Chunks returns the kept output, oldest first, and the number of bytes that
+// were discarded before it.
+func (b *OutputBuffer) Chunks() ([]OutputChunk, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]OutputChunk(nil), b.chunks...), b.dropped
+}
+
+// Text returns the kept output of the given categories concatenated. If no
+// categories are given, all output is returned.
+func (b *OutputBuffer) Text(categories ...string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var text strings.Builder
+	for _, chunk := range b.chunks {
+		if len(categories) > 0 && !slices.Contains(categories, chunk.Category) {
+			continue
+		}
+		text.WriteString(chunk.Output)
+	}
+
+	return text.String()
+}
+
+// Stop stops collecting output.
+func (b *OutputBuffer) Stop() {
+	b.unsubscribe()
+}
+
+// handleEvent appends the text of output events, discarding the oldest output
+// once the limit is exceeded.
+func (b *OutputBuffer) handleEvent(event dap.EventMessage) {
+	output, ok := event.(*dap.OutputEvent)
+	if !ok || output.Body.Output == "" {
+		return
+	}
+
+	// The DAP default category is "console".
+	chunk := OutputChunk{
+		Category: output.Body.Category,
+		Output:   output.Body.Output,
+	}
+	if chunk.Category == "" {
+		chunk.Category = "console"
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Only the tail of oversized output can be kept.
+	if excess := len(chunk.Output) - b.limit; excess > 0 {
+		chunk.Output = chunk.Output[excess:]
+		b.dropped += excess
+	}
+
+	b.chunks = append(b.chunks, chunk)
+	b.size += len(chunk.Output)
+
+	var drop int
+	for b.size > b.limit {
+		b.size -= len(b.chunks[drop].Output)
+		b.dropped += len(b.chunks[drop].Output)
+		drop++
+	}
+	if drop > 0 {
+		b.chunks = append([]OutputChunk(nil), b.chunks[drop:]...)
+	}
+}
@@ -0,0 +1,46 @@
+package debugger
+
+import (
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+// publishOutput publishes an output event with the given category and text.
+func publishOutput(bus *EventBus, category, output string) {
+	bus.Publish(&dap.OutputEvent{
+		Body: dap.OutputEventBody{Category: category, Output: output},
+	})
+}
+
+// TestOutputBuffer tests that output is collected by category and that only
+// the most recent output within the limit is kept.
+func TestOutputBuffer(t *testing.T) {
+	bus := NewEventBus()
+	buffer := NewOutputBuffer(bus, 10)
+	t.Cleanup(buffer.Stop)
+
+	publishOutput(bus, "stdout", "abc\n")
+	publishOutput(bus, "", "dlv\n")
+	publishOutput(bus, "stderr", "err\n")
+
+	// The oldest output is discarded once the limit is exceeded.
+	require.Equal(t, "dlv\nerr\n", buffer.Text())
+	require.Equal(t, "err\n", buffer.Text("stdout", "stderr"))
+
+	chunks, dropped := buffer.Chunks()
+	require.Equal(t, 4, dropped)
+	require.Equal(t, []OutputChunk{
+		{Category: "console", Output: "dlv\n"},
+		{Category: "stderr", Output: "err\n"},
+	}, chunks)
+
+	// Output longer than the limit keeps only its tail.
+	publishOutput(bus, "stdout", "0123456789abcdef")
+	chunks, dropped = buffer.Chunks()
+	require.Equal(t, []OutputChunk{
+		{Category: "stdout", Output: "6789abcdef"},
+	}, chunks)
+	require.Equal(t, 18, dropped)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/roasbeef/mcp-debug/internal/metrics"
 )
 
 // RetryConfig configures retry behavior
@@ -12,6 +14,9 @@ type RetryConfig struct {
 	InitialDelay time.Duration
 	MaxDelay     time.Duration
 	Multiplier   float64
+
+	// Operation names what is retried in the retry metrics.
+	Operation string
 }
 
 // DefaultRetryConfig provides sensible defaults for retry operations
@@ -45,6 +50,12 @@ func RetryWithBackoff(ctx context.Context, config RetryConfig, operation func()
 		if attempt == config.MaxAttempts {
 			break
 		}
+
+		name := config.Operation
+		if name == "" {
+			name = "other"
+		}
+		metrics.Retries.Inc(name)
 		
 		// Wait with exponential backoff
 		select {
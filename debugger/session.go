@@ -3,13 +3,20 @@ package debugger
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
 	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+	"github.com/roasbeef/mcp-debug/internal/metrics"
 )
 
 // Session is an actor that manages a single DAP debugging session.
@@ -17,51 +24,96 @@ type Session struct {
 	conn    net.Conn
 	cleanup func()
 
+	// log attributes records to the session.
+	log *slog.Logger
+
+	// timeouts bounds how long Receive waits for each response.
+	timeouts RequestTimeouts
+
+	// seq is the sequence number of the last request sent. It's only
+	// used by Receive, which the actor calls for one message at a time.
+	seq int
+
+	// pending is the sequence number of the request Receive is waiting
+	// for, or zero if it isn't waiting. The read loop drops responses to
+	// any other request, such as late responses to requests that timed
+	// out, so that they can't block it from publishing events.
+	pending atomic.Int64
+
 	// The actor's quit channel is used to signal that the session should be
 	// terminated.
-	quit chan struct{}
+	quit     chan struct{}
+	stopOnce sync.Once
+
+	// events fans out the DAP events sent by the server to any interested
+	// subscribers, independent of whether a request is in flight.
+	events *EventBus
 
 	// The following channels are used to route messages from the DAP server
 	// back to the actor's message loop.
 	responses chan dap.Message
-	events    chan dap.Message
 	errors    chan error
 }
 
 // NewSession creates a new debugging session actor.
-// It launches a new Delve DAP server and connects to it.
-func NewSession() (*Session, error) {
-	log.Printf("[Session] Creating new debugging session...")
-	
-	conn, cleanup, err := launchDelve()
+// It launches a new Delve DAP server and connects to it. Messages about the
+// session are logged to the given logger.
+func NewSession(config DelveConfig, logger *slog.Logger) (*Session, error) {
+	logger.Debug("Creating new debugging session")
+
+	conn, cleanup, err := launchDelve(config)
 	if err != nil {
-		log.Printf("[Session] Failed to launch Delve: %v", err)
+		logger.Error("Failed to launch Delve", "err", err)
 		return nil, err
 	}
-	
-	log.Printf("[Session] Successfully connected to Delve DAP server")
+
+	logger.Info("Connected to Delve DAP server")
+
+	return newSession(conn, cleanup, logger, config.Timeouts), nil
+}
+
+// newSession creates a session for a connection to a DAP server and starts
+// reading from it.
+func newSession(conn net.Conn, cleanup func(), logger *slog.Logger,
+	timeouts RequestTimeouts) *Session {
 
 	s := &Session{
 		conn:      conn,
 		cleanup:   cleanup,
+		log:       logger,
+		timeouts:  timeouts,
 		quit:      make(chan struct{}),
+		events:    NewEventBus(),
 		responses: make(chan dap.Message, 1),
-		events:    make(chan dap.Message, 1),
 		errors:    make(chan error, 1),
 	}
 
 	// Start the read loop immediately
 	go s.readLoop()
-	log.Printf("[Session] Started read loop")
 
-	return s, nil
+	return s
+}
+
+// sessionLog returns the logger for messages about a session actor, for code
+// that only holds a reference to it.
+func sessionLog(session actor.ActorRef[*DAPRequest, *DAPResponse]) *slog.Logger {
+	return logging.Component("dap").With(logging.KeyDAPSession, session.ID())
+}
+
+// Events returns the bus on which all DAP events received by this session are
+// published.
+func (s *Session) Events() *EventBus {
+	return s.events
 }
 
-// Stop terminates the DAP session and cleans up resources.
+// Stop terminates the DAP session and cleans up resources. It is safe to call
+// more than once.
 func (s *Session) Stop() {
-	close(s.quit)
-	s.conn.Close()
-	s.cleanup()
+	s.stopOnce.Do(func() {
+		close(s.quit)
+		s.conn.Close()
+		s.cleanup()
+	})
 }
 
 // readLoop is a long-running goroutine that reads messages from the DAP
@@ -79,7 +131,8 @@ func (s *Session) readLoop() {
 		msg, err := dap.ReadProtocolMessage(reader)
 		if err != nil {
 			if err != io.EOF {
-				log.Printf("[ReadLoop] Error reading DAP message: %v", err)
+				s.log.Error("Error reading DAP message",
+					"err", err)
 				select {
 				case s.errors <- fmt.Errorf("error reading DAP message: %w", err):
 				case <-s.quit:
@@ -89,85 +142,254 @@ func (s *Session) readLoop() {
 			return
 		}
 
-		log.Printf("[ReadLoop] Received message: %T", msg)
-		
 		switch m := msg.(type) {
 		case dap.ResponseMessage:
-			log.Printf("[ReadLoop] Forwarding response: %T", m)
-			select {
-			case s.responses <- m:
-			case <-s.quit:
+			s.logResponse(m)
+			if !s.deliver(m) {
 				return
 			}
 		case dap.EventMessage:
-			log.Printf("[ReadLoop] Forwarding event: %T", m)
-			select {
-			case s.events <- m:
-			case <-s.quit:
-				return
-			}
+			// Events are published as they arrive rather than
+			// waiting for the next request, so subscribers learn
+			// about stops and output immediately.
+			s.logEvent(m)
+			s.events.Publish(m)
 		default:
 			// Log unexpected message types but don't fail
-			log.Printf("[ReadLoop] Unexpected message type: %T", msg)
+			s.log.Warn("Unexpected DAP message",
+				"type", fmt.Sprintf("%T", msg))
+		}
+	}
+}
+
+// deliver passes a response to Receive if it's waiting for it, and drops it
+// otherwise. It never blocks on a response nobody reads, so events keep being
+// published after a request timed out. It returns false if the session was
+// stopped.
+func (s *Session) deliver(msg dap.ResponseMessage) bool {
+	resp := msg.GetResponse()
+	if int64(resp.RequestSeq) != s.pending.Load() {
+		// A late response to a request that was cancelled, or to a
+		// cancel request.
+		s.log.Debug("Discarding stale DAP response",
+			"command", resp.Command,
+			logging.KeySeq, resp.RequestSeq)
+
+		return true
+	}
+
+	for {
+		select {
+		case s.responses <- msg:
+			return true
+
+		case <-s.quit:
+			return false
+
+		default:
+		}
+
+		// The buffer still holds a response that arrived just as
+		// Receive gave up on it, so it's stale and can be dropped.
+		select {
+		case <-s.responses:
+		default:
 		}
 	}
 }
 
-// Receive is the actor's message handler.
+// Receive is the actor's message handler. It sends the request to Delve and
+// waits for the response with the request's sequence number, until the
+// request's context is done or the command's timeout passes.
 func (s *Session) Receive(actorCtx context.Context, msg *DAPRequest) fn.Result[*DAPResponse] {
-	// Log the outgoing request
-	log.Printf("[Session] Sending DAP request: %T", msg.Request)
-	
+	ctx := msg.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	command := requestCommand(msg.Request)
+	if timeout := s.timeouts.For(command); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Give up before sending if the caller already has.
+	if err := ctx.Err(); err != nil {
+		return fn.Err[*DAPResponse](err)
+	}
+
+	// Sequence numbers are assigned here, rather than by the callers, so
+	// that responses to requests that were cancelled can be told apart
+	// from the response to this one.
+	seq := s.nextSeq(msg.Request)
+	s.pending.Store(int64(seq))
+	defer s.pending.Store(0)
+	s.log.Debug("Sending DAP request", "command", command,
+		logging.KeySeq, seq)
+
 	// First, send the request to the DAP server.
+	start := time.Now()
 	if err := dap.WriteProtocolMessage(s.conn, msg.Request); err != nil {
+		metrics.DAPRequestFailures.Inc(command)
 		return fn.Err[*DAPResponse](fmt.Errorf("error writing DAP message: %w", err))
 	}
 
-	// Now, wait for the corresponding response.
-	// We MUST wait for the actual response, not return events as responses.
-	// Events should be handled separately or collected.
+	// Now, wait for the corresponding response. Events are published on
+	// the session's event bus by the read loop and never surface here.
 	for {
 		select {
 		case resp := <-s.responses:
-			// We got a direct response to our request.
-			log.Printf("[Session] Received DAP response: %T", resp)
-			return fn.Ok(&DAPResponse{Response: resp})
-
-		case event := <-s.events:
-			// Log the event
-			log.Printf("[Session] Received DAP event: %T", event)
-			
-			// Events are NOT responses! We should handle them but continue waiting
-			// for the actual response to our request.
-			switch e := event.(type) {
-			case *dap.OutputEvent:
-				// Log output but continue waiting for the actual response
-				log.Printf("[Session] Output event (continuing): %s", e.Body.Output)
-				continue
-			case *dap.StoppedEvent:
-				// Important event but not a response to our request
-				log.Printf("[Session] Stopped event (continuing to wait for response): threadId=%d, reason=%s", 
-					e.Body.ThreadId, e.Body.Reason)
-				continue
-			case *dap.TerminatedEvent, *dap.ExitedEvent:
-				// These might indicate the session is ending
-				log.Printf("[Session] Termination event (continuing): %T", event)
-				continue
-			default:
-				// For other events, continue waiting
-				log.Printf("[Session] Other event (continuing): %T", event)
+			r, ok := resp.(dap.ResponseMessage)
+			if ok && r.GetResponse().RequestSeq != seq {
+				// A response to an earlier request that the
+				// read loop delivered just as it was given up
+				// on.
+				stale := r.GetResponse()
+				s.log.Debug("Discarding stale DAP response",
+					"command", stale.Command,
+					logging.KeySeq, stale.RequestSeq)
 				continue
 			}
 
+			// We got a direct response to our request.
+			metrics.DAPRequestDuration.Observe(
+				time.Since(start).Seconds(), command,
+			)
+			if ok && !r.GetResponse().Success {
+				metrics.DAPRequestFailures.Inc(command)
+			}
+
+			return fn.Ok(&DAPResponse{Response: resp})
+
 		case err := <-s.errors:
 			// An error occurred in the read loop.
-			log.Printf("[Session] Error in read loop: %v", err)
+			metrics.DAPRequestFailures.Inc(command)
 			return fn.Err[*DAPResponse](err)
 
+		case <-s.quit:
+			return fn.Err[*DAPResponse](fmt.Errorf("session stopped"))
+
+		case <-ctx.Done():
+			// The caller gave up or the request timed out. Delve
+			// is asked to cancel the request so that it doesn't
+			// hold up the session, and its response is discarded
+			// whenever it arrives.
+			metrics.DAPRequestFailures.Inc(command)
+			s.cancelRequest(seq)
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				s.log.Warn("DAP request timed out",
+					"command", command, logging.KeySeq, seq,
+					"elapsed", time.Since(start))
+
+				return fn.Err[*DAPResponse](fmt.Errorf("%s "+
+					"request timed out after %v: %w",
+					command, time.Since(start).Round(
+						time.Millisecond), ctx.Err()))
+			}
+
+			return fn.Err[*DAPResponse](fmt.Errorf("%s request "+
+				"cancelled: %w", command, ctx.Err()))
+
 		case <-actorCtx.Done():
 			// The actor is shutting down.
-			log.Printf("[Session] Actor context done: %v", actorCtx.Err())
+			s.log.Debug("Actor context done", "err", actorCtx.Err())
 			return fn.Err[*DAPResponse](actorCtx.Err())
 		}
 	}
 }
+
+// nextSeq assigns the next sequence number to a request and returns it.
+func (s *Session) nextSeq(msg dap.Message) int {
+	s.seq++
+	if req, ok := msg.(dap.RequestMessage); ok {
+		req.GetRequest().Seq = s.seq
+	}
+
+	return s.seq
+}
+
+// cancelRequest asks Delve to cancel the request with the given sequence
+// number. The response to the cancel request is discarded by the read loop
+// like any other stale response.
+func (s *Session) cancelRequest(seq int) {
+	req := &dap.CancelRequest{
+		Request: dap.Request{
+			ProtocolMessage: dap.ProtocolMessage{
+				Type: "request",
+			},
+			Command: "cancel",
+		},
+		Arguments: &dap.CancelArguments{
+			RequestId: seq,
+		},
+	}
+	cancelSeq := s.nextSeq(req)
+	s.log.Debug("Cancelling DAP request", logging.KeySeq, seq,
+		"cancel_seq", cancelSeq)
+
+	if err := dap.WriteProtocolMessage(s.conn, req); err != nil {
+		s.log.Warn("Unable to cancel DAP request", logging.KeySeq, seq,
+			"err", err)
+	}
+}
+
+// requestCommand returns the command of a DAP request, or its type if it
+// isn't a request.
+func requestCommand(msg dap.Message) string {
+	if req, ok := msg.(dap.RequestMessage); ok {
+		return req.GetRequest().Command
+	}
+
+	return fmt.Sprintf("%T", msg)
+}
+
+// logResponse logs a DAP response received from the server. Failed requests
+// are logged as warnings so that they stand out.
+func (s *Session) logResponse(msg dap.ResponseMessage) {
+	resp := msg.GetResponse()
+	attrs := []any{
+		"command", resp.Command,
+		logging.KeySeq, resp.RequestSeq,
+	}
+
+	if !resp.Success {
+		attrs = append(attrs, "message", resp.Message)
+		if errResp, ok := msg.(*dap.ErrorResponse); ok &&
+			errResp.Body.Error != nil {
+
+			attrs = append(attrs, "error", errResp.Body.Error.Format)
+		}
+		s.log.Warn("DAP request failed", attrs...)
+
+		return
+	}
+
+	s.log.Debug("Received DAP response", attrs...)
+}
+
+// logEvent logs a DAP event received from the server.
+func (s *Session) logEvent(event dap.EventMessage) {
+	switch e := event.(type) {
+	case *dap.OutputEvent:
+		s.log.Debug("Output event", "category", e.Body.Category,
+			"output", e.Body.Output)
+
+	case *dap.StoppedEvent:
+		s.log.Debug("Stopped event", "thread_id", e.Body.ThreadId,
+			"reason", e.Body.Reason)
+
+	case *dap.BreakpointEvent:
+		s.log.Debug("Breakpoint event", "reason", e.Body.Reason,
+			"id", e.Body.Breakpoint.Id,
+			"verified", e.Body.Breakpoint.Verified)
+
+	case *dap.TerminatedEvent, *dap.ExitedEvent:
+		s.log.Info("Debugged program terminated",
+			"event", event.GetEvent().Event)
+
+	default:
+		s.log.Debug("Event", "event", event.GetEvent().Event)
+	}
+}
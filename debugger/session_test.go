@@ -0,0 +1,217 @@
+package debugger
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+// newPipeSession creates a session connected to an in-memory DAP server. The
+// requests the server receives are sent on the returned channel, and
+// responses are written to the returned connection.
+func newPipeSession(t *testing.T,
+	timeouts RequestTimeouts) (*Session, <-chan dap.Message, net.Conn) {
+
+	client, server := net.Pipe()
+	s := newSession(client, func() {}, slog.Default(), timeouts)
+
+	requests := make(chan dap.Message, 16)
+	go func() {
+		reader := bufio.NewReader(server)
+		for {
+			msg, err := dap.ReadProtocolMessage(reader)
+			if err != nil {
+				return
+			}
+			requests <- msg
+		}
+	}()
+
+	t.Cleanup(func() {
+		s.Stop()
+		server.Close()
+	})
+
+	return s, requests, server
+}
+
+// threadsRequest returns a request for the threads of the program.
+func threadsRequest() *dap.ThreadsRequest {
+	return &dap.ThreadsRequest{
+		Request: dap.Request{
+			ProtocolMessage: dap.ProtocolMessage{Type: "request"},
+			Command:         "threads",
+		},
+	}
+}
+
+// threadsResponse returns a successful response to the threads request with
+// the given sequence number.
+func threadsResponse(requestSeq int) *dap.ThreadsResponse {
+	return &dap.ThreadsResponse{
+		Response: dap.Response{
+			ProtocolMessage: dap.ProtocolMessage{Type: "response"},
+			Command:         "threads",
+			RequestSeq:      requestSeq,
+			Success:         true,
+		},
+	}
+}
+
+// TestSessionRequestTimeout tests that a request that Delve doesn't answer in
+// time is cancelled, and that its late response isn't mistaken for the
+// response to a later request.
+func TestSessionRequestTimeout(t *testing.T) {
+	s, requests, server := newPipeSession(t, RequestTimeouts{
+		Default: time.Second,
+		Commands: map[string]time.Duration{
+			"evaluate": 50 * time.Millisecond,
+		},
+	})
+
+	evaluate := &dap.EvaluateRequest{
+		Request: dap.Request{
+			ProtocolMessage: dap.ProtocolMessage{Type: "request"},
+			Command:         "evaluate",
+		},
+		Arguments: dap.EvaluateArguments{Expression: "forever()"},
+	}
+	_, err := s.Receive(
+		context.Background(), &DAPRequest{Request: evaluate},
+	).Unpack()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorContains(t, err, "evaluate request timed out")
+
+	// The session numbers requests itself and cancels the one that timed
+	// out.
+	sent := (<-requests).(*dap.EvaluateRequest)
+	require.Equal(t, 1, sent.Seq)
+	cancel := (<-requests).(*dap.CancelRequest)
+	require.Equal(t, 2, cancel.Seq)
+	require.Equal(t, 1, cancel.Arguments.RequestId)
+
+	// The late response to the evaluation arrives while the next request
+	// is waiting.
+	go func() {
+		sent := <-requests
+		_ = dap.WriteProtocolMessage(server, threadsResponse(1))
+		_ = dap.WriteProtocolMessage(server, threadsResponse(
+			sent.GetSeq(),
+		))
+	}()
+
+	resp, err := s.Receive(
+		context.Background(), &DAPRequest{Request: threadsRequest()},
+	).Unpack()
+	require.NoError(t, err)
+	require.Equal(t, 3,
+		resp.Response.(*dap.ThreadsResponse).RequestSeq)
+}
+
+// TestSessionEventsAfterTimeout tests that late responses to a request that
+// timed out, and to its cancel request, don't stop events from being
+// published while no request is waiting.
+func TestSessionEventsAfterTimeout(t *testing.T) {
+	s, requests, server := newPipeSession(t, RequestTimeouts{
+		Default: 50 * time.Millisecond,
+	})
+
+	events := make(chan dap.EventMessage, 1)
+	unsubscribe := s.Events().Subscribe(func(event dap.EventMessage) {
+		events <- event
+	})
+	defer unsubscribe()
+
+	_, err := s.Receive(
+		context.Background(), &DAPRequest{Request: threadsRequest()},
+	).Unpack()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The pipe is synchronous, so the server writes from its own
+	// goroutine and a blocked read loop shows up as missing events.
+	sent := <-requests
+	cancel := <-requests
+	go func() {
+		_ = dap.WriteProtocolMessage(server, threadsResponse(
+			sent.GetSeq(),
+		))
+		_ = dap.WriteProtocolMessage(server, &dap.CancelResponse{
+			Response: dap.Response{
+				ProtocolMessage: dap.ProtocolMessage{
+					Type: "response",
+				},
+				Command:    "cancel",
+				RequestSeq: cancel.GetSeq(),
+				Success:    true,
+			},
+		})
+
+		for i := range 3 {
+			_ = dap.WriteProtocolMessage(server, &dap.OutputEvent{
+				Event: dap.Event{
+					ProtocolMessage: dap.ProtocolMessage{
+						Type: "event",
+					},
+					Event: "output",
+				},
+				Body: dap.OutputEventBody{
+					Output: fmt.Sprintf("line %d\n", i),
+				},
+			})
+		}
+	}()
+
+	for i := range 3 {
+		select {
+		case event := <-events:
+			require.Equal(t, fmt.Sprintf("line %d\n", i),
+				event.(*dap.OutputEvent).Body.Output)
+
+		case <-time.After(time.Second):
+			t.Fatalf("event %d wasn't published", i)
+		}
+	}
+}
+
+// TestSessionRequestCancelled tests that a request is cancelled once the
+// caller's context is done.
+func TestSessionRequestCancelled(t *testing.T) {
+	s, requests, _ := newPipeSession(t, RequestTimeouts{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-requests
+		cancel()
+	}()
+
+	_, err := s.Receive(context.Background(), &DAPRequest{
+		Ctx: ctx,
+		Request: &dap.ContinueRequest{
+			Request: dap.Request{
+				ProtocolMessage: dap.ProtocolMessage{
+					Type: "request",
+				},
+				Command: "continue",
+			},
+		},
+	}).Unpack()
+	require.ErrorIs(t, err, context.Canceled)
+
+	cancelReq := (<-requests).(*dap.CancelRequest)
+	require.Equal(t, 1, cancelReq.Arguments.RequestId)
+
+	// A request whose context is already done isn't sent.
+	_, err = s.Receive(context.Background(), &DAPRequest{
+		Ctx:     ctx,
+		Request: threadsRequest(),
+	}).Unpack()
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, requests)
+}
@@ -0,0 +1,244 @@
+package debugger
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SymbolKind identifies the kind of a symbol found in a debugged binary.
+type SymbolKind string
+
+const (
+	// SymbolFunction is a function or method.
+	SymbolFunction SymbolKind = "function"
+
+	// SymbolType is a named type.
+	SymbolType SymbolKind = "type"
+
+	// SymbolVariable is a package-level variable.
+	SymbolVariable SymbolKind = "variable"
+)
+
+// Symbol describes a function, type or package-level variable defined in a
+// debugged binary.
+type Symbol struct {
+	// Name is the fully qualified name of the symbol. For functions this
+	// is the name Delve expects for function breakpoints, e.g.
+	// "main.(*Worker).process".
+	Name string
+
+	// Kind is the kind of the symbol.
+	Kind SymbolKind
+
+	// File is the source file the symbol is declared in, if known.
+	File string
+
+	// Line is the line the symbol is declared on, if known.
+	Line int
+}
+
+// SearchSymbols lists the functions, types and package-level variables in the
+// binary at binaryPath whose names match the given regular expression. The
+// results are read from the binary's DWARF information and are sorted by kind
+// and then name. If kind is non-empty, only symbols of that kind are returned.
+func SearchSymbols(binaryPath, pattern string,
+	kind SymbolKind) ([]Symbol, error) {
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid symbol pattern: %w", err)
+	}
+
+	switch kind {
+	case "", SymbolFunction, SymbolType, SymbolVariable:
+	default:
+		return nil, fmt.Errorf("unknown symbol kind: %q", kind)
+	}
+
+	data, err := loadDWARF(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		symbols []Symbol
+		seen    = make(map[string]struct{})
+		reader  = data.Reader()
+		files   []*dwarf.LineFile
+		lines   *dwarf.LineReader
+	)
+
+	// add records the symbol described by entry if it matches the search,
+	// resolving its source location only once we know it's wanted since
+	// that may require a scan of the line table.
+	add := func(entry *dwarf.Entry, name string, symKind SymbolKind) {
+		if kind != "" && symKind != kind {
+			return
+		}
+		if !re.MatchString(name) {
+			return
+		}
+
+		key := string(symKind) + ":" + name
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+
+		file, line := declLocation(entry, files, lines)
+		symbols = append(symbols, Symbol{
+			Name: name,
+			Kind: symKind,
+			File: file,
+			Line: line,
+		})
+	}
+
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read DWARF entry: %w",
+				err)
+		}
+		if entry == nil {
+			break
+		}
+
+		switch entry.Tag {
+		// Each compile unit carries its own file table, which the
+		// declaration attributes of its children index into.
+		case dwarf.TagCompileUnit:
+			files, lines = nil, nil
+			lr, err := data.LineReader(entry)
+			if err == nil && lr != nil {
+				files, lines = lr.Files(), lr
+			}
+
+		case dwarf.TagSubprogram:
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			if name != "" {
+				add(entry, name, SymbolFunction)
+			}
+
+			// Locals and parameters live below the subprogram, we
+			// only care about package-level declarations.
+			reader.SkipChildren()
+
+		case dwarf.TagVariable:
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			if name != "" {
+				add(entry, name, SymbolVariable)
+			}
+
+		case dwarf.TagStructType, dwarf.TagTypedef,
+			dwarf.TagInterfaceType, dwarf.TagBaseType:
+
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			if isNamedType(name) {
+				add(entry, name, SymbolType)
+			}
+			reader.SkipChildren()
+
+		default:
+			if entry.Children {
+				reader.SkipChildren()
+			}
+		}
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].Kind != symbols[j].Kind {
+			return symbols[i].Kind < symbols[j].Kind
+		}
+		return symbols[i].Name < symbols[j].Name
+	})
+
+	return symbols, nil
+}
+
+// loadDWARF opens the executable at path, trying each of the object formats
+// supported by Delve, and returns its DWARF data.
+func loadDWARF(path string) (*dwarf.Data, error) {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		return dwarfOrErr(f.DWARF())
+	}
+
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		return dwarfOrErr(f.DWARF())
+	}
+
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		return dwarfOrErr(f.DWARF())
+	}
+
+	return nil, fmt.Errorf("%s is not a supported executable", path)
+}
+
+// dwarfOrErr wraps a DWARF loading error with a hint about stripped binaries.
+func dwarfOrErr(data *dwarf.Data, err error) (*dwarf.Data, error) {
+	if err != nil {
+		return nil, fmt.Errorf("binary has no usable DWARF information "+
+			"(was it built with -ldflags=-w?): %w", err)
+	}
+
+	return data, nil
+}
+
+// declLocation returns the source file and line for a DWARF entry. The
+// declaration attributes are preferred, falling back to the line table entry
+// for the entry's low PC for functions that don't carry them.
+func declLocation(entry *dwarf.Entry, files []*dwarf.LineFile,
+	lines *dwarf.LineReader) (string, int) {
+
+	var file string
+	if idx, ok := entry.Val(dwarf.AttrDeclFile).(int64); ok {
+		if idx >= 0 && int(idx) < len(files) && files[idx] != nil {
+			file = files[idx].Name
+		}
+	}
+
+	line, _ := entry.Val(dwarf.AttrDeclLine).(int64)
+	if file != "" && line > 0 {
+		return file, int(line)
+	}
+
+	lowPC, ok := entry.Val(dwarf.AttrLowpc).(uint64)
+	if !ok || lines == nil {
+		return file, int(line)
+	}
+
+	var lineEntry dwarf.LineEntry
+	err := lines.SeekPC(lowPC, &lineEntry)
+	if err != nil || lineEntry.File == nil {
+		return file, int(line)
+	}
+
+	return lineEntry.File.Name, lineEntry.Line
+}
+
+// isNamedType reports whether a DWARF type name refers to a declared type as
+// opposed to a composite type literal such as a pointer, slice or map.
+func isNamedType(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, prefix := range []string{"*", "[", "map[", "chan ", "<-chan",
+		"func(", "struct {", "interface {"} {
+
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
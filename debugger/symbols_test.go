@@ -0,0 +1,84 @@
+package debugger
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// buildWorkerExample compiles the worker example program with debug
+// information and returns the path of the resulting binary.
+func buildWorkerExample(t *testing.T) string {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	binary := filepath.Join(t.TempDir(), "worker")
+	cmd := exec.Command(
+		goBin, "build", "-gcflags=all=-N -l", "-o", binary,
+		"../examples/worker",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return binary
+}
+
+// TestSearchSymbolsFunctions tests that functions and methods are found along
+// with their declaring source file and line.
+func TestSearchSymbolsFunctions(t *testing.T) {
+	symbols, err := SearchSymbols(
+		buildWorkerExample(t), `^main\.\(\*WorkerPool\)\.process`,
+		SymbolFunction,
+	)
+	require.NoError(t, err)
+	require.Len(t, symbols, 1)
+
+	sym := symbols[0]
+	require.Equal(t, SymbolFunction, sym.Kind)
+	require.Equal(t, "main.(*WorkerPool).processTask", sym.Name)
+	require.Equal(t, "worker.go", filepath.Base(sym.File))
+	require.Equal(t, 72, sym.Line)
+}
+
+// TestSearchSymbolsKinds tests that types and package-level variables are
+// reported with the right kind and that the kind filter is honoured.
+func TestSearchSymbolsKinds(t *testing.T) {
+	binary := buildWorkerExample(t)
+
+	// Without a kind filter the pattern should match both the type and
+	// the variable.
+	symbols, err := SearchSymbols(binary, `^(main\.Task|os\.Args)$`, "")
+	require.NoError(t, err)
+	require.Len(t, symbols, 2)
+	require.Equal(t, "main.Task", symbols[0].Name)
+	require.Equal(t, SymbolType, symbols[0].Kind)
+	require.Equal(t, "os.Args", symbols[1].Name)
+	require.Equal(t, SymbolVariable, symbols[1].Kind)
+
+	// With a kind filter only the variable should be returned.
+	symbols, err = SearchSymbols(
+		binary, `^(main\.Task|os\.Args)$`, SymbolVariable,
+	)
+	require.NoError(t, err)
+	require.Len(t, symbols, 1)
+	require.Equal(t, "os.Args", symbols[0].Name)
+}
+
+// TestSearchSymbolsErrors tests the error paths of SearchSymbols.
+func TestSearchSymbolsErrors(t *testing.T) {
+	_, err := SearchSymbols("symbols.go", "(", "")
+	require.ErrorContains(t, err, "invalid symbol pattern")
+
+	_, err = SearchSymbols("symbols.go", "main", "constant")
+	require.ErrorContains(t, err, "unknown symbol kind")
+
+	// A source file is not an executable.
+	_, err = SearchSymbols("symbols.go", "main", "")
+	require.ErrorContains(t, err, "not a supported executable")
+}
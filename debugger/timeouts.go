@@ -0,0 +1,40 @@
+package debugger
+
+import "time"
+
+// RequestTimeouts bounds how long a session waits for Delve to answer a DAP
+// request. Requests that take longer are cancelled so that a hung request
+// doesn't block the session. A zero timeout waits as long as the caller's
+// context allows.
+type RequestTimeouts struct {
+	// Default applies to commands without a timeout in Commands.
+	Default time.Duration
+
+	// Commands holds the timeouts of individual DAP commands, such as
+	// "launch" or "evaluate".
+	Commands map[string]time.Duration
+}
+
+// DefaultRequestTimeouts returns the timeouts sessions use by default.
+// Launching builds the program, which can take minutes, while evaluating an
+// expression should be quick unless it calls a function that never returns.
+func DefaultRequestTimeouts() RequestTimeouts {
+	return RequestTimeouts{
+		Default: 30 * time.Second,
+		Commands: map[string]time.Duration{
+			"launch":     5 * time.Minute,
+			"attach":     time.Minute,
+			"disconnect": time.Minute,
+			"evaluate":   10 * time.Second,
+		},
+	}
+}
+
+// For returns the timeout of a DAP command.
+func (t RequestTimeouts) For(command string) time.Duration {
+	if timeout, ok := t.Commands[command]; ok {
+		return timeout
+	}
+
+	return t.Default
+}
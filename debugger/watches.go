@@ -0,0 +1,415 @@
+package debugger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
+)
+
+// DefaultWatchHistory is the number of values kept per watch expression when
+// no limit is given.
+const DefaultWatchHistory = 100
+
+// WatchValue is the value of a watch expression at one stop.
+type WatchValue struct {
+	// Expression is the watched expression.
+	Expression string `json:"expression"`
+
+	// Value is the evaluated value, empty if evaluation failed.
+	Value string `json:"value,omitempty"`
+
+	// Type is the type of the value, if reported by the debugger.
+	Type string `json:"type,omitempty"`
+
+	// Error is the reason the expression could not be evaluated.
+	Error string `json:"error,omitempty"`
+}
+
+// WatchStop is the result of evaluating all watch expressions at a stop.
+type WatchStop struct {
+	// Stop numbers the stops seen by the tracker, starting at 1.
+	Stop int `json:"stop"`
+
+	// Time is when the program stopped.
+	Time time.Time `json:"time"`
+
+	// Reason is the reason given by the debugger for the stop, such as
+	// "breakpoint" or "step".
+	Reason string `json:"reason"`
+
+	// Location is where the stopped thread was, or nil if its stack
+	// could not be read.
+	Location *StopLocation `json:"location,omitempty"`
+
+	// Values holds the value of each watch expression in the order they
+	// were added. It is empty if there were no watches.
+	Values []WatchValue `json:"values"`
+}
+
+// WatchSample is one entry in the history of a watch expression.
+type WatchSample struct {
+	WatchValue
+
+	// Stop is the number of the stop the value was recorded at.
+	Stop int `json:"stop"`
+
+	// Time is when the program stopped.
+	Time time.Time `json:"time"`
+
+	// Location is where the stopped thread was, if known.
+	Location *StopLocation `json:"location,omitempty"`
+}
+
+// WatchTracker keeps a session's watch expressions and re-evaluates them in
+// the top frame of the stopped thread every time the program stops. The values
+// are kept as a bounded history per expression.
+type WatchTracker struct {
+	session actor.ActorRef[*DAPRequest, *DAPResponse]
+
+	// onStop, if set, is called with the values recorded at each stop.
+	onStop func(*WatchStop)
+
+	// maxHistory is the number of values kept per expression.
+	maxHistory int
+
+	mu sync.Mutex
+
+	expressions []string
+	history     map[string][]WatchSample
+	stops       int
+
+	// suspended counts the callers that have suspended evaluation, and
+	// pending is the last stop seen while suspended, or nil if the
+	// program has since resumed or exited.
+	suspended int
+	pending   *dap.StoppedEvent
+
+	// queue feeds stops to the evaluation goroutine, as event handlers
+	// must not issue requests to the session.
+	queue chan *dap.StoppedEvent
+	quit  chan struct{}
+	wg    sync.WaitGroup
+
+	unsubscribe func()
+}
+
+// NewWatchTracker creates a tracker that evaluates watch expressions on the
+// stops published on the given bus, keeping up to maxHistory values per
+// expression. A non-positive maxHistory uses DefaultWatchHistory. If onStop is
+// non-nil, it is called for every stop that isn't suppressed by Suspend, even
+// if there are no watches, which makes it a convenient place to report stops.
+func NewWatchTracker(session actor.ActorRef[*DAPRequest, *DAPResponse],
+	events *EventBus, maxHistory int,
+	onStop func(*WatchStop)) *WatchTracker {
+
+	if maxHistory <= 0 {
+		maxHistory = DefaultWatchHistory
+	}
+
+	t := &WatchTracker{
+		session:     session,
+		onStop:      onStop,
+		maxHistory:  maxHistory,
+		history:     make(map[string][]WatchSample),
+		queue:       make(chan *dap.StoppedEvent, 16),
+		quit:        make(chan struct{}),
+		unsubscribe: func() {},
+	}
+
+	t.wg.Add(1)
+	go t.evaluateStops()
+
+	if events != nil {
+		t.unsubscribe = events.Subscribe(t.handleEvent)
+	}
+
+	return t
+}
+
+// Add adds watch expressions, ignoring any that are already watched. It
+// returns the expressions that were added.
+func (t *WatchTracker) Add(expressions ...string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var added []string
+	for _, expr := range expressions {
+		if expr == "" || t.watching(expr) {
+			continue
+		}
+
+		t.expressions = append(t.expressions, expr)
+		added = append(added, expr)
+	}
+
+	return added
+}
+
+// Remove stops watching the given expressions and drops their history. It
+// returns the expressions that were being watched.
+func (t *WatchTracker) Remove(expressions ...string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var removed []string
+	for _, expr := range expressions {
+		for i, watched := range t.expressions {
+			if watched != expr {
+				continue
+			}
+
+			t.expressions = append(
+				t.expressions[:i:i], t.expressions[i+1:]...,
+			)
+			delete(t.history, expr)
+			removed = append(removed, expr)
+
+			break
+		}
+	}
+
+	return removed
+}
+
+// Set replaces all watch expressions. History is kept for expressions that
+// are still watched.
+func (t *WatchTracker) Set(expressions []string) {
+	t.mu.Lock()
+	t.expressions = nil
+	t.mu.Unlock()
+
+	t.Add(expressions...)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for expr := range t.history {
+		if !t.watching(expr) {
+			delete(t.history, expr)
+		}
+	}
+}
+
+// Expressions returns the watch expressions in the order they were added.
+func (t *WatchTracker) Expressions() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.expressions...)
+}
+
+// History returns the recorded values of a watch expression, oldest first,
+// and whether the expression is watched.
+func (t *WatchTracker) History(expression string) ([]WatchSample, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.watching(expression) {
+		return nil, false
+	}
+
+	return append([]WatchSample(nil), t.history[expression]...), true
+}
+
+// Latest returns the most recently recorded value of each watch expression,
+// in the order they were added. Expressions that haven't been evaluated yet
+// are omitted.
+func (t *WatchTracker) Latest() []WatchSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var latest []WatchSample
+	for _, expr := range t.expressions {
+		if samples := t.history[expr]; len(samples) > 0 {
+			latest = append(latest, samples[len(samples)-1])
+		}
+	}
+
+	return latest
+}
+
+// Suspend stops watches from being evaluated until the returned function is
+// called, which is used while tools step or resume the program many times on
+// their own. Once the last suspension ends, the watches are evaluated at the
+// final stop if the program is still stopped.
+func (t *WatchTracker) Suspend() func() {
+	t.mu.Lock()
+	t.suspended++
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			t.suspended--
+			var stop *dap.StoppedEvent
+			if t.suspended == 0 {
+				stop, t.pending = t.pending, nil
+			}
+			t.mu.Unlock()
+
+			if stop != nil {
+				t.enqueue(stop)
+			}
+		})
+	}
+}
+
+// Stop stops tracking stops and waits for any evaluation in progress.
+func (t *WatchTracker) Stop() {
+	t.unsubscribe()
+
+	select {
+	case <-t.quit:
+	default:
+		close(t.quit)
+	}
+	t.wg.Wait()
+}
+
+// watching reports whether an expression is watched. The mutex must be held.
+func (t *WatchTracker) watching(expression string) bool {
+	for _, expr := range t.expressions {
+		if expr == expression {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleEvent queues stops for evaluation, or remembers them while evaluation
+// is suspended.
+func (t *WatchTracker) handleEvent(event dap.EventMessage) {
+	var stop *dap.StoppedEvent
+	switch e := event.(type) {
+	case *dap.StoppedEvent:
+		stop = e
+
+	case *dap.ContinuedEvent, *dap.ExitedEvent, *dap.TerminatedEvent:
+
+	default:
+		return
+	}
+
+	t.mu.Lock()
+	if t.suspended > 0 {
+		t.pending = stop
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+
+	if stop != nil {
+		t.enqueue(stop)
+	}
+}
+
+// enqueue hands a stop to the evaluation goroutine. Stops are dropped if the
+// queue is full, as the values would be stale by the time they're evaluated.
+func (t *WatchTracker) enqueue(stop *dap.StoppedEvent) {
+	select {
+	case t.queue <- stop:
+	case <-t.quit:
+	default:
+		sessionLog(t.session).Warn("Dropping stop, watch evaluation "+
+			"is falling behind", "thread_id", stop.Body.ThreadId)
+	}
+}
+
+// evaluateStops evaluates the watch expressions for each queued stop. It must
+// be run as a goroutine.
+func (t *WatchTracker) evaluateStops() {
+	defer t.wg.Done()
+
+	// Evaluations in progress are abandoned once the tracker is stopped.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-t.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case stop := <-t.queue:
+			result := t.evaluate(ctx, stop)
+			if t.onStop != nil {
+				t.onStop(result)
+			}
+
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// evaluate evaluates all watch expressions in the top frame of the stopped
+// thread and records the values.
+func (t *WatchTracker) evaluate(ctx context.Context,
+	stop *dap.StoppedEvent) *WatchStop {
+
+	expressions := t.Expressions()
+
+	result := &WatchStop{
+		Time:   time.Now(),
+		Reason: stop.Body.Reason,
+		Values: make([]WatchValue, len(expressions)),
+	}
+
+	location, err := TopStopLocation(ctx, t.session, stop.Body.ThreadId)
+	if err == nil {
+		result.Location = location
+	}
+
+	for i, expr := range expressions {
+		result.Values[i].Expression = expr
+		if err != nil {
+			result.Values[i].Error = err.Error()
+			continue
+		}
+
+		value, evalErr := EvaluateExpressionResult(
+			ctx, t.session, expr, location.FrameID,
+		)
+		if evalErr != nil {
+			result.Values[i].Error = evalErr.Error()
+			continue
+		}
+
+		result.Values[i].Value = value.Result
+		result.Values[i].Type = value.Type
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stops++
+	result.Stop = t.stops
+
+	for _, value := range result.Values {
+		// Expressions removed during evaluation aren't recorded.
+		if !t.watching(value.Expression) {
+			continue
+		}
+
+		samples := append(t.history[value.Expression], WatchSample{
+			WatchValue: value,
+			Stop:       result.Stop,
+			Time:       result.Time,
+			Location:   result.Location,
+		})
+		if len(samples) > t.maxHistory {
+			samples = samples[len(samples)-t.maxHistory:]
+		}
+		t.history[value.Expression] = samples
+	}
+
+	return result
+}
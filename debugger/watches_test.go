@@ -0,0 +1,130 @@
+package debugger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+// nextWatchStop waits for the watch values recorded at the next stop.
+func nextWatchStop(t *testing.T, stops <-chan *WatchStop) *WatchStop {
+	t.Helper()
+
+	select {
+	case stop := <-stops:
+		return stop
+
+	case <-time.After(time.Second):
+		t.Fatal("watches not evaluated")
+		return nil
+	}
+}
+
+// TestWatchTracker tests that watch expressions are evaluated at every stop
+// and kept as a bounded history.
+func TestWatchTracker(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProgram{
+		events: bus, line: 10, trueFrom: 12, function: "main.main",
+	}
+	session := startMockProgram(t, program)
+
+	stops := make(chan *WatchStop, 10)
+	tracker := NewWatchTracker(session, bus, 2, func(stop *WatchStop) {
+		stops <- stop
+	})
+	t.Cleanup(tracker.Stop)
+
+	require.Equal(t, []string{"done", "i"}, tracker.Add("done", "i", "done"))
+	require.Equal(t, []string{"done", "i"}, tracker.Expressions())
+
+	_, err := Next(context.Background(), session, 1)
+	require.NoError(t, err)
+
+	stop := nextWatchStop(t, stops)
+	require.Equal(t, 1, stop.Stop)
+	require.Equal(t, "step", stop.Reason)
+	require.Equal(t, 11, stop.Location.Line)
+	require.Equal(t, []WatchValue{
+		{Expression: "done", Value: "false"},
+		{Expression: "i", Value: "false"},
+	}, stop.Values)
+
+	// The history only keeps the most recent values.
+	for i := 0; i < 2; i++ {
+		_, err := Next(context.Background(), session, 1)
+		require.NoError(t, err)
+		nextWatchStop(t, stops)
+	}
+
+	history, ok := tracker.History("done")
+	require.True(t, ok)
+	require.Len(t, history, 2)
+	require.Equal(t, 2, history[0].Stop)
+	require.Equal(t, 12, history[0].Location.Line)
+	require.Equal(t, "true", history[1].Value)
+
+	latest := tracker.Latest()
+	require.Len(t, latest, 2)
+	require.Equal(t, 3, latest[1].Stop)
+
+	// Removed expressions lose their history.
+	require.Equal(t, []string{"i"}, tracker.Remove("i", "missing"))
+	_, ok = tracker.History("i")
+	require.False(t, ok)
+
+	tracker.Set([]string{"done", "x"})
+	history, _ = tracker.History("done")
+	require.Len(t, history, 2)
+}
+
+// TestWatchTrackerSuspend tests that stops aren't evaluated while suspended,
+// and that the final stop is evaluated once evaluation resumes.
+func TestWatchTrackerSuspend(t *testing.T) {
+	bus := NewEventBus()
+	program := &mockProgram{
+		events: bus, line: 10, trueFrom: 100, function: "main.main",
+	}
+	session := startMockProgram(t, program)
+
+	stops := make(chan *WatchStop, 10)
+	tracker := NewWatchTracker(session, bus, 0, func(stop *WatchStop) {
+		stops <- stop
+	})
+	t.Cleanup(tracker.Stop)
+	tracker.Add("x")
+
+	resume := tracker.Suspend()
+	for i := 0; i < 3; i++ {
+		_, err := Next(context.Background(), session, 1)
+		require.NoError(t, err)
+	}
+	require.Empty(t, stops)
+
+	resume()
+	resume()
+
+	stop := nextWatchStop(t, stops)
+	require.Equal(t, 1, stop.Stop)
+	require.Equal(t, 13, stop.Location.Line)
+
+	// A program that exits while suspended has no final stop.
+	resume = tracker.Suspend()
+	_, err := Next(context.Background(), session, 1)
+	require.NoError(t, err)
+	bus.Publish(&dap.TerminatedEvent{})
+	resume()
+
+	select {
+	case stop := <-stops:
+		t.Fatalf("unexpected watch evaluation at stop %d", stop.Stop)
+
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	history, _ := tracker.History("x")
+	require.Len(t, history, 1)
+}
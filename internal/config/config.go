@@ -0,0 +1,850 @@
+// Package config loads the settings of dlv-mcp-server from a config file, the
+// environment and the command line.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+	"github.com/roasbeef/mcp-debug/mcp"
+)
+
+const (
+	// FileName is the name of the config file looked for in the log
+	// directory if no config file is given.
+	FileName = "config.json"
+
+	// AuditFileName is the name of the audit log in the log directory.
+	AuditFileName = "audit.jsonl"
+
+	// EnvPrefix is the prefix of the environment variables that override
+	// settings, e.g. DLV_MCP_MAX_SESSIONS for -max-sessions.
+	EnvPrefix = "DLV_MCP_"
+
+	// configFlag is the flag, and with EnvPrefix the environment variable,
+	// naming the config file.
+	configFlag = "config"
+)
+
+// Duration is a time.Duration that is written as a string such as "30m" in
+// the config file.
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+
+	return nil
+}
+
+// Retry configures how often starting Delve is retried.
+type Retry struct {
+	MaxAttempts  int      `json:"max_attempts"`
+	InitialDelay Duration `json:"initial_delay"`
+	MaxDelay     Duration `json:"max_delay"`
+	Multiplier   float64  `json:"multiplier"`
+}
+
+// Timeouts bounds how long Delve may take to answer DAP requests.
+type Timeouts struct {
+	Request  Duration            `json:"request"`
+	Commands map[string]Duration `json:"commands"`
+}
+
+// Sessions bounds the number and lifetime of debug sessions.
+type Sessions struct {
+	Max          int      `json:"max"`
+	MaxPerClient int      `json:"max_per_client"`
+	IdleTimeout  Duration `json:"idle_timeout"`
+}
+
+// Policy restricts what MCP clients may do, see mcp.Policy.
+type Policy struct {
+	AllowedDirs   []string `json:"allowed_dirs"`
+	DisableAttach bool     `json:"disable_attach"`
+	ReadOnly      bool     `json:"read_only"`
+	DeniedEnv     []string `json:"denied_env"`
+}
+
+// Audit configures the audit log of tool calls.
+type Audit struct {
+	Enabled   bool     `json:"enabled"`
+	MaxSizeMB int      `json:"max_size_mb"`
+	MaxFiles  int      `json:"max_files"`
+	Redact    []string `json:"redact"`
+}
+
+// Config holds the settings of dlv-mcp-server.
+type Config struct {
+	// DlvPath is the dlv executable, either a path or a name looked up in
+	// PATH.
+	DlvPath string `json:"dlv_path"`
+
+	// LogDir is the directory logs and other server state, such as
+	// workspaces, are kept in.
+	LogDir string `json:"log_dir"`
+
+	// LogLevel is the minimum level of log messages, e.g. "debug".
+	LogLevel string `json:"log_level"`
+
+	// LogFormat is the encoding of the log, "text" or "json".
+	LogFormat string `json:"log_format"`
+
+	// Transport is how MCP clients connect, see mcp.Transport.
+	Transport string `json:"transport"`
+
+	// Listen is the address the HTTP transports listen on.
+	Listen string `json:"listen"`
+
+	// MetricsListen is the address metrics are served on for Prometheus,
+	// or empty to not serve them.
+	MetricsListen string `json:"metrics_listen"`
+
+	// BuildFlags are added to the build flags of every program built for
+	// debugging.
+	BuildFlags []string `json:"build_flags"`
+
+	// Retry configures how often starting Delve is retried.
+	Retry Retry `json:"retry"`
+
+	// Timeouts bounds how long Delve may take to answer DAP requests.
+	Timeouts Timeouts `json:"timeouts"`
+
+	// Sessions bounds the number and lifetime of debug sessions.
+	Sessions Sessions `json:"sessions"`
+
+	// Policy restricts what MCP clients may do.
+	Policy Policy `json:"policy"`
+
+	// Audit configures the audit log of tool calls.
+	Audit Audit `json:"audit"`
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	delve := debugger.DefaultDelveConfig()
+	limits := mcp.DefaultSessionLimits()
+
+	// Without a home directory, the log directory has to be configured.
+	logDir, _ := logging.DefaultDir()
+
+	return Config{
+		DlvPath:   delve.Path,
+		LogDir:    logDir,
+		LogLevel:  "info",
+		LogFormat: string(logging.FormatText),
+		Transport: string(mcp.TransportStdio),
+		Listen:    mcp.DefaultListenAddress,
+		Retry: Retry{
+			MaxAttempts:  delve.Retry.MaxAttempts,
+			InitialDelay: Duration(delve.Retry.InitialDelay),
+			MaxDelay:     Duration(delve.Retry.MaxDelay),
+			Multiplier:   delve.Retry.Multiplier,
+		},
+		Timeouts: Timeouts{
+			Request:  Duration(delve.Timeouts.Default),
+			Commands: durations(delve.Timeouts.Commands),
+		},
+		Sessions: Sessions{
+			Max:          limits.MaxSessions,
+			MaxPerClient: limits.MaxSessionsPerClient,
+			IdleTimeout:  Duration(limits.IdleTimeout),
+		},
+		Audit: Audit{
+			Enabled:   true,
+			MaxSizeMB: 50,
+			MaxFiles:  10,
+			Redact:    []string{"env"},
+		},
+	}
+}
+
+// option is a setting that can be given on the command line and in the
+// environment.
+type option struct {
+	name  string
+	usage string
+	get   func(c *Config) string
+	set   func(c *Config, value string) error
+
+	// boolean marks settings that are switched on by a bare flag.
+	boolean bool
+}
+
+// options lists the settings in the order they are shown.
+var options = []option{
+	{
+		name:  "dlv",
+		usage: "dlv executable to run, as a path or a name in PATH",
+		get:   func(c *Config) string { return c.DlvPath },
+		set:   setString(func(c *Config) *string { return &c.DlvPath }),
+	},
+	{
+		name:  "log-dir",
+		usage: "directory for logs, workspaces and other server state",
+		get:   func(c *Config) string { return c.LogDir },
+		set:   setString(func(c *Config) *string { return &c.LogDir }),
+	},
+	{
+		name: "log-level",
+		usage: "minimum level of log messages: debug (includes every " +
+			"DAP message), info, warn or error",
+		get: func(c *Config) string { return c.LogLevel },
+		set: setString(func(c *Config) *string { return &c.LogLevel }),
+	},
+	{
+		name:  "log-format",
+		usage: "encoding of the log: 'text' or 'json'",
+		get:   func(c *Config) string { return c.LogFormat },
+		set:   setString(func(c *Config) *string { return &c.LogFormat }),
+	},
+	{
+		name: "transport",
+		usage: "how MCP clients connect: 'stdio' for a single client, " +
+			"or 'sse' or 'http' (streamable HTTP) to share the " +
+			"server between clients",
+		get: func(c *Config) string { return c.Transport },
+		set: setString(func(c *Config) *string { return &c.Transport }),
+	},
+	{
+		name: "listen",
+		usage: "address the sse and http transports listen on, as " +
+			"host:port or unix:<path> for a Unix socket",
+		get: func(c *Config) string { return c.Listen },
+		set: setString(func(c *Config) *string { return &c.Listen }),
+	},
+	{
+		name: "metrics-listen",
+		usage: "address to serve Prometheus metrics on at /metrics, " +
+			"as host:port or unix:<path> (default: not served)",
+		get: func(c *Config) string { return c.MetricsListen },
+		set: setString(func(c *Config) *string {
+			return &c.MetricsListen
+		}),
+	},
+	{
+		name: "build-flags",
+		usage: "build flags added when building programs for " +
+			"debugging, separated by spaces like GOFLAGS",
+		get: func(c *Config) string {
+			return strings.Join(c.BuildFlags, " ")
+		},
+		set: func(c *Config, value string) error {
+			c.BuildFlags = strings.Fields(value)
+			return nil
+		},
+	},
+	{
+		name:  "retry-attempts",
+		usage: "number of attempts to start dlv for a new session",
+		get: func(c *Config) string {
+			return strconv.Itoa(c.Retry.MaxAttempts)
+		},
+		set: setInt(func(c *Config) *int { return &c.Retry.MaxAttempts }),
+	},
+	{
+		name:  "retry-initial-delay",
+		usage: "delay before the first retry of starting dlv",
+		get: func(c *Config) string {
+			return time.Duration(c.Retry.InitialDelay).String()
+		},
+		set: setDuration(func(c *Config) *Duration {
+			return &c.Retry.InitialDelay
+		}),
+	},
+	{
+		name:  "retry-max-delay",
+		usage: "longest delay between retries of starting dlv",
+		get: func(c *Config) string {
+			return time.Duration(c.Retry.MaxDelay).String()
+		},
+		set: setDuration(func(c *Config) *Duration {
+			return &c.Retry.MaxDelay
+		}),
+	},
+	{
+		name:  "retry-multiplier",
+		usage: "factor the delay between retries grows by",
+		get: func(c *Config) string {
+			return strconv.FormatFloat(c.Retry.Multiplier, 'g', -1, 64)
+		},
+		set: func(c *Config, value string) error {
+			multiplier, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			c.Retry.Multiplier = multiplier
+
+			return nil
+		},
+	},
+	{
+		name: "request-timeout",
+		usage: "longest time to wait for dlv to answer a DAP request " +
+			"before cancelling it (0 for no limit)",
+		get: func(c *Config) string {
+			return time.Duration(c.Timeouts.Request).String()
+		},
+		set: setDuration(func(c *Config) *Duration {
+			return &c.Timeouts.Request
+		}),
+	},
+	{
+		name: "command-timeouts",
+		usage: "comma-separated timeouts of individual DAP commands " +
+			"overriding -request-timeout, e.g. 'launch=5m,evaluate=10s'; " +
+			"commands not listed keep their timeout",
+		get: func(c *Config) string {
+			commands := make([]string, 0, len(c.Timeouts.Commands))
+			for command := range c.Timeouts.Commands {
+				commands = append(commands, command)
+			}
+			sort.Strings(commands)
+
+			for i, command := range commands {
+				commands[i] = fmt.Sprintf("%s=%v", command,
+					time.Duration(c.Timeouts.Commands[command]))
+			}
+
+			return strings.Join(commands, ",")
+		},
+		set: func(c *Config, value string) error {
+			timeouts := make(map[string]Duration)
+			for command, timeout := range c.Timeouts.Commands {
+				timeouts[command] = timeout
+			}
+			for _, entry := range splitList(value) {
+				command, timeout, ok := strings.Cut(entry, "=")
+				if !ok {
+					return fmt.Errorf("%q is not "+
+						"command=timeout", entry)
+				}
+
+				d, err := time.ParseDuration(timeout)
+				if err != nil {
+					return err
+				}
+				timeouts[strings.TrimSpace(command)] = Duration(d)
+			}
+			c.Timeouts.Commands = timeouts
+
+			return nil
+		},
+	},
+	{
+		name:  "max-sessions",
+		usage: "maximum number of concurrent debug sessions (0 for no limit)",
+		get: func(c *Config) string {
+			return strconv.Itoa(c.Sessions.Max)
+		},
+		set: setInt(func(c *Config) *int { return &c.Sessions.Max }),
+	},
+	{
+		name: "max-sessions-per-client",
+		usage: "maximum number of concurrent debug sessions per MCP " +
+			"client (0 for no limit)",
+		get: func(c *Config) string {
+			return strconv.Itoa(c.Sessions.MaxPerClient)
+		},
+		set: setInt(func(c *Config) *int {
+			return &c.Sessions.MaxPerClient
+		}),
+	},
+	{
+		name: "idle-timeout",
+		usage: "close debug sessions with no tool calls for this long " +
+			"(0 to disable)",
+		get: func(c *Config) string {
+			return time.Duration(c.Sessions.IdleTimeout).String()
+		},
+		set: setDuration(func(c *Config) *Duration {
+			return &c.Sessions.IdleTimeout
+		}),
+	},
+	{
+		name: "allowed-dirs",
+		usage: "directories programs may be launched from and run in, " +
+			"separated like PATH (default: anywhere)",
+		get: func(c *Config) string {
+			return strings.Join(c.Policy.AllowedDirs,
+				string(filepath.ListSeparator))
+		},
+		set: func(c *Config, value string) error {
+			c.Policy.AllowedDirs = filepath.SplitList(value)
+			return nil
+		},
+	},
+	{
+		name:    "disable-attach",
+		usage:   "don't allow attaching to running processes",
+		boolean: true,
+		get: func(c *Config) string {
+			return strconv.FormatBool(c.Policy.DisableAttach)
+		},
+		set: setBool(func(c *Config) *bool {
+			return &c.Policy.DisableAttach
+		}),
+	},
+	{
+		name: "read-only",
+		usage: "don't allow expressions that call functions or run " +
+			"Delve commands",
+		boolean: true,
+		get: func(c *Config) string {
+			return strconv.FormatBool(c.Policy.ReadOnly)
+		},
+		set: setBool(func(c *Config) *bool {
+			return &c.Policy.ReadOnly
+		}),
+	},
+	{
+		name: "denied-env",
+		usage: "comma-separated environment variables launched " +
+			"programs may not be given, e.g. 'LD_*,GODEBUG'",
+		get: func(c *Config) string {
+			return strings.Join(c.Policy.DeniedEnv, ",")
+		},
+		set: func(c *Config, value string) error {
+			c.Policy.DeniedEnv = splitList(value)
+			return nil
+		},
+	},
+	{
+		name:    "audit",
+		usage:   "record every tool call in " + AuditFileName,
+		boolean: true,
+		get: func(c *Config) string {
+			return strconv.FormatBool(c.Audit.Enabled)
+		},
+		set: setBool(func(c *Config) *bool { return &c.Audit.Enabled }),
+	},
+	{
+		name: "audit-max-size-mb",
+		usage: "size in megabytes at which the audit log is rotated " +
+			"(0 to never rotate)",
+		get: func(c *Config) string {
+			return strconv.Itoa(c.Audit.MaxSizeMB)
+		},
+		set: setInt(func(c *Config) *int { return &c.Audit.MaxSizeMB }),
+	},
+	{
+		name:  "audit-max-files",
+		usage: "number of rotated audit logs to keep (0 to keep all)",
+		get: func(c *Config) string {
+			return strconv.Itoa(c.Audit.MaxFiles)
+		},
+		set: setInt(func(c *Config) *int { return &c.Audit.MaxFiles }),
+	},
+	{
+		name: "audit-redact",
+		usage: "comma-separated tool arguments whose values are left " +
+			"out of the audit log, e.g. 'env,*token*'",
+		get: func(c *Config) string {
+			return strings.Join(c.Audit.Redact, ",")
+		},
+		set: func(c *Config, value string) error {
+			c.Audit.Redact = splitList(value)
+			return nil
+		},
+	},
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
+
+// durations converts the values of a map to Durations.
+func durations(m map[string]time.Duration) map[string]Duration {
+	converted := make(map[string]Duration, len(m))
+	for key, d := range m {
+		converted[key] = Duration(d)
+	}
+
+	return converted
+}
+
+// setBool returns a setter for a boolean setting.
+func setBool(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+
+		return nil
+	}
+}
+
+// setString returns a setter for a string setting.
+func setString(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+// setInt returns a setter for an integer setting.
+func setInt(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+
+		return nil
+	}
+}
+
+// setDuration returns a setter for a duration setting.
+func setDuration(field func(*Config) *Duration) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = Duration(d)
+
+		return nil
+	}
+}
+
+// envName returns the environment variable that overrides a setting.
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// flagValue records the value of a flag so that it can be applied after the
+// config file and environment.
+type flagValue string
+
+// String returns the flag's value.
+func (v *flagValue) String() string {
+	return string(*v)
+}
+
+// Set sets the flag's value.
+func (v *flagValue) Set(value string) error {
+	*v = flagValue(value)
+	return nil
+}
+
+// boolFlagValue is a flagValue for settings that are switched on by a bare
+// flag.
+type boolFlagValue struct {
+	flagValue
+}
+
+// IsBoolFlag tells the flag package that the flag needs no value.
+func (v *boolFlagValue) IsBoolFlag() bool {
+	return true
+}
+
+// RegisterFlags defines a flag for every setting, and one naming the config
+// file, on the flag set. The defaults shown are the built-in ones.
+func RegisterFlags(fs *flag.FlagSet) {
+	defaults := Default()
+
+	fs.String(configFlag, "", fmt.Sprintf("config file to load (default: "+
+		"%s in the log directory, if present) (env %s)", FileName,
+		envName(configFlag)))
+	for _, opt := range options {
+		var value flag.Value = (*flagValue)(new(string))
+		if opt.boolean {
+			value = &boolFlagValue{}
+		}
+		_ = value.Set(opt.get(&defaults))
+
+		fs.Var(value, opt.name, fmt.Sprintf("%s (env %s)", opt.usage,
+			envName(opt.name)))
+	}
+}
+
+// Load builds the configuration from the built-in defaults, the config file,
+// the environment and the flags set on the parsed flag set, each overriding
+// the previous ones, and validates it. It returns the path of the config file
+// that was loaded, which is empty if there was none.
+func Load(fs *flag.FlagSet,
+	lookupEnv func(string) (string, bool)) (*Config, string, error) {
+
+	cfg := Default()
+
+	// The log directory decides where the config file is looked for, so
+	// it's resolved from the environment and flags first.
+	set := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+
+	path, explicit := set[configFlag]
+	if !explicit {
+		path, explicit = lookupEnv(envName(configFlag))
+	}
+	if !explicit {
+		logDir := cfg.LogDir
+		if dir, ok := lookupEnv(envName("log-dir")); ok {
+			logDir = dir
+		}
+		if dir, ok := set["log-dir"]; ok {
+			logDir = dir
+		}
+		path = filepath.Join(logDir, FileName)
+	}
+
+	err := cfg.loadFile(path)
+	switch {
+	case err == nil:
+
+	case !explicit && errors.Is(err, os.ErrNotExist):
+		path = ""
+
+	default:
+		return nil, "", fmt.Errorf("unable to load config file: %w", err)
+	}
+
+	for _, opt := range options {
+		name := envName(opt.name)
+		if value, ok := lookupEnv(name); ok {
+			if err := opt.set(&cfg, value); err != nil {
+				return nil, "", fmt.Errorf("invalid %s: %w",
+					name, err)
+			}
+		}
+	}
+
+	for _, opt := range options {
+		if value, ok := set[opt.name]; ok {
+			if err := opt.set(&cfg, value); err != nil {
+				return nil, "", fmt.Errorf("invalid -%s: %w",
+					opt.name, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	return &cfg, path, nil
+}
+
+// loadFile overrides the settings given in a config file.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Validate checks that the settings can be used.
+func (c *Config) Validate() error {
+	if c.LogDir == "" {
+		return fmt.Errorf("log_dir must be set as the home directory " +
+			"is unknown")
+	}
+
+	// A name is looked up in PATH whenever a session starts, so it may be
+	// installed later, but a path must point at an executable.
+	if c.DlvPath == "" {
+		return fmt.Errorf("dlv_path must not be empty")
+	}
+	if strings.ContainsRune(c.DlvPath, filepath.Separator) {
+		if _, err := exec.LookPath(c.DlvPath); err != nil {
+			return fmt.Errorf("dlv_path: %w", err)
+		}
+	}
+
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if _, err := logging.ParseFormat(c.LogFormat); err != nil {
+		return err
+	}
+
+	transport, err := mcp.ParseTransport(c.Transport)
+	if err != nil {
+		return err
+	}
+	if transport != mcp.TransportStdio && c.Listen == "" {
+		return fmt.Errorf("listen must be set for the %s transport",
+			transport)
+	}
+
+	switch {
+	case c.Retry.MaxAttempts < 1:
+		return fmt.Errorf("retry max_attempts must be at least 1")
+
+	case c.Retry.InitialDelay < 0 || c.Retry.MaxDelay < 0:
+		return fmt.Errorf("retry delays must not be negative")
+
+	case c.Retry.MaxDelay < c.Retry.InitialDelay:
+		return fmt.Errorf("retry max_delay must not be less than " +
+			"initial_delay")
+
+	case c.Retry.Multiplier < 1:
+		return fmt.Errorf("retry multiplier must be at least 1")
+	}
+
+	if c.Timeouts.Request < 0 {
+		return fmt.Errorf("request timeout must not be negative")
+	}
+	for command, timeout := range c.Timeouts.Commands {
+		if timeout < 0 {
+			return fmt.Errorf("timeout of %s requests must not be "+
+				"negative", command)
+		}
+	}
+
+	if c.Sessions.Max < 0 || c.Sessions.MaxPerClient < 0 ||
+		c.Sessions.IdleTimeout < 0 {
+
+		return fmt.Errorf("session limits must not be negative")
+	}
+
+	for _, dir := range c.Policy.AllowedDirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("allowed_dirs: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("allowed_dirs: %s is not a directory",
+				dir)
+		}
+	}
+
+	for _, pattern := range c.Policy.DeniedEnv {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("denied_env: invalid pattern %q",
+				pattern)
+		}
+	}
+
+	if c.Audit.MaxSizeMB < 0 || c.Audit.MaxFiles < 0 {
+		return fmt.Errorf("audit limits must not be negative")
+	}
+	for _, pattern := range c.Audit.Redact {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("audit redact: invalid pattern %q",
+				pattern)
+		}
+	}
+
+	return nil
+}
+
+// Delve returns the configuration Delve servers are started with.
+func (c *Config) Delve() debugger.DelveConfig {
+	commands := make(map[string]time.Duration, len(c.Timeouts.Commands))
+	for command, timeout := range c.Timeouts.Commands {
+		commands[command] = time.Duration(timeout)
+	}
+
+	return debugger.DelveConfig{
+		Path: c.DlvPath,
+		Retry: debugger.RetryConfig{
+			MaxAttempts:  c.Retry.MaxAttempts,
+			InitialDelay: time.Duration(c.Retry.InitialDelay),
+			MaxDelay:     time.Duration(c.Retry.MaxDelay),
+			Multiplier:   c.Retry.Multiplier,
+		},
+		Timeouts: debugger.RequestTimeouts{
+			Default:  time.Duration(c.Timeouts.Request),
+			Commands: commands,
+		},
+	}
+}
+
+// MCPPolicy returns the policy restricting MCP clients.
+func (c *Config) MCPPolicy() mcp.Policy {
+	return mcp.Policy{
+		AllowedDirs:   c.Policy.AllowedDirs,
+		DisableAttach: c.Policy.DisableAttach,
+		ReadOnly:      c.Policy.ReadOnly,
+		DeniedEnv:     c.Policy.DeniedEnv,
+	}
+}
+
+// LogOptions returns the options of the server's logger.
+func (c *Config) LogOptions() logging.Options {
+	// Validated by Validate.
+	level, _ := logging.ParseLevel(c.LogLevel)
+	format, _ := logging.ParseFormat(c.LogFormat)
+
+	return logging.Options{Level: level, Format: format}
+}
+
+// OpenAuditLog opens the audit log in the log directory, or returns nil if the
+// audit log is disabled.
+func (c *Config) OpenAuditLog() (*logging.RotatingFile, error) {
+	if !c.Audit.Enabled {
+		return nil, nil
+	}
+
+	return logging.OpenRotatingFile(
+		filepath.Join(c.LogDir, AuditFileName),
+		int64(c.Audit.MaxSizeMB)<<20, c.Audit.MaxFiles,
+	)
+}
+
+// SessionLimits returns the limits on debug sessions.
+func (c *Config) SessionLimits() mcp.SessionLimits {
+	return mcp.SessionLimits{
+		MaxSessions:          c.Sessions.Max,
+		MaxSessionsPerClient: c.Sessions.MaxPerClient,
+		IdleTimeout:          time.Duration(c.Sessions.IdleTimeout),
+	}
+}
+
+// Summary returns one "name: value" line per setting, for logging at startup.
+func (c *Config) Summary() []string {
+	lines := make([]string, len(options))
+	for i, opt := range options {
+		lines[i] = fmt.Sprintf("%s: %s", opt.name, opt.get(c))
+	}
+
+	return lines
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// load parses the arguments and loads the configuration with the given
+// environment.
+func load(t *testing.T, env map[string]string, args ...string) (*Config,
+	string, error) {
+
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	RegisterFlags(fs)
+	require.NoError(t, fs.Parse(args))
+
+	return Load(fs, func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+}
+
+// TestLoad tests that the config file, environment and flags override the
+// defaults in that order.
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	// Without a config file the defaults are used.
+	cfg, file, err := load(t, nil, "-log-dir", dir)
+	require.NoError(t, err)
+	require.Empty(t, file)
+	require.Equal(t, dir, cfg.LogDir)
+	require.Equal(t, "dlv", cfg.DlvPath)
+	require.Equal(t, Default().Retry, cfg.Retry)
+
+	// The config file in the log directory is picked up.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte(`{
+		"transport": "http",
+		"build_flags": ["-tags", "integration"],
+		"retry": {"max_attempts": 10, "max_delay": "2s"},
+		"sessions": {"max": 2, "idle_timeout": "1h"},
+		"timeouts": {"request": "1m", "commands": {"evaluate": "2s"}}
+	}`), 0o644))
+
+	env := map[string]string{
+		"DLV_MCP_LOG_DIR":      dir,
+		"DLV_MCP_MAX_SESSIONS": "3",
+		"DLV_MCP_TRANSPORT":    "sse",
+	}
+	cfg, file, err = load(t, env, "-transport", "stdio",
+		"-build-flags", "-race -tags=ci", "-command-timeouts", "next=5s")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, FileName), file)
+
+	require.Equal(t, "stdio", cfg.Transport)
+	require.Equal(t, []string{"-race", "-tags=ci"}, cfg.BuildFlags)
+	require.Equal(t, 3, cfg.Sessions.Max)
+	require.Equal(t, time.Hour, cfg.SessionLimits().IdleTimeout)
+	require.Equal(t, 10, cfg.Delve().Retry.MaxAttempts)
+	require.Equal(t, 2*time.Second, cfg.Delve().Retry.MaxDelay)
+	require.Equal(t, Default().Retry.InitialDelay, cfg.Retry.InitialDelay)
+
+	// Command timeouts are added to the defaults.
+	timeouts := cfg.Delve().Timeouts
+	require.Equal(t, time.Minute, timeouts.For("threads"))
+	require.Equal(t, 2*time.Second, timeouts.For("evaluate"))
+	require.Equal(t, 5*time.Second, timeouts.For("next"))
+	require.Equal(t, 5*time.Minute, timeouts.For("launch"))
+	require.Contains(t, cfg.Summary(), "command-timeouts: attach=1m0s,"+
+		"disconnect=1m0s,evaluate=2s,launch=5m0s,next=5s")
+	require.Contains(t, cfg.Summary(), "max-sessions: 3")
+	require.Equal(t, slog.LevelInfo, cfg.LogOptions().Level)
+
+	// Policy switches are set by bare flags.
+	env = map[string]string{"DLV_MCP_DENIED_ENV": "LD_*, GODEBUG"}
+	cfg, _, err = load(t, env, "-log-dir", dir, "-read-only",
+		"-allowed-dirs", dir)
+	require.NoError(t, err)
+	policy := cfg.MCPPolicy()
+	require.True(t, policy.ReadOnly)
+	require.False(t, policy.DisableAttach)
+	require.Equal(t, []string{dir}, policy.AllowedDirs)
+	require.Equal(t, []string{"LD_*", "GODEBUG"}, policy.DeniedEnv)
+
+	// The audit log is written to the log directory unless disabled.
+	auditLog, err := cfg.OpenAuditLog()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, AuditFileName), auditLog.Path())
+	require.NoError(t, auditLog.Close())
+	require.Equal(t, []string{"env"}, cfg.Audit.Redact)
+
+	env = map[string]string{"DLV_MCP_AUDIT_REDACT": "env,*token*"}
+	cfg, _, err = load(t, env, "-log-dir", dir, "-audit=false")
+	require.NoError(t, err)
+	require.Equal(t, []string{"env", "*token*"}, cfg.Audit.Redact)
+
+	auditLog, err = cfg.OpenAuditLog()
+	require.NoError(t, err)
+	require.Nil(t, auditLog)
+
+	// A config file that is given explicitly must exist.
+	_, _, err = load(t, nil, "-config", filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
+
+// TestLoadInvalid tests that invalid settings are rejected.
+func TestLoadInvalid(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "custom.json")
+
+	tests := []struct {
+		name string
+		file string
+		args []string
+	}{
+		{
+			name: "unknown setting",
+			file: `{"max_sessions": 2}`,
+		},
+		{
+			name: "bad duration",
+			file: `{"sessions": {"idle_timeout": 30}}`,
+		},
+		{
+			name: "unknown transport",
+			args: []string{"-transport", "websocket"},
+		},
+		{
+			name: "no attempts",
+			args: []string{"-retry-attempts", "0"},
+		},
+		{
+			name: "shrinking delay",
+			args: []string{"-retry-multiplier", "0.5"},
+		},
+		{
+			name: "negative limit",
+			args: []string{"-max-sessions", "-1"},
+		},
+		{
+			name: "missing dlv",
+			args: []string{"-dlv", filepath.Join(dir, "dlv")},
+		},
+		{
+			name: "missing allowed dir",
+			args: []string{"-allowed-dirs", filepath.Join(dir, "x")},
+		},
+		{
+			name: "bad env pattern",
+			file: `{"policy": {"denied_env": ["LD_["]}}`,
+		},
+		{
+			name: "negative audit files",
+			args: []string{"-audit-max-files", "-1"},
+		},
+		{
+			name: "negative timeout",
+			args: []string{"-request-timeout", "-1s"},
+		},
+		{
+			name: "bad command timeout",
+			args: []string{"-command-timeouts", "evaluate"},
+		},
+		{
+			name: "unknown log level",
+			args: []string{"-log-level", "verbose"},
+		},
+		{
+			name: "unknown log format",
+			file: `{"log_format": "xml"}`,
+		},
+		{
+			name: "not a number",
+			args: []string{"-max-sessions", "many"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := test.file
+			if file == "" {
+				file = "{}"
+			}
+			require.NoError(t, os.WriteFile(
+				configFile, []byte(file), 0o644,
+			))
+
+			args := append([]string{"-config", configFile},
+				test.args...)
+			_, _, err := load(t, nil, args...)
+			require.Error(t, err)
+		})
+	}
+}
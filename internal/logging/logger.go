@@ -2,21 +2,127 @@ package logging
 
 import (
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"time"
 )
 
-// InitFileLogger initializes a logger that writes to a file in ~/.dlv-mcp-server
-func InitFileLogger() (*os.File, error) {
-	// Create log directory
+// dir overrides the directory returned by DefaultDir if set.
+var dir string
+
+// SetDir changes the directory the server keeps its logs and state in. It
+// must be called before the server starts.
+func SetDir(path string) {
+	dir = path
+}
+
+// DefaultDir returns the directory the server keeps its state in,
+// ~/.dlv-mcp-server unless changed with SetDir. The directory is not created.
+func DefaultDir() (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return filepath.Join(homeDir, ".dlv-mcp-server"), nil
+}
+
+// Attribute keys used in log records, so that records about the same session,
+// request or tool call can be filtered.
+const (
+	// KeyComponent names the part of the server a record comes from.
+	KeyComponent = "component"
+
+	// KeySession is the session ID given by the MCP client.
+	KeySession = "session_id"
+
+	// KeyDAPSession is the ID of the debugger's session actor.
+	KeyDAPSession = "dap_session"
+
+	// KeySeq is the sequence number of a DAP message.
+	KeySeq = "seq"
+
+	// KeyTool is the name of an MCP tool.
+	KeyTool = "tool"
+)
+
+// Format is the encoding of log records.
+type Format string
+
+const (
+	// FormatText writes records as key=value pairs.
+	FormatText Format = "text"
+
+	// FormatJSON writes records as lines of JSON.
+	FormatJSON Format = "json"
+)
+
+// Options configures the logger.
+type Options struct {
+	// Level is the minimum level of records that are written.
+	Level slog.Level
+
+	// Format is the encoding of records.
+	Format Format
+}
+
+// ParseLevel parses a level name such as "debug" or "warn".
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q, expected debug, "+
+			"info, warn or error", name)
+	}
+
+	return level, nil
+}
+
+// ParseFormat parses the name of a log format.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatText, FormatJSON:
+		return format, nil
+
+	default:
+		return "", fmt.Errorf("unknown log format %q, expected %q or "+
+			"%q", name, FormatText, FormatJSON)
+	}
+}
+
+// NewHandler returns a handler writing records to w.
+func NewHandler(w io.Writer, opts Options) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if opts.Format == FormatJSON {
+		return slog.NewJSONHandler(w, handlerOpts)
+	}
+
+	return slog.NewTextHandler(w, handlerOpts)
+}
+
+// Component returns the default logger with records attributed to the named
+// component. It must be called when logging, rather than once at startup, so
+// that it picks up the logger installed by InitFileLogger.
+func Component(name string) *slog.Logger {
+	return slog.With(KeyComponent, name)
+}
+
+// InitFileLogger installs a logger that writes to a file in the directory
+// returned by DefaultDir as the default slog logger. Messages of the standard
+// log package are written to it at info level.
+func InitFileLogger(opts Options) (*os.File, error) {
+	// Create log directory
+	logDir, err := DefaultDir()
+	if err != nil {
+		return nil, err
 	}
 
-	logDir := filepath.Join(homeDir, ".dlv-mcp-server")
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
@@ -24,11 +130,11 @@ func InitFileLogger() (*os.File, error) {
 	// Create log file with timestamp
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
 	logFile := filepath.Join(logDir, fmt.Sprintf("debug_%s.log", timestamp))
-	
+
 	// Also create a symlink to latest log
 	latestLink := filepath.Join(logDir, "latest.log")
 	os.Remove(latestLink) // Remove old symlink if exists
-	
+
 	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
@@ -37,16 +143,14 @@ func InitFileLogger() (*os.File, error) {
 	// Create symlink to latest log (ignore errors as it's not critical)
 	os.Symlink(logFile, latestLink)
 
-	// Set default logger to write to both file and stdout
-	log.SetOutput(file)
-	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
-	
-	log.Printf("========================================")
-	log.Printf("MCP Debug Server started at %s", time.Now().Format(time.RFC3339))
-	log.Printf("Log file: %s", logFile)
-	log.Printf("========================================")
-	
+	// The handler adds its own timestamps to messages of the log package.
+	log.SetFlags(0)
+	slog.SetDefault(slog.New(NewHandler(file, opts)))
+
+	slog.Info("MCP Debug Server started", "log_file", logFile,
+		"level", opts.Level.String(), "format", string(opts.Format))
+
 	fmt.Printf("Logging to: %s\n", logFile)
-	
+
 	return file, nil
-}
\ No newline at end of file
+}
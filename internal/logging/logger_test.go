@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewHandler tests that the handler honours the level and format and
+// writes the attributes used for filtering.
+func TestNewHandler(t *testing.T) {
+	level, err := ParseLevel("warn")
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	require.Error(t, err)
+
+	format, err := ParseFormat("json")
+	require.NoError(t, err)
+	_, err = ParseFormat("xml")
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, Options{
+		Level:  level,
+		Format: format,
+	}))
+
+	logger.Info("dropped")
+	logger.With(KeyComponent, "dap", KeySession, "debug1").Warn(
+		"DAP request failed", KeySeq, 7,
+	)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "WARN", record["level"])
+	require.Equal(t, "DAP request failed", record["msg"])
+	require.Equal(t, "dap", record[KeyComponent])
+	require.Equal(t, "debug1", record[KeySession])
+	require.Equal(t, 7.0, record[KeySeq])
+
+	// Text is the default format.
+	buf.Reset()
+	logger = slog.New(NewHandler(&buf, Options{Level: slog.LevelDebug}))
+	logger.Debug("Sending DAP request", KeySeq, 3)
+	require.Contains(t, buf.String(), "level=DEBUG")
+	require.Contains(t, buf.String(), "seq=3")
+}
@@ -0,0 +1,193 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFile is a log file that is moved aside once it grows beyond a size
+// limit, keeping a bounded number of old files. Each Write is kept whole in
+// one file, so writers of line-oriented formats should write one line at a
+// time. It is safe for concurrent use.
+type RotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens or creates the log file at path, appending to it.
+// Once the file is larger than maxSize bytes it is renamed to
+// <name>_<timestamp><ext> next to it and a new file is started, and only the
+// maxFiles most recent renamed files are kept. A maxSize of zero disables
+// rotation by size, and a maxFiles of zero keeps all files.
+func OpenRotatingFile(path string, maxSize int64,
+	maxFiles int) (*RotatingFile, error) {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	r := &RotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Path returns the path of the current log file.
+func (r *RotatingFile) Path() string {
+	return r.path
+}
+
+// Write appends p to the log file, rotating it first if p would take it over
+// the size limit.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Rotate moves the current log file aside and starts a new one, regardless of
+// its size.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return os.ErrClosed
+	}
+
+	return r.rotate()
+}
+
+// Close closes the log file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+
+	return err
+}
+
+// open opens the log file for appending. The caller must hold the mutex or
+// have exclusive access.
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(
+		r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+
+	return nil
+}
+
+// rotate renames the current file, opens a new one and removes old files
+// beyond the limit. The caller must hold the mutex.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	r.file = nil
+
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext)
+	rotated := rotatedName(base, ext)
+
+	if err := os.Rename(r.path, rotated); err != nil {
+		// Keep writing to the old file rather than losing records.
+		if openErr := r.open(); openErr != nil {
+			return openErr
+		}
+
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	return r.removeOld(base + "_*" + ext)
+}
+
+// removeOld removes the oldest rotated files matching the pattern beyond the
+// limit. The timestamps in their names sort chronologically.
+func (r *RotatingFile) removeOld(pattern string) error {
+	if r.maxFiles <= 0 {
+		return nil
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(files) <= r.maxFiles {
+		return nil
+	}
+
+	sort.Strings(files)
+	for _, file := range files[:len(files)-r.maxFiles] {
+		if err := os.Remove(file); err != nil {
+			return fmt.Errorf("failed to remove old log file: %w",
+				err)
+		}
+	}
+
+	return nil
+}
+
+// rotatedName returns a name for a rotated file that doesn't exist yet.
+func rotatedName(base, ext string) string {
+	for {
+		timestamp := time.Now().Format("2006-01-02_15-04-05.000000")
+		name := fmt.Sprintf("%s_%s%s", base, timestamp, ext)
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			return name
+		}
+
+		time.Sleep(time.Microsecond)
+	}
+}
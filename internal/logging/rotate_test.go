@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRotatingFile tests that the file is rotated once it would exceed its
+// size limit, without splitting writes, and that old files are removed.
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+
+	r, err := OpenRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+
+	rotated := func() []string {
+		files, err := filepath.Glob(filepath.Join(dir, "audit_*.jsonl"))
+		require.NoError(t, err)
+
+		return files
+	}
+
+	// Writes that fit stay in the same file.
+	_, err = r.Write([]byte("line 1\n"))
+	require.NoError(t, err)
+	require.Empty(t, rotated())
+
+	// A write that doesn't fit starts a new file.
+	_, err = r.Write([]byte("line 2\n"))
+	require.NoError(t, err)
+	require.Len(t, rotated(), 1)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "line 2\n", string(content))
+
+	// A write larger than the limit is kept whole.
+	_, err = r.Write([]byte("a much longer line\n"))
+	require.NoError(t, err)
+	require.Len(t, rotated(), 2)
+
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "a much longer line\n", string(content))
+
+	// Only the newest rotated files are kept.
+	require.NoError(t, r.Rotate())
+	files := rotated()
+	require.Len(t, files, 2)
+
+	content, err = os.ReadFile(files[1])
+	require.NoError(t, err)
+	require.Equal(t, "a much longer line\n", string(content))
+
+	// Reopening appends to the existing file.
+	require.NoError(t, r.Close())
+	_, err = r.Write([]byte("closed\n"))
+	require.ErrorIs(t, err, os.ErrClosed)
+
+	r, err = OpenRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	_, err = r.Write([]byte("line 3\n"))
+	require.NoError(t, err)
+
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "line 3\n", string(content))
+}
@@ -0,0 +1,390 @@
+// Package metrics keeps counters, gauges and histograms and serves them in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType is the content type of the text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// labelSeparator joins label values into series keys. It can't appear in valid
+// UTF-8 label values.
+const labelSeparator = "\xff"
+
+// metric is a named family of series that can be written in the text format.
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics in the order they were created.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds a metric to the registry.
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText writes all metrics in the Prometheus text format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+
+	return bw.Flush()
+}
+
+// Handler returns an HTTP handler serving the metrics, for Prometheus to
+// scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_ = r.WriteText(w)
+	})
+}
+
+// family holds the name, help and label names shared by the series of a
+// metric, and looks up series by their label values.
+type family[S any] struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*S
+	values map[string][]string
+	create func() *S
+}
+
+// newFamily creates an empty family.
+func newFamily[S any](name, help, kind string, labels []string,
+	create func() *S) *family[S] {
+
+	return &family[S]{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]*S),
+		values: make(map[string][]string),
+		create: create,
+	}
+}
+
+// get returns the series with the given label values, creating it if needed.
+// The caller must hold the mutex.
+func (f *family[S]) get(values []string) *S {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values",
+			f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, labelSeparator)
+	s, ok := f.series[key]
+	if !ok {
+		s = f.create()
+		f.series[key] = s
+		f.values[key] = append([]string(nil), values...)
+	}
+
+	return s
+}
+
+// each calls fn for every series, sorted by label values, writing the HELP and
+// TYPE lines first. The caller must hold the mutex.
+func (f *family[S]) each(w *bufio.Writer, fn func(values []string, s *S)) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fn(f.values[key], f.series[key])
+	}
+}
+
+// matches returns whether the series with the given label values has all the
+// given labels.
+func (f *family[S]) matches(values []string, match map[string]string) bool {
+	for i, label := range f.labels {
+		if want, ok := match[label]; ok && values[i] != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Counter is a metric that only goes up, partitioned by labels.
+type Counter struct {
+	f *family[float64]
+}
+
+// NewCounter creates a counter with the given label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{f: newFamily(name, help, "counter", labels,
+		func() *float64 { return new(float64) })}
+	r.register(c)
+
+	return c
+}
+
+// Inc adds one to the series with the given label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds a non-negative amount to the series with the given label values.
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("counter %s can't decrease", c.f.name))
+	}
+
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+
+	*c.f.get(values) += v
+}
+
+// Sum returns the total of the series whose labels have the given values. A
+// nil match sums all series.
+func (c *Counter) Sum(match map[string]string) float64 {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+
+	var sum float64
+	for key, v := range c.f.series {
+		if c.f.matches(c.f.values[key], match) {
+			sum += *v
+		}
+	}
+
+	return sum
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+
+	c.f.each(w, func(values []string, v *float64) {
+		writeSample(w, c.f.name, c.f.labels, values, "", "", *v)
+	})
+}
+
+// Gauge is a metric that can go up and down, partitioned by labels.
+type Gauge struct {
+	f *family[float64]
+}
+
+// NewGauge creates a gauge with the given label names.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{f: newFamily(name, help, "gauge", labels,
+		func() *float64 { return new(float64) })}
+	r.register(g)
+
+	return g
+}
+
+// Set sets the series with the given label values.
+func (g *Gauge) Set(v float64, values ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	*g.f.get(values) = v
+}
+
+// Add adds to the series with the given label values.
+func (g *Gauge) Add(v float64, values ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	*g.f.get(values) += v
+}
+
+// Inc adds one to the series with the given label values.
+func (g *Gauge) Inc(values ...string) {
+	g.Add(1, values...)
+}
+
+// Dec subtracts one from the series with the given label values.
+func (g *Gauge) Dec(values ...string) {
+	g.Add(-1, values...)
+}
+
+// Value returns the series with the given label values.
+func (g *Gauge) Value(values ...string) float64 {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	return *g.f.get(values)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	g.f.each(w, func(values []string, v *float64) {
+		writeSample(w, g.f.name, g.f.labels, values, "", "", *v)
+	})
+}
+
+// histogramSeries holds the observations of one histogram series.
+type histogramSeries struct {
+	// counts holds the number of observations in each bucket, not
+	// cumulative, with the last entry for the +Inf bucket.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram counts observations in buckets, partitioned by labels.
+type Histogram struct {
+	f       *family[histogramSeries]
+	buckets []float64
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds, in
+// increasing order, and label names.
+func (r *Registry) NewHistogram(name, help string, buckets []float64,
+	labels ...string) *Histogram {
+
+	h := &Histogram{buckets: buckets}
+	h.f = newFamily(name, help, "histogram", labels,
+		func() *histogramSeries {
+			return &histogramSeries{
+				counts: make([]uint64, len(buckets)+1),
+			}
+		})
+	r.register(h)
+
+	return h
+}
+
+// Observe records an observation in the series with the given label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	s := h.f.get(values)
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.sum += v
+	s.count++
+}
+
+// Count returns the number of observations in the series with the given label
+// values.
+func (h *Histogram) Count(values ...string) uint64 {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	return h.f.get(values).count
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	h.f.each(w, func(values []string, s *histogramSeries) {
+		var cumulative uint64
+		for i, count := range s.counts {
+			cumulative += count
+
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			writeSample(w, h.f.name+"_bucket", h.f.labels, values,
+				"le", formatFloat(le), float64(cumulative))
+		}
+
+		writeSample(w, h.f.name+"_sum", h.f.labels, values, "", "",
+			s.sum)
+		writeSample(w, h.f.name+"_count", h.f.labels, values, "", "",
+			float64(s.count))
+	})
+}
+
+// writeSample writes a sample line, with an extra label if extraName is set.
+func writeSample(w *bufio.Writer, name string, labels, values []string,
+	extraName, extraValue string, v float64) {
+
+	w.WriteString(name)
+
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+// formatFloat formats a sample value as the text format expects.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+
+	case math.IsInf(v, -1):
+		return "-Inf"
+
+	case math.IsNaN(v):
+		return "NaN"
+
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// escapeLabel escapes a label value.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapes help text.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
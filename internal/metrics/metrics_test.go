@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestWriteText tests that metrics are written in the Prometheus text format.
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+
+	calls := r.NewCounter("calls_total", "Calls by tool.", "tool", "outcome")
+	calls.Inc("launch", "ok")
+	calls.Inc("launch", "error")
+	calls.Add(2, "eval", "ok")
+	calls.Inc(`say "hi"`, "ok")
+
+	sessions := r.NewGauge("sessions", "Open sessions.\nNot reserved.")
+	sessions.Inc()
+	sessions.Inc()
+	sessions.Dec()
+
+	latency := r.NewHistogram("latency_seconds", "Latency.",
+		[]float64{0.1, 1}, "command")
+	latency.Observe(0.05, "next")
+	latency.Observe(0.1, "next")
+	latency.Observe(5, "next")
+
+	var buf strings.Builder
+	require.NoError(t, r.WriteText(&buf))
+	require.Equal(t, `# HELP calls_total Calls by tool.
+# TYPE calls_total counter
+calls_total{tool="eval",outcome="ok"} 2
+calls_total{tool="launch",outcome="error"} 1
+calls_total{tool="launch",outcome="ok"} 1
+calls_total{tool="say \"hi\"",outcome="ok"} 1
+# HELP sessions Open sessions.\nNot reserved.
+# TYPE sessions gauge
+sessions 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{command="next",le="0.1"} 2
+latency_seconds_bucket{command="next",le="1"} 2
+latency_seconds_bucket{command="next",le="+Inf"} 3
+latency_seconds_sum{command="next"} 5.15
+latency_seconds_count{command="next"} 3
+`, buf.String())
+
+	require.Equal(t, 5.0, calls.Sum(nil))
+	require.Equal(t, 4.0, calls.Sum(map[string]string{"outcome": "ok"}))
+	require.Equal(t, 1.0, sessions.Value())
+	require.Equal(t, uint64(3), latency.Count("next"))
+
+	require.Panics(t, func() { calls.Inc("launch") })
+	require.Panics(t, func() { calls.Add(-1, "launch", "ok") })
+
+	// The handler serves the same text.
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, buf.String(), rec.Body.String())
+	require.Contains(t, rec.Header().Get("Content-Type"), "version=0.0.4")
+}
@@ -0,0 +1,50 @@
+package metrics
+
+// Default holds the metrics of the server.
+var Default = NewRegistry()
+
+// LatencyBuckets are the upper bounds, in seconds, of the latency histograms.
+// Tool calls that run the program, such as continue_execution, can take far
+// longer than single DAP requests.
+var LatencyBuckets = []float64{
+	0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60,
+}
+
+var (
+	// ToolCalls counts MCP tool calls by tool name and outcome, which is
+	// "ok", "error" for error results or "failed" if the handler failed.
+	ToolCalls = Default.NewCounter("dlv_mcp_tool_calls_total",
+		"MCP tool calls by tool and outcome.", "tool", "outcome")
+
+	// ToolCallDuration observes how long MCP tool calls take.
+	ToolCallDuration = Default.NewHistogram(
+		"dlv_mcp_tool_call_duration_seconds",
+		"Duration of MCP tool calls.", LatencyBuckets, "tool")
+
+	// DAPRequestDuration observes how long Delve takes to answer DAP
+	// requests, by command.
+	DAPRequestDuration = Default.NewHistogram(
+		"dlv_mcp_dap_request_duration_seconds",
+		"Duration of DAP requests to Delve by command.",
+		LatencyBuckets, "command")
+
+	// DAPRequestFailures counts DAP requests that Delve rejected or that
+	// couldn't be sent, by command.
+	DAPRequestFailures = Default.NewCounter(
+		"dlv_mcp_dap_request_failures_total",
+		"DAP requests that failed by command.", "command")
+
+	// ActiveSessions is the number of open debug sessions.
+	ActiveSessions = Default.NewGauge("dlv_mcp_active_sessions",
+		"Number of open debug sessions.")
+
+	// DelveSpawnFailures counts failed attempts to start a dlv process.
+	DelveSpawnFailures = Default.NewCounter(
+		"dlv_mcp_delve_spawn_failures_total",
+		"Failed attempts to start a dlv DAP server.")
+
+	// Retries counts operations retried by debugger.RetryWithBackoff, by
+	// operation.
+	Retries = Default.NewCounter("dlv_mcp_retries_total",
+		"Retries of failed operations by operation.", "operation")
+)
@@ -0,0 +1,475 @@
+// Package vscode reads the Go debug configurations of a VS Code launch.json
+// file and maps them onto the debugger's launch and attach configurations.
+package vscode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/roasbeef/mcp-debug/debugger"
+)
+
+// LaunchFile is the conventional location of launch.json relative to the
+// workspace folder.
+const LaunchFile = ".vscode/launch.json"
+
+// variable matches a ${...} variable reference.
+var variable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Configuration is a debug configuration from launch.json. Only the fields
+// used by the Go extension's Delve adapter are decoded.
+type Configuration struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Request     string            `json:"request"`
+	Mode        string            `json:"mode"`
+	Program     string            `json:"program"`
+	Args        []string          `json:"args"`
+	Env         map[string]string `json:"env"`
+	EnvFile     StringList        `json:"envFile"`
+	BuildFlags  StringList        `json:"buildFlags"`
+	Cwd         string            `json:"cwd"`
+	StopOnEntry bool              `json:"stopOnEntry"`
+	ProcessID   json.RawMessage   `json:"processId"`
+	Host        string            `json:"host"`
+	Port        int               `json:"port"`
+}
+
+// StringList is a launch.json value that may be given either as a single
+// string or as a list of strings, like envFile and buildFlags.
+type StringList []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = nil
+		if single != "" {
+			*l = StringList{single}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or a list of strings")
+	}
+	*l = list
+
+	return nil
+}
+
+// IsGo reports whether the configuration is for the Go extension's debugger.
+func (c *Configuration) IsGo() bool {
+	return c.Type == "go"
+}
+
+// IsAttach reports whether the configuration attaches to a running process
+// rather than launching a program.
+func (c *Configuration) IsAttach() bool {
+	return c.Request == "attach"
+}
+
+// launchFile is the top-level structure of launch.json.
+type launchFile struct {
+	Configurations []Configuration `json:"configurations"`
+}
+
+// LoadConfigurations reads the debug configurations of a launch.json file,
+// which may contain comments and trailing commas.
+func LoadConfigurations(path string) ([]Configuration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file launchFile
+	if err := json.Unmarshal(standardize(data), &file); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	return file.Configurations, nil
+}
+
+// FindConfiguration returns the configuration with the given name.
+func FindConfiguration(configs []Configuration,
+	name string) (*Configuration, error) {
+
+	for i := range configs {
+		if configs[i].Name == name {
+			return &configs[i], nil
+		}
+	}
+
+	names := make([]string, len(configs))
+	for i, config := range configs {
+		names[i] = strconv.Quote(config.Name)
+	}
+
+	return nil, fmt.Errorf("no configuration named %q, available: %s",
+		name, strings.Join(names, ", "))
+}
+
+// Resolver expands the ${...} variables supported in launch.json.
+type Resolver struct {
+	// WorkspaceFolder is the folder containing the .vscode directory.
+	WorkspaceFolder string
+
+	// Getenv looks up environment variables. If nil, os.Getenv is used.
+	Getenv func(string) string
+
+	// CheckFile, if set, is called with the resolved path of each env
+	// file before it is read, and an error stops the file from being
+	// read.
+	CheckFile func(string) error
+}
+
+// Expand replaces the variables in s. Variables that depend on the editor,
+// such as ${file} or ${command:...}, can't be resolved and are reported as
+// errors.
+func (r *Resolver) Expand(s string) (string, error) {
+	getenv := r.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	var unresolved []string
+	expanded := variable.ReplaceAllStringFunc(s, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+
+		switch {
+		case name == "workspaceFolder" || name == "workspaceRoot":
+			return r.WorkspaceFolder
+
+		case name == "workspaceFolderBasename":
+			return filepath.Base(r.WorkspaceFolder)
+
+		case name == "userHome":
+			home, err := os.UserHomeDir()
+			if err != nil {
+				unresolved = append(unresolved, ref)
+			}
+			return home
+
+		case name == "pathSeparator":
+			return string(filepath.Separator)
+
+		case strings.HasPrefix(name, "env:"):
+			return getenv(strings.TrimPrefix(name, "env:"))
+		}
+
+		unresolved = append(unresolved, ref)
+		return ref
+	})
+
+	if len(unresolved) > 0 {
+		return "", fmt.Errorf("unsupported variable %s in %q",
+			strings.Join(unresolved, ", "), s)
+	}
+
+	return expanded, nil
+}
+
+// path expands the variables in a path and makes it absolute relative to the
+// workspace folder.
+func (r *Resolver) path(p string) (string, error) {
+	p, err := r.Expand(p)
+	if err != nil || p == "" {
+		return p, err
+	}
+
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(r.WorkspaceFolder, p)
+	}
+
+	return p, nil
+}
+
+// expandAll expands the variables in each of the strings.
+func (r *Resolver) expandAll(list []string) ([]string, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	expanded := make([]string, len(list))
+	for i, s := range list {
+		var err error
+		if expanded[i], err = r.Expand(s); err != nil {
+			return nil, err
+		}
+	}
+
+	return expanded, nil
+}
+
+// LaunchConfig maps a launch configuration onto a debugger.LaunchConfig.
+// Environment variables from env take precedence over those from envFile.
+func (r *Resolver) LaunchConfig(c *Configuration) (debugger.LaunchConfig,
+	error) {
+
+	var config debugger.LaunchConfig
+	if c.IsAttach() {
+		return config, fmt.Errorf("configuration %q attaches to a "+
+			"process, it can't be launched", c.Name)
+	}
+
+	switch c.Mode {
+	case "", "auto":
+	case "debug", "test", "exec":
+		config.Mode = c.Mode
+	default:
+		return config, fmt.Errorf("configuration %q uses unsupported "+
+			"mode %q", c.Name, c.Mode)
+	}
+
+	var err error
+	config.Name = c.Name
+	config.StopOnEntry = c.StopOnEntry
+
+	if config.Program, err = r.path(c.Program); err != nil {
+		return config, err
+	}
+	if config.Program == "" {
+		return config, fmt.Errorf("configuration %q has no program",
+			c.Name)
+	}
+	if config.WorkingDir, err = r.path(c.Cwd); err != nil {
+		return config, err
+	}
+	if config.Args, err = r.expandAll(c.Args); err != nil {
+		return config, err
+	}
+
+	// The Go extension accepts build flags as a single string, which
+	// we split on whitespace.
+	var buildFlags []string
+	for _, flags := range c.BuildFlags {
+		buildFlags = append(buildFlags, strings.Fields(flags)...)
+	}
+	if config.BuildFlags, err = r.expandAll(buildFlags); err != nil {
+		return config, err
+	}
+
+	env := make(map[string]string)
+	for _, file := range c.EnvFile {
+		path, err := r.path(file)
+		if err != nil {
+			return config, err
+		}
+		if r.CheckFile != nil {
+			if err := r.CheckFile(path); err != nil {
+				return config, fmt.Errorf("env file %s: %w",
+					path, err)
+			}
+		}
+
+		vars, err := readEnvFile(path)
+		if err != nil {
+			return config, err
+		}
+		for k, v := range vars {
+			env[k] = v
+		}
+	}
+	for k, v := range c.Env {
+		if env[k], err = r.Expand(v); err != nil {
+			return config, err
+		}
+	}
+
+	for k, v := range env {
+		config.Env = append(config.Env, k+"="+v)
+	}
+	sort.Strings(config.Env)
+
+	return config, nil
+}
+
+// AttachConfig maps an attach configuration onto a debugger.AttachConfig. If
+// processID is non-zero it overrides the configured process, which is needed
+// for configurations that ask the editor to pick a process.
+func (r *Resolver) AttachConfig(c *Configuration,
+	processID int) (debugger.AttachConfig, error) {
+
+	config := debugger.AttachConfig{
+		Name: c.Name,
+		Mode: c.Mode,
+		Host: c.Host,
+		Port: c.Port,
+	}
+	if !c.IsAttach() {
+		return config, fmt.Errorf("configuration %q launches a "+
+			"program, it can't be attached", c.Name)
+	}
+
+	switch config.Mode {
+	case "":
+		config.Mode = "local"
+
+	case "local", "remote":
+
+	default:
+		return config, fmt.Errorf("configuration %q uses unsupported "+
+			"mode %q", c.Name, c.Mode)
+	}
+
+	if config.Mode == "remote" {
+		return config, nil
+	}
+
+	config.ProcessID = processID
+	if config.ProcessID != 0 {
+		return config, nil
+	}
+
+	// The process ID is either a number or a string such as
+	// "${command:pickProcess}" that only the editor can resolve.
+	var pid int
+	if err := json.Unmarshal(c.ProcessID, &pid); err == nil && pid > 0 {
+		config.ProcessID = pid
+		return config, nil
+	}
+
+	var ref string
+	if err := json.Unmarshal(c.ProcessID, &ref); err == nil {
+		expanded, err := r.Expand(ref)
+		if err == nil {
+			pid, err = strconv.Atoi(expanded)
+		}
+		if err == nil && pid > 0 {
+			config.ProcessID = pid
+			return config, nil
+		}
+	}
+
+	return config, fmt.Errorf("configuration %q has no usable process "+
+		"ID, please provide one", c.Name)
+}
+
+// readEnvFile reads an env file of KEY=VALUE lines. Blank lines and lines
+// starting with # are ignored, and values may be quoted.
+func readEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read env file: %w", err)
+	}
+
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path,
+				lineNum)
+		}
+
+		value = strings.TrimSpace(value)
+		quoted := len(value) >= 2 && value[len(value)-1] == value[0]
+		switch {
+		case quoted && value[0] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				unquoted = value[1 : len(value)-1]
+			}
+			value = unquoted
+
+		case quoted && value[0] == '\'':
+			value = value[1 : len(value)-1]
+		}
+		env[key] = value
+	}
+
+	return env, scanner.Err()
+}
+
+// standardize turns the JSON with comments and trailing commas that VS Code
+// accepts into standard JSON. Comments are replaced by spaces so that offsets
+// in parse errors stay meaningful.
+func standardize(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+
+	// pendingComma is the position in out of a comma that must be
+	// dropped if the next significant character closes an object or
+	// array.
+	pendingComma := -1
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		switch {
+		case c == '"':
+			pendingComma = -1
+			start := i
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			end := i + 1
+			if end > len(data) {
+				end = len(data)
+			}
+			out = append(out, data[start:end]...)
+
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for ; i < len(data) && data[i] != '\n'; i++ {
+				out = append(out, ' ')
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			out = append(out, ' ', ' ')
+			for i += 2; i < len(data); i++ {
+				if data[i] == '*' && i+1 < len(data) &&
+					data[i+1] == '/' {
+
+					out = append(out, ' ', ' ')
+					i++
+					break
+				}
+				if data[i] == '\n' {
+					out = append(out, '\n')
+				} else {
+					out = append(out, ' ')
+				}
+			}
+
+		case c == ',':
+			pendingComma = len(out)
+			out = append(out, c)
+
+		case c == '}' || c == ']':
+			if pendingComma >= 0 {
+				out[pendingComma] = ' '
+				pendingComma = -1
+			}
+			out = append(out, c)
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			out = append(out, c)
+
+		default:
+			pendingComma = -1
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,201 @@
+package vscode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/stretchr/testify/require"
+)
+
+// testLaunchJSON is a launch.json in the style VS Code writes, with comments
+// and trailing commas.
+const testLaunchJSON = `{
+    // Use IntelliSense to learn about possible attributes.
+    "version": "0.2.0",
+    "configurations": [
+        {
+            "name": "Launch server",
+            "type": "go",
+            "request": "launch",
+            "mode": "auto",
+            "program": "${workspaceFolder}/cmd/server",
+            "args": ["--config", "${workspaceFolder}/dev.yaml",],
+            "env": {"LOG_LEVEL": "debug", "HOME_DIR": "${env:TEST_HOME}"},
+            "envFile": "${workspaceFolder}/.env",
+            "buildFlags": "-tags=integration -race",
+            "cwd": "testdata", /* relative to the workspace */
+        },
+        {
+            "name": "Test package",
+            "type": "go",
+            "request": "launch",
+            "mode": "test",
+            "program": "${workspaceFolderBasename}/pkg // not a comment",
+        },
+        {
+            "name": "Attach to process",
+            "type": "go",
+            "request": "attach",
+            "mode": "local",
+            "processId": "${command:pickProcess}",
+        },
+        {
+            "name": "Launch Chrome",
+            "type": "chrome",
+            "request": "launch",
+        },
+    ],
+}`
+
+// writeWorkspace creates a workspace folder with the test launch.json and an
+// env file.
+func writeWorkspace(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".vscode"), 0755))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, LaunchFile), []byte(testLaunchJSON), 0644,
+	))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(
+		"# Local settings\nexport DB_URL=\"postgres://localhost\"\n"+
+			"LOG_LEVEL=info\nNAME='my service'\n"), 0644,
+	))
+
+	return dir
+}
+
+// TestLoadConfigurations tests that launch.json files with comments and
+// trailing commas are parsed.
+func TestLoadConfigurations(t *testing.T) {
+	dir := writeWorkspace(t)
+
+	configs, err := LoadConfigurations(filepath.Join(dir, LaunchFile))
+	require.NoError(t, err)
+	require.Len(t, configs, 4)
+
+	require.True(t, configs[0].IsGo())
+	require.False(t, configs[0].IsAttach())
+	require.Equal(t, StringList{"-tags=integration -race"},
+		configs[0].BuildFlags)
+	require.Equal(t, "${workspaceFolderBasename}/pkg // not a comment",
+		configs[1].Program)
+	require.True(t, configs[2].IsAttach())
+	require.False(t, configs[3].IsGo())
+
+	config, err := FindConfiguration(configs, "Test package")
+	require.NoError(t, err)
+	require.Equal(t, "test", config.Mode)
+
+	_, err = FindConfiguration(configs, "missing")
+	require.ErrorContains(t, err, `"Launch server"`)
+}
+
+// TestLaunchConfig tests the mapping of a launch configuration, including
+// variables, env files and build flags.
+func TestLaunchConfig(t *testing.T) {
+	dir := writeWorkspace(t)
+
+	configs, err := LoadConfigurations(filepath.Join(dir, LaunchFile))
+	require.NoError(t, err)
+
+	resolver := &Resolver{
+		WorkspaceFolder: dir,
+		Getenv: func(name string) string {
+			return map[string]string{"TEST_HOME": "/home/test"}[name]
+		},
+	}
+
+	config, err := resolver.LaunchConfig(&configs[0])
+	require.NoError(t, err)
+	require.Equal(t, debugger.LaunchConfig{
+		Name:    "Launch server",
+		Program: filepath.Join(dir, "cmd/server"),
+		Args: []string{
+			"--config", filepath.Join(dir, "dev.yaml"),
+		},
+		Env: []string{
+			"DB_URL=postgres://localhost",
+			"HOME_DIR=/home/test",
+			"LOG_LEVEL=debug",
+			"NAME=my service",
+		},
+		WorkingDir: filepath.Join(dir, "testdata"),
+		BuildFlags: []string{"-tags=integration", "-race"},
+	}, config)
+
+	config, err = resolver.LaunchConfig(&configs[1])
+	require.NoError(t, err)
+	require.Equal(t, "test", config.Mode)
+	require.Equal(t, filepath.Join(
+		dir, filepath.Base(dir), "pkg // not a comment",
+	), config.Program)
+
+	_, err = resolver.LaunchConfig(&configs[2])
+	require.ErrorContains(t, err, "attaches to a process")
+}
+
+// TestAttachConfig tests the mapping of attach configurations.
+func TestAttachConfig(t *testing.T) {
+	resolver := &Resolver{WorkspaceFolder: "/src"}
+
+	picked := &Configuration{
+		Name:      "Attach",
+		Request:   "attach",
+		ProcessID: []byte(`"${command:pickProcess}"`),
+	}
+	_, err := resolver.AttachConfig(picked, 0)
+	require.ErrorContains(t, err, "no usable process ID")
+
+	config, err := resolver.AttachConfig(picked, 1234)
+	require.NoError(t, err)
+	require.Equal(t, debugger.AttachConfig{
+		Name: "Attach", Mode: "local", ProcessID: 1234,
+	}, config)
+
+	fixed := &Configuration{
+		Name:      "Attach",
+		Request:   "attach",
+		ProcessID: []byte(`4321`),
+	}
+	config, err = resolver.AttachConfig(fixed, 0)
+	require.NoError(t, err)
+	require.Equal(t, 4321, config.ProcessID)
+
+	remote := &Configuration{
+		Name:    "Remote",
+		Request: "attach",
+		Mode:    "remote",
+		Host:    "127.0.0.1",
+		Port:    2345,
+	}
+	config, err = resolver.AttachConfig(remote, 0)
+	require.NoError(t, err)
+	require.Equal(t, debugger.AttachConfig{
+		Name: "Remote", Mode: "remote", Host: "127.0.0.1", Port: 2345,
+	}, config)
+}
+
+// TestExpand tests variable substitution.
+func TestExpand(t *testing.T) {
+	resolver := &Resolver{
+		WorkspaceFolder: "/src/project",
+		Getenv: func(string) string {
+			return "value"
+		},
+	}
+
+	expanded, err := resolver.Expand(
+		"${workspaceFolder}/${workspaceFolderBasename}/${env:X}",
+	)
+	require.NoError(t, err)
+	require.Equal(t, "/src/project/project/value", expanded)
+
+	_, err = resolver.Expand("${file}")
+	require.ErrorContains(t, err, "unsupported variable ${file}")
+
+	_, err = resolver.Expand("${input:name}")
+	require.Error(t, err)
+}
@@ -0,0 +1,169 @@
+// Package workspace persists debug workspaces: named sets of launch settings,
+// breakpoints and watch expressions that can be restored in a new session.
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+)
+
+// ErrNotFound is returned when loading a workspace that doesn't exist.
+var ErrNotFound = errors.New("workspace not found")
+
+// validName matches the workspace names we accept. Names become file names,
+// so path separators and other special characters are rejected.
+var validName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Workspace is the saved state of a debug session.
+type Workspace struct {
+	// Name identifies the workspace.
+	Name string `json:"name"`
+
+	// Launch is the configuration used to launch the program.
+	Launch debugger.LaunchConfig `json:"launch"`
+
+	// Breakpoints are the source breakpoints, including conditional
+	// breakpoints and logpoints.
+	Breakpoints []debugger.BreakpointLocation `json:"breakpoints,omitempty"`
+
+	// FunctionBreakpoints are the function breakpoints.
+	FunctionBreakpoints []debugger.FunctionBreakpoint `json:"function_breakpoints,omitempty"`
+
+	// ExceptionFilters are the exception breakpoint filters.
+	ExceptionFilters []string `json:"exception_filters,omitempty"`
+
+	// Watches are expressions the user wants evaluated whenever the
+	// program stops.
+	Watches []string `json:"watches,omitempty"`
+
+	// SavedAt is when the workspace was last saved.
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// Store saves workspaces as JSON files in a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store that keeps workspaces in the given directory. The
+// directory is created when the first workspace is saved.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStore returns the store in ~/.dlv-mcp-server/workspaces.
+func DefaultStore() (*Store, error) {
+	dir, err := logging.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStore(filepath.Join(dir, "workspaces")), nil
+}
+
+// ValidateName returns an error if the name can't be used for a workspace.
+func ValidateName(name string) error {
+	if !validName.MatchString(name) || name == "." || name == ".." {
+		return fmt.Errorf("invalid workspace name %q: only letters, "+
+			"digits, '.', '_' and '-' are allowed", name)
+	}
+
+	return nil
+}
+
+// path returns the file the named workspace is stored in.
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// Save writes the workspace, replacing any existing workspace with the same
+// name. The file is replaced atomically so a crash can't leave a partially
+// written workspace behind.
+func (s *Store) Save(ws *Workspace) error {
+	if err := ValidateName(ws.Name); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("unable to create workspace directory: %w",
+			err)
+	}
+
+	data, err := json.MarshalIndent(ws, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ws.Name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path(ws.Name))
+}
+
+// Load reads the named workspace.
+func (s *Store) Load(name string) (*Workspace, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ws Workspace
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("unable to parse workspace %s: %w", name,
+			err)
+	}
+	ws.Name = name
+
+	return &ws, nil
+}
+
+// List returns the names of all saved workspaces in alphabetical order.
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || ValidateName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
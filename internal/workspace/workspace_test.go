@@ -0,0 +1,76 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStoreRoundTrip tests that a saved workspace loads back unchanged and
+// shows up in the list.
+func TestStoreRoundTrip(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "workspaces"))
+
+	names, err := store.List()
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	ws := &Workspace{
+		Name: "server",
+		Launch: debugger.LaunchConfig{
+			Program: "./cmd/server",
+			Args:    []string{"-v"},
+			Env:     []string{"DEBUG=1"},
+		},
+		Breakpoints: []debugger.BreakpointLocation{
+			{File: "/src/main.go", Line: 10, Condition: "x > 1"},
+			{File: "/src/main.go", Line: 20, LogMessage: "x={x}"},
+		},
+		FunctionBreakpoints: []debugger.FunctionBreakpoint{
+			{Name: "main.handle", HitCondition: "3"},
+		},
+		ExceptionFilters: []string{"panic"},
+		Watches:          []string{"len(queue)"},
+		SavedAt:          time.Now().UTC().Truncate(time.Second),
+	}
+	require.NoError(t, store.Save(ws))
+	require.NoError(t, store.Save(&Workspace{Name: "another"}))
+
+	loaded, err := store.Load("server")
+	require.NoError(t, err)
+	require.Equal(t, ws, loaded)
+
+	names, err = store.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"another", "server"}, names)
+
+	// Saving again replaces the workspace without leaving files behind.
+	ws.Watches = nil
+	require.NoError(t, store.Save(ws))
+	loaded, err = store.Load("server")
+	require.NoError(t, err)
+	require.Empty(t, loaded.Watches)
+
+	files, err := os.ReadDir(store.dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
+
+// TestStoreErrors tests that invalid and missing workspaces are rejected.
+func TestStoreErrors(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	for _, name := range []string{"", "..", "a/b", "../escape", "a b"} {
+		require.Error(t, store.Save(&Workspace{Name: name}), name)
+
+		_, err := store.Load(name)
+		require.Error(t, err, name)
+	}
+
+	_, err := store.Load("missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
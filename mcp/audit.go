@@ -0,0 +1,201 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+)
+
+const (
+	// AuditOK is the outcome of tool calls that succeeded.
+	AuditOK = "ok"
+
+	// AuditError is the outcome of tool calls that returned an error
+	// result, including calls rejected by the policy or session limits.
+	AuditError = "error"
+
+	// AuditFailed is the outcome of tool calls whose handler failed, which
+	// the client sees as a protocol error.
+	AuditFailed = "failed"
+
+	// redacted replaces the values of redacted arguments.
+	redacted = "[REDACTED]"
+
+	// maxAuditErrorLen bounds the length of error messages in the audit
+	// log, as error results may contain program output.
+	maxAuditErrorLen = 1024
+)
+
+// AuditRecord is a line of the audit log, describing one tool call.
+type AuditRecord struct {
+	Time       time.Time      `json:"time"`
+	Client     string         `json:"client,omitempty"`
+	ClientName string         `json:"client_name,omitempty"`
+	SessionID  string         `json:"session_id,omitempty"`
+	Tool       string         `json:"tool"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	Outcome    string         `json:"outcome"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS float64        `json:"duration_ms"`
+}
+
+// auditLog writes audit records as JSON lines.
+type auditLog struct {
+	mu  sync.Mutex
+	out io.Writer
+
+	// redact lists patterns of argument names whose values are left out.
+	redact []string
+}
+
+// WithAuditLog records every tool call as a line of JSON written to out. The
+// values of arguments whose names match one of the redact patterns, e.g. 'env'
+// or '*token*', are replaced, including in nested objects.
+func WithAuditLog(out io.Writer, redact []string) ServerOption {
+	return func(mds *MCPDebugServer) {
+		mds.audit = &auditLog{out: out, redact: redact}
+	}
+}
+
+// auditToolCalls is a tool handler middleware that writes a record of every
+// tool call to the audit log. It runs before the other middleware, so that
+// calls they reject are recorded too.
+func (mds *MCPDebugServer) auditToolCalls(
+	next server.ToolHandlerFunc) server.ToolHandlerFunc {
+
+	return func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		if mds.audit == nil {
+			return next(ctx, request)
+		}
+
+		start := time.Now()
+		result, err := next(ctx, request)
+
+		args := request.GetArguments()
+		record := AuditRecord{
+			Time:       start.UTC(),
+			Client:     clientID(ctx),
+			ClientName: clientName(ctx),
+			Tool:       request.Params.Name,
+			Arguments:  mds.audit.redactArgs(args),
+			Outcome:    toolOutcome(result, err),
+			DurationMS: float64(time.Since(start).Microseconds()) /
+				1000,
+		}
+		record.SessionID, _ = args["session_id"].(string)
+
+		switch record.Outcome {
+		case AuditFailed:
+			record.Error = err.Error()
+
+		case AuditError:
+			record.Error = resultText(result)
+		}
+		if len(record.Error) > maxAuditErrorLen {
+			record.Error = record.Error[:maxAuditErrorLen] + "..."
+		}
+
+		mds.audit.write(record)
+
+		return result, err
+	}
+}
+
+// write appends a record to the audit log. Failures are logged rather than
+// failing the tool call.
+func (a *auditLog) write(record AuditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		logging.Component("mcp").Error("Failed to encode audit record",
+			logging.KeyTool, record.Tool, "err", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.out.Write(append(line, '\n')); err != nil {
+		logging.Component("mcp").Error("Failed to write audit record",
+			logging.KeyTool, record.Tool, "err", err)
+	}
+}
+
+// redactArgs returns a copy of the arguments with redacted values replaced.
+func (a *auditLog) redactArgs(args map[string]any) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+
+	return a.redactValue(args).(map[string]any)
+}
+
+// redactValue returns a copy of a JSON value with the values of redacted keys
+// replaced.
+func (a *auditLog) redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for key, v := range value {
+			if a.isRedacted(key) {
+				copied[key] = redacted
+				continue
+			}
+			copied[key] = a.redactValue(v)
+		}
+
+		return copied
+
+	case []any:
+		copied := make([]any, len(value))
+		for i, v := range value {
+			copied[i] = a.redactValue(v)
+		}
+
+		return copied
+
+	default:
+		return value
+	}
+}
+
+// isRedacted returns whether the values of a key are left out of the log.
+func (a *auditLog) isRedacted(key string) bool {
+	for _, pattern := range a.redact {
+		if match, _ := path.Match(pattern, key); match {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientName returns the name the MCP client gave when it initialized, if the
+// transport keeps it.
+func clientName(ctx context.Context) string {
+	session := server.ClientSessionFromContext(ctx)
+	if info, ok := session.(server.SessionWithClientInfo); ok {
+		return info.GetClientInfo().Name
+	}
+
+	return ""
+}
+
+// resultText returns the text of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readAudit decodes the records written to an audit log.
+func readAudit(t *testing.T, buf *bytes.Buffer) []AuditRecord {
+	t.Helper()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var record AuditRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+
+	return records
+}
+
+// TestAuditLog tests that every tool call is recorded with its outcome,
+// including calls rejected by the policy, and that redacted arguments are left
+// out.
+func TestAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	mds, _ := newTestServer(t,
+		WithAuditLog(&buf, []string{"env", "*token*"}),
+		WithPolicy(Policy{DisableAttach: true}),
+	)
+
+	requireSession(t, mds, "audited")
+	requireTool(t, mds, "launch_program", map[string]any{
+		"session_id": "audited",
+		"program":    "./cmd/app",
+		"mode":       "debug",
+		"env":        []string{"API_TOKEN=secret"},
+		"args":       []string{"-v"},
+	})
+	requireDenied(t, mds, "attach_to_process", map[string]any{
+		"session_id": "audited",
+		"process_id": 1,
+	}, "attaching")
+	requireDenied(t, mds, "list_watches", map[string]any{
+		"session_id": "missing",
+	}, "missing")
+
+	records := readAudit(t, &buf)
+	require.Len(t, records, 5)
+
+	for _, record := range records {
+		require.False(t, record.Time.IsZero())
+		require.GreaterOrEqual(t, record.DurationMS, 0.0)
+		require.NotContains(t, record.Error, "secret")
+	}
+
+	create := records[0]
+	require.Equal(t, "create_debug_session", create.Tool)
+	require.Equal(t, "audited", create.SessionID)
+	require.Equal(t, AuditOK, create.Outcome)
+	require.Empty(t, create.Error)
+
+	require.Equal(t, "initialize_session", records[1].Tool)
+
+	launch := records[2]
+	require.Equal(t, "launch_program", launch.Tool)
+	require.Equal(t, AuditOK, launch.Outcome)
+	require.Equal(t, redacted, launch.Arguments["env"])
+	require.Equal(t, []any{"-v"}, launch.Arguments["args"])
+	require.Equal(t, "./cmd/app", launch.Arguments["program"])
+
+	attach := records[3]
+	require.Equal(t, AuditError, attach.Outcome)
+	require.Contains(t, attach.Error, "Not allowed")
+
+	require.Equal(t, AuditError, records[4].Outcome)
+	require.Equal(t, "missing", records[4].SessionID)
+}
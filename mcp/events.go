@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+)
+
+const (
+	// loggingNotification is the method of MCP logging notifications,
+	// which carry the events of debug sessions.
+	loggingNotification = "notifications/message"
+
+	// eventLogger is the logger name used for session event
+	// notifications.
+	eventLogger = "debug-session"
+
+	// defaultEventLevel is the lowest level of the events sent to clients
+	// that haven't set a logging level. mcp-go assumes error for them,
+	// which would hide every event.
+	defaultEventLevel = mcp.LoggingLevelInfo
+)
+
+// logLevelHooks returns server hooks that record the logging level each client
+// sets, which its event notifications are filtered by.
+func (mds *MCPDebugServer) logLevelHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterSetLevel(func(ctx context.Context, _ any,
+		request *mcp.SetLevelRequest, _ *mcp.EmptyResult) {
+
+		client := server.ClientSessionFromContext(ctx)
+		if client != nil {
+			mds.logLevels.Store(
+				client.SessionID(), request.Params.Level,
+			)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(_ context.Context,
+		client server.ClientSession) {
+
+		mds.logLevels.Delete(client.SessionID())
+	})
+
+	return hooks
+}
+
+// wantsEvent reports whether a client's logging level lets through events of
+// the given level.
+func (mds *MCPDebugServer) wantsEvent(client string,
+	level mcp.LoggingLevel) bool {
+
+	minLevel := defaultEventLevel
+	if set, ok := mds.logLevels.Load(client); ok {
+		minLevel = set.(mcp.LoggingLevel)
+	}
+
+	return level.ShouldSendTo(minLevel)
+}
+
+// forwardEvents starts sending the output, exit and breakpoint events of a
+// session to the client that owns it. Stops are sent by notifyStop once the
+// session's watches have been evaluated.
+func (mds *MCPDebugServer) forwardEvents(sessionID string, sess *debugSession) {
+	sess.stopEventForwarding = sess.events.Subscribe(
+		func(event dap.EventMessage) {
+			mds.forwardEvent(sessionID, sess, event)
+		},
+	)
+}
+
+// forwardEvent sends a single session event to the owning client. It is called
+// from the session's read loop, so it must not block or issue requests.
+func (mds *MCPDebugServer) forwardEvent(sessionID string, sess *debugSession,
+	event dap.EventMessage) {
+
+	var (
+		level = mcp.LoggingLevelInfo
+		data  map[string]any
+	)
+	switch e := event.(type) {
+	case *dap.OutputEvent:
+		if e.Body.Category == "stderr" {
+			level = mcp.LoggingLevelWarning
+		}
+		data = map[string]any{
+			"event":    "output",
+			"category": getStringOrDefault(e.Body.Category, "console"),
+			"output":   e.Body.Output,
+		}
+
+	case *dap.ExitedEvent:
+		level = mcp.LoggingLevelNotice
+		data = map[string]any{
+			"event":     "exited",
+			"exit_code": e.Body.ExitCode,
+		}
+
+	case *dap.TerminatedEvent:
+		level = mcp.LoggingLevelNotice
+		data = map[string]any{
+			"event": "terminated",
+		}
+
+	case *dap.BreakpointEvent:
+		bp := e.Body.Breakpoint
+		data = map[string]any{
+			"event":         "breakpoint",
+			"reason":        e.Body.Reason,
+			"breakpoint_id": bp.Id,
+			"verified":      bp.Verified,
+		}
+		if bp.Line != 0 {
+			data["line"] = bp.Line
+		}
+		if bp.Source != nil && bp.Source.Path != "" {
+			data["file"] = bp.Source.Path
+		}
+		if bp.Message != "" {
+			data["message"] = bp.Message
+		}
+
+	default:
+		return
+	}
+
+	mds.notifyEvent(sess.client, sessionID, level, data)
+}
+
+// notifyStop sends a stop of a session, along with the values of its watch
+// expressions, to the client that owns it.
+func (mds *MCPDebugServer) notifyStop(client, sessionID string,
+	stop *debugger.WatchStop) {
+
+	data := map[string]any{
+		"event":  "stopped",
+		"stop":   stop.Stop,
+		"reason": stop.Reason,
+	}
+	if stop.Location != nil {
+		data["thread_id"] = stop.Location.ThreadID
+		data["location"] = stop.Location
+	}
+	if len(stop.Values) > 0 {
+		data["watches"] = stop.Values
+	}
+
+	mds.notifyEvent(client, sessionID, mcp.LoggingLevelNotice, data)
+}
+
+// notifyEvent sends a session event to a client as a logging notification
+// tagged with the session ID, unless the client's logging level filters it
+// out.
+func (mds *MCPDebugServer) notifyEvent(client, sessionID string,
+	level mcp.LoggingLevel, data map[string]any) {
+
+	if client == "" || !mds.wantsEvent(client, level) {
+		return
+	}
+
+	data["session_id"] = sessionID
+	err := mds.server.SendNotificationToSpecificClient(
+		client, loggingNotification, map[string]any{
+			"level":  level,
+			"logger": eventLogger,
+			"data":   data,
+		},
+	)
+	if err != nil {
+		logging.Component("mcp").Warn("Failed to send event", "event", data["event"],
+			logging.KeySession, sessionID, "err", err)
+	}
+}
@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// nextEvent waits for a session event notification of the given kind and
+// returns its data, skipping any other notifications.
+func (c *fakeClient) nextEvent(t *testing.T, event string) map[string]any {
+	t.Helper()
+
+	for {
+		notification := c.nextNotification(t, loggingNotification)
+		params := notification.Params.AdditionalFields
+		require.Equal(t, eventLogger, params["logger"])
+
+		data, ok := params["data"].(map[string]any)
+		require.True(t, ok)
+		if data["event"] == event {
+			return data
+		}
+	}
+}
+
+// TestEventNotifications tests that session events are sent to the client
+// that created the session as logging notifications.
+func TestEventNotifications(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	client := &fakeClient{
+		id:            "agent",
+		notifications: make(chan mcp.JSONRPCNotification, 20),
+	}
+	require.NoError(t, mds.server.RegisterSession(
+		context.Background(), client,
+	))
+	ctx := mds.server.WithContext(context.Background(), client)
+
+	result, err := callTool(ctx, mds, "create_debug_session",
+		map[string]any{"session_id": "events"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	sess, ok := mds.sessions.get("events")
+	require.True(t, ok)
+
+	// Stops are reported even without any watches.
+	sess.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{Reason: "step", ThreadId: 3},
+	})
+	data := client.nextEvent(t, "stopped")
+	require.Equal(t, "events", data["session_id"])
+	require.Equal(t, "step", data["reason"])
+	require.Equal(t, 3, data["thread_id"])
+	require.NotContains(t, data, "watches")
+
+	sess.events.Publish(&dap.OutputEvent{
+		Body: dap.OutputEventBody{Category: "stderr", Output: "oops\n"},
+	})
+	notification := client.nextNotification(t, loggingNotification)
+	params := notification.Params.AdditionalFields
+	require.Equal(t, mcp.LoggingLevelWarning, params["level"])
+	require.Equal(t, map[string]any{
+		"event":      "output",
+		"category":   "stderr",
+		"output":     "oops\n",
+		"session_id": "events",
+	}, params["data"])
+
+	sess.events.Publish(&dap.BreakpointEvent{
+		Body: dap.BreakpointEventBody{
+			Reason: "changed",
+			Breakpoint: dap.Breakpoint{
+				Id:       2,
+				Verified: true,
+				Line:     10,
+				Source:   &dap.Source{Path: "/src/main.go"},
+			},
+		},
+	})
+	data = client.nextEvent(t, "breakpoint")
+	require.Equal(t, "changed", data["reason"])
+	require.Equal(t, 2, data["breakpoint_id"])
+	require.Equal(t, "/src/main.go", data["file"])
+
+	sess.events.Publish(&dap.ExitedEvent{
+		Body: dap.ExitedEventBody{ExitCode: 1},
+	})
+	data = client.nextEvent(t, "exited")
+	require.Equal(t, 1, data["exit_code"])
+
+	// Nothing is sent once the session is closed.
+	requireTool(t, mds, "close_debug_session", map[string]any{
+		"session_id": "events",
+	})
+	for len(client.notifications) > 0 {
+		<-client.notifications
+	}
+	sess.events.Publish(&dap.OutputEvent{
+		Body: dap.OutputEventBody{Output: "late\n"},
+	})
+	require.Empty(t, client.notifications)
+}
+
+// TestEventNotificationLevel tests that events below the logging level a
+// client set aren't sent to it.
+func TestEventNotificationLevel(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	client := &fakeClient{
+		id:            "agent",
+		notifications: make(chan mcp.JSONRPCNotification, 20),
+	}
+	require.NoError(t, mds.server.RegisterSession(
+		context.Background(), client,
+	))
+	ctx := mds.server.WithContext(context.Background(), client)
+
+	result, err := callTool(ctx, mds, "create_debug_session",
+		map[string]any{"session_id": "events"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	sess, ok := mds.sessions.get("events")
+	require.True(t, ok)
+
+	var empty mcp.EmptyResult
+	sendRequest(t, ctx, mds, "logging/setLevel",
+		map[string]any{"level": "warning"}, &empty)
+	for len(client.notifications) > 0 {
+		<-client.notifications
+	}
+
+	// Output on stdout and stops are below the warning level, while
+	// output on stderr isn't.
+	sess.events.Publish(&dap.OutputEvent{
+		Body: dap.OutputEventBody{Category: "stdout", Output: "chatty\n"},
+	})
+	sess.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{Reason: "step", ThreadId: 3},
+	})
+	sess.events.Publish(&dap.OutputEvent{
+		Body: dap.OutputEventBody{Category: "stderr", Output: "oops\n"},
+	})
+
+	data := client.nextEvent(t, "output")
+	require.Equal(t, "oops\n", data["output"])
+
+	// Stops are sent after their watches are evaluated, so give a stop
+	// that wasn't filtered time to arrive.
+	time.Sleep(100 * time.Millisecond)
+	for len(client.notifications) > 0 {
+		notification := <-client.notifications
+		require.NotEqual(t, loggingNotification, notification.Method)
+	}
+}
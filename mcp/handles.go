@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/roasbeef/mcp-debug/debugger"
+)
+
+// errStaleFrame is returned when a client passes a frame ID that was handed
+// out at an earlier stop. The debugger reassigns frame IDs every time the
+// program stops, so such an ID no longer names the frame it was given for.
+var errStaleFrame = errors.New("frame ID is from an earlier stop")
+
+// frameHelp is appended to tool descriptions that take a frame.
+const frameHelp = "Address the frame by thread_id and depth (0 is the " +
+	"innermost frame), which stay valid across stops, or by a frame_id " +
+	"from the current stop. Without either, the top frame of the " +
+	"thread that last stopped is used"
+
+// frameAddress names a stack frame either by a DAP frame ID, which is only
+// valid until the program resumes, or by its thread and depth, which are
+// resolved at the current stop.
+type frameAddress struct {
+	FrameID  int
+	ThreadID int
+	Depth    int
+}
+
+// markStop records a stop of the program, after which the frame IDs handed
+// out before are stale. Stops that were already recorded are ignored, as the
+// same event may be tracked more than once.
+func (s *debugSession) markStop(stop *dap.StoppedEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stop == s.lastStop {
+		return
+	}
+	s.lastStop = stop
+	s.stops++
+}
+
+// noteFrames records that frame IDs were handed to a client at the current
+// stop.
+func (s *debugSession) noteFrames(ids ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.frameStops == nil {
+		s.frameStops = make(map[int]int)
+	}
+	for _, id := range ids {
+		s.frameStops[id] = s.stops
+	}
+}
+
+// staleFrame reports whether a frame ID was handed out at an earlier stop and
+// hasn't been handed out again since. IDs the server never handed out are
+// left for the debugger to judge.
+func (s *debugSession) staleFrame(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stop, ok := s.frameStops[id]
+	return ok && stop != s.stops
+}
+
+// stackFrames reads the stack of a thread and records its frame IDs as
+// handed out at the current stop.
+func (s *debugSession) stackFrames(ctx context.Context,
+	threadID int) ([]debugger.StackFrame, error) {
+
+	frames, err := debugger.GetStackFrames(ctx, s.ref, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(frames))
+	for i, frame := range frames {
+		ids[i] = frame.ID
+	}
+	s.noteFrames(ids...)
+
+	return frames, nil
+}
+
+// resolveFrame returns the DAP frame ID for a frame address at the current
+// stop. Frame IDs from earlier stops are rejected.
+func resolveFrame(ctx context.Context, sess *debugSession,
+	addr frameAddress) (int, error) {
+
+	if addr.FrameID != 0 {
+		if sess.staleFrame(addr.FrameID) {
+			return 0, fmt.Errorf("%w: frame %d is no longer valid "+
+				"since the program resumed; address the frame by "+
+				"thread_id and depth instead, or call "+
+				"get_stack_frames for fresh IDs", errStaleFrame,
+				addr.FrameID)
+		}
+
+		return addr.FrameID, nil
+	}
+
+	threadID := addr.ThreadID
+	if threadID == 0 {
+		threadID = sess.stoppedThread()
+	}
+	if threadID == 0 {
+		return 0, errors.New("no thread is stopped; pass thread_id " +
+			"and depth or frame_id")
+	}
+	if addr.Depth < 0 {
+		return 0, fmt.Errorf("invalid depth %d", addr.Depth)
+	}
+
+	frames, err := sess.stackFrames(ctx, threadID)
+	if err != nil {
+		return 0, err
+	}
+	if addr.Depth >= len(frames) {
+		return 0, fmt.Errorf("thread %d has %d frames, so there is "+
+			"no frame at depth %d", threadID, len(frames),
+			addr.Depth)
+	}
+
+	return frames[addr.Depth].ID, nil
+}
+
+// readPath reads the fields or elements of the variable at a path, such as
+// req.Header["X"], in a frame. The path is evaluated at the current stop, so
+// unlike a variables reference it can be used again after the program
+// resumes. A variable without children is returned itself. The variables are
+// returned as a single scope named after the path.
+func readPath(ctx context.Context, sess *debugSession, frameID int,
+	path string) ([]debugger.VariableScope,
+	map[string][]debugger.Variable, error) {
+
+	result, err := debugger.EvaluateExpressionResult(
+		ctx, sess.ref, path, frameID,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scopes := []debugger.VariableScope{{Name: path}}
+	if result.VariablesReference == 0 {
+		return scopes, map[string][]debugger.Variable{
+			path: {{
+				Name:  path,
+				Value: result.Result,
+				Type:  result.Type,
+			}},
+		}, nil
+	}
+
+	variables, err := debugger.GetVariableList(
+		ctx, sess.ref, result.VariablesReference,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return scopes, map[string][]debugger.Variable{path: variables}, nil
+}
+
+// frameOptions are the tool options that address a frame.
+func frameOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithNumber("thread_id",
+			mcp.Description("Thread (goroutine) ID of the frame")),
+		mcp.WithNumber("depth",
+			mcp.Description("Depth of the frame in the thread's "+
+				"stack, 0 being the innermost frame")),
+		mcp.WithNumber("frame_id",
+			mcp.Description("Frame ID from the current stop, e.g. "+
+				"from get_stack_frames. IDs from earlier stops "+
+				"are rejected")),
+	}
+}
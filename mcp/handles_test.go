@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFrameAddressing tests that frames can be addressed by thread and depth
+// at any stop, that frame IDs from earlier stops are rejected and that
+// variables can be expanded by path.
+func TestFrameAddressing(t *testing.T) {
+	mds, _ := newTestServer(t)
+	requireSession(t, mds, "app")
+	stopSession(t, mds, "app")
+
+	sess, ok := mds.sessions.get("app")
+	require.True(t, ok)
+
+	// Frame IDs from the current stop are accepted.
+	requireTool(t, mds, "get_stack_frames", map[string]any{
+		"session_id": "app",
+		"thread_id":  1,
+	})
+	requireTool(t, mds, "evaluate_expression", map[string]any{
+		"session_id": "app",
+		"expression": "count",
+		"frame_id":   1000,
+	})
+
+	// Once the program stopped again they are stale.
+	sess.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{Reason: "step", ThreadId: 1},
+	})
+	var errResult ErrorResult
+	_, failed := callStructured(t, mds, "get_variables", map[string]any{
+		"session_id": "app",
+		"frame_id":   1000,
+	}, &errResult)
+	require.True(t, failed)
+	require.Contains(t, errResult.Error, "frame 1000 is no longer valid")
+	require.Contains(t, errResult.Error, "thread_id and depth")
+
+	// Addressing the frame by thread and depth resolves a fresh ID, after
+	// which the numeric ID is accepted again.
+	var vars VariablesResult
+	text, failed := callStructured(t, mds, "get_variables", map[string]any{
+		"session_id": "app",
+		"thread_id":  1,
+		"depth":      0,
+	}, &vars)
+	require.False(t, failed, text)
+	require.Equal(t, 1000, vars.FrameID)
+	require.Equal(t, "3", vars.Scopes["Locals"][0].Value)
+
+	requireTool(t, mds, "evaluate_expression", map[string]any{
+		"session_id": "app",
+		"expression": "count",
+		"frame_id":   1000,
+	})
+
+	// Without a frame the top frame of the stopped thread is used.
+	var eval EvaluationResult
+	text, failed = callStructured(t, mds, "evaluate_expression",
+		map[string]any{
+			"session_id": "app",
+			"expression": "count",
+		}, &eval)
+	require.False(t, failed, text)
+	require.Equal(t, 1000, eval.FrameID)
+	require.Equal(t, "len(count)", eval.Result)
+
+	errResult = ErrorResult{}
+	_, failed = callStructured(t, mds, "evaluate_expression",
+		map[string]any{
+			"session_id": "app",
+			"expression": "count",
+			"thread_id":  1,
+			"depth":      3,
+		}, &errResult)
+	require.True(t, failed)
+	require.Contains(t, errResult.Error, "no frame at depth 3")
+
+	// Variables are expanded by path, and paths to values without
+	// children return the value itself.
+	var fields VariablesResult
+	text, failed = callStructured(t, mds, "get_variables", map[string]any{
+		"session_id": "app",
+		"path":       "req",
+	}, &fields)
+	require.False(t, failed, text)
+	require.Equal(t, "req", fields.Path)
+	require.Equal(t, "count", fields.Scopes["req"][0].Name)
+	require.Contains(t, text, "req:\n  count int = 3")
+
+	var value VariablesResult
+	text, failed = callStructured(t, mds, "get_variables", map[string]any{
+		"session_id": "app",
+		"path":       `req.Header["X"]`,
+	}, &value)
+	require.False(t, failed, text)
+	require.Equal(t, `len(req.Header["X"])`,
+		value.Scopes[`req.Header["X"]`][0].Value)
+}
@@ -0,0 +1,270 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/vscode"
+)
+
+// ListLaunchConfigurationsArgs represents the arguments for listing the
+// configurations of a launch.json file.
+type ListLaunchConfigurationsArgs struct {
+	WorkspaceFolder string `json:"workspace_folder"`
+	LaunchFile      string `json:"launch_file,omitempty"`
+}
+
+// LaunchConfigurationArgs represents the arguments for starting a session
+// from a launch.json configuration.
+type LaunchConfigurationArgs struct {
+	SessionID       string `json:"session_id"`
+	WorkspaceFolder string `json:"workspace_folder"`
+	LaunchFile      string `json:"launch_file,omitempty"`
+	Name            string `json:"name"`
+	ProcessID       int    `json:"process_id,omitempty"`
+}
+
+// loadLaunchConfigurations reads the configurations of the launch.json file
+// of a workspace folder. If launchFile is empty, the conventional
+// .vscode/launch.json is used, otherwise it's relative to the folder.
+func loadLaunchConfigurations(workspaceFolder,
+	launchFile string) (string, []vscode.Configuration, error) {
+
+	if workspaceFolder == "" {
+		return "", nil, fmt.Errorf("a workspace folder is required")
+	}
+
+	folder, err := filepath.Abs(workspaceFolder)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path := getStringOrDefault(launchFile, vscode.LaunchFile)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(folder, path)
+	}
+
+	configs, err := vscode.LoadConfigurations(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return folder, configs, nil
+}
+
+// registerListLaunchConfigurationsTool registers the list launch
+// configurations tool.
+func (mds *MCPDebugServer) registerListLaunchConfigurationsTool() {
+	tool := mcp.NewTool("list_launch_configurations",
+		mcp.WithDescription("List the Go debug configurations in a VS Code launch.json file. Start a session from one with launch_configuration"),
+		mcp.WithOutputSchema[LaunchConfigurationsResult](),
+		mcp.WithString("workspace_folder", mcp.Required(),
+			mcp.Description("Workspace folder containing the .vscode directory, used for ${workspaceFolder}")),
+		mcp.WithString("launch_file",
+			mcp.Description("Path to launch.json, relative to the workspace folder (default: .vscode/launch.json)")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args ListLaunchConfigurationsArgs) (*mcp.CallToolResult, error) {
+
+		_, configs, err := loadLaunchConfigurations(
+			args.WorkspaceFolder, args.LaunchFile,
+		)
+		if err != nil {
+			return errorResult(
+				"Failed to read launch configurations: "+
+					"%v", err), nil
+		}
+
+		var (
+			text   strings.Builder
+			result = LaunchConfigurationsResult{
+				Configurations: []LaunchConfigurationSummary{},
+			}
+		)
+		for _, config := range configs {
+			if !config.IsGo() {
+				continue
+			}
+
+			summary := LaunchConfigurationSummary{
+				Name:        config.Name,
+				Request:     config.Request,
+				Description: describeLaunchConfiguration(&config),
+			}
+			result.Configurations = append(
+				result.Configurations, summary,
+			)
+
+			fmt.Fprintf(&text, "- %s: %s\n", summary.Name,
+				summary.Description)
+		}
+
+		if text.Len() == 0 {
+			return mcp.NewToolResultStructured(
+				result, "No Go debug configurations found",
+			), nil
+		}
+
+		return mcp.NewToolResultStructured(result,
+			"Launch configurations:\n"+
+				strings.TrimRight(text.String(), "\n"),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerLaunchConfigurationTool registers the launch configuration tool.
+func (mds *MCPDebugServer) registerLaunchConfigurationTool() {
+	tool := mcp.NewTool("launch_configuration",
+		mcp.WithDescription("Launch a program or attach to a process in an initialized session using a Go debug configuration from a VS Code launch.json file. Maps mode, program, args, env, envFile, buildFlags, cwd and stopOnEntry, and resolves ${workspaceFolder}, ${workspaceFolderBasename}, ${userHome} and ${env:NAME} variables"),
+		mcp.WithOutputSchema[LaunchResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithString("workspace_folder", mcp.Required(),
+			mcp.Description("Workspace folder containing the .vscode directory, used for ${workspaceFolder}")),
+		mcp.WithString("name", mcp.Required(),
+			mcp.Description("Name of the configuration to use")),
+		mcp.WithString("launch_file",
+			mcp.Description("Path to launch.json, relative to the workspace folder (default: .vscode/launch.json)")),
+		mcp.WithNumber("process_id",
+			mcp.Description("Process ID for local attach configurations, required when the configuration lets the editor pick the process")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args LaunchConfigurationArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		folder, configs, err := loadLaunchConfigurations(
+			args.WorkspaceFolder, args.LaunchFile,
+		)
+		var config *vscode.Configuration
+		if err == nil {
+			config, err = vscode.FindConfiguration(configs, args.Name)
+		}
+		if err == nil && !config.IsGo() {
+			err = fmt.Errorf("configuration %q is of type %q, only "+
+				"go configurations are supported", config.Name,
+				config.Type)
+		}
+		if err != nil {
+			return errorResult(
+				"Failed to read launch configuration: "+
+					"%v", err), nil
+		}
+
+		resolver := &vscode.Resolver{
+			WorkspaceFolder: folder,
+			CheckFile:       mds.policy.checkPath,
+		}
+
+		var (
+			summary string
+			result  = LaunchResult{
+				SessionID:     args.SessionID,
+				Configuration: config.Name,
+			}
+		)
+		if config.IsAttach() {
+			attach, err := resolver.AttachConfig(
+				config, args.ProcessID,
+			)
+			if err == nil {
+				_, err = mds.attachSession(ctx, sess, attach)
+			}
+			if err != nil {
+				return errorResult(
+					"Failed to attach with %s: %v",
+					config.Name, err), nil
+			}
+
+			result.Attach = &attach
+			summary = fmt.Sprintf("Attached with %s (%s mode)",
+				config.Name, attach.Mode)
+			if attach.Mode == "local" {
+				summary += fmt.Sprintf(" to process %d",
+					attach.ProcessID)
+			}
+		} else {
+			launch, err := resolver.LaunchConfig(config)
+			if err == nil {
+				_, err = mds.launchSession(
+					ctx, args.SessionID, sess, launch,
+				)
+			}
+			if err != nil {
+				return errorResult(
+					"Failed to launch %s: %v",
+					config.Name, err), nil
+			}
+
+			shown := redactEnv(*sess.launchConfig())
+			result.Launch = &shown
+			summary = fmt.Sprintf("Launched %s (%s mode): %s",
+				config.Name, debugger.DetectLaunchMode(launch),
+				launch.Program)
+			if len(launch.Args) > 0 {
+				summary += fmt.Sprintf(" with args %q",
+					launch.Args)
+			}
+		}
+		result.Binary = sess.binaryPath()
+
+		return mcp.NewToolResultStructured(result, summary), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// redactEnv returns a copy of a launch configuration whose environment
+// variables only show their names. Values may have been read from env files
+// the client can't read otherwise, so they are never returned.
+func redactEnv(config debugger.LaunchConfig) debugger.LaunchConfig {
+	if len(config.Env) == 0 {
+		return config
+	}
+
+	env := make([]string, len(config.Env))
+	for i, kv := range config.Env {
+		name, _, _ := strings.Cut(kv, "=")
+		env[i] = name + "=" + redacted
+	}
+	config.Env = env
+
+	return config
+}
+
+// describeLaunchConfiguration summarises a launch.json configuration in one
+// line.
+func describeLaunchConfiguration(c *vscode.Configuration) string {
+	if c.IsAttach() {
+		if c.Mode == "remote" {
+			return fmt.Sprintf("attach (remote) to %s:%d", c.Host,
+				c.Port)
+		}
+
+		process := strings.Trim(string(c.ProcessID), `"`)
+		if process == "" {
+			process = "(process_id required)"
+		}
+
+		return fmt.Sprintf("attach (local) to process %s", process)
+	}
+
+	mode := getStringOrDefault(c.Mode, "auto")
+	desc := fmt.Sprintf("launch (%s) %s", mode, c.Program)
+	if len(c.Args) > 0 {
+		desc += fmt.Sprintf(" with args %q", c.Args)
+	}
+
+	return desc
+}
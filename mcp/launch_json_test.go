@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roasbeef/mcp-debug/internal/vscode"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLaunchConfiguration tests that sessions are launched and attached from
+// the configurations of a launch.json file.
+func TestLaunchConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".vscode"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, vscode.LaunchFile),
+		[]byte(`{
+    "configurations": [
+        {
+            "name": "Server",
+            "type": "go",
+            "request": "launch",
+            "mode": "exec",
+            "program": "${workspaceFolder}/bin/server",
+            "args": ["-v"], // verbose logging
+            "env": {"PORT": "8080"},
+        },
+        {
+            "name": "Attach",
+            "type": "go",
+            "request": "attach",
+            "processId": "${command:pickProcess}",
+        },
+        {"name": "Node", "type": "node", "request": "launch"},
+    ],
+}`), 0644))
+
+	mds, _ := newTestServer(t)
+
+	text := requireTool(t, mds, "list_launch_configurations",
+		map[string]any{"workspace_folder": dir})
+	require.Contains(t, text, `- Server: launch (exec) `+
+		`${workspaceFolder}/bin/server with args ["-v"]`)
+	require.Contains(t, text, "- Attach: attach (local) to process "+
+		"${command:pickProcess}")
+	require.NotContains(t, text, "Node")
+
+	requireSession(t, mds, "launched")
+	text = requireTool(t, mds, "launch_configuration", map[string]any{
+		"session_id":       "launched",
+		"workspace_folder": dir,
+		"name":             "Server",
+	})
+	require.Contains(t, text, "Launched Server")
+
+	sess, ok := mds.sessions.get("launched")
+	require.True(t, ok)
+	launch := sess.launchConfig()
+	require.NotNil(t, launch)
+	require.Equal(t, filepath.Join(dir, "bin/server"), launch.Program)
+	require.Equal(t, "exec", launch.Mode)
+	require.Equal(t, []string{"-v"}, launch.Args)
+	require.Equal(t, []string{"PORT=8080"}, launch.Env)
+
+	// Only the names of the environment variables are returned.
+	var launched LaunchResult
+	requireSession(t, mds, "redacted")
+	text, failed := callStructured(t, mds, "launch_configuration",
+		map[string]any{
+			"session_id":       "redacted",
+			"workspace_folder": dir,
+			"name":             "Server",
+		}, &launched)
+	require.False(t, failed, text)
+	require.Equal(t, []string{"PORT=" + redacted}, launched.Launch.Env)
+
+	// Attach configurations that let the editor pick the process need
+	// an explicit process ID.
+	requireSession(t, mds, "attached")
+	result, err := callTool(context.Background(), mds,
+		"launch_configuration", map[string]any{
+			"session_id":       "attached",
+			"workspace_folder": dir,
+			"name":             "Attach",
+		})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	text = requireTool(t, mds, "launch_configuration", map[string]any{
+		"session_id":       "attached",
+		"workspace_folder": dir,
+		"name":             "Attach",
+		"process_id":       os.Getpid(),
+	})
+	require.Contains(t, text, "Attached with Attach (local mode)")
+
+	requireSession(t, mds, "unused")
+	for _, name := range []string{"Node", "Missing"} {
+		result, err := callTool(context.Background(), mds,
+			"launch_configuration", map[string]any{
+				"session_id":       "unused",
+				"workspace_folder": dir,
+				"name":             name,
+			})
+		require.NoError(t, err)
+		require.True(t, result.IsError, name)
+	}
+}
+
+// TestLaunchConfigurationEnvFile tests that env files outside the allowed
+// directories aren't read.
+func TestLaunchConfigurationEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	secrets := filepath.Join(t.TempDir(), "secrets.env")
+	require.NoError(t, os.WriteFile(secrets, []byte("TOKEN=hunter2\n"),
+		0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".vscode"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, vscode.LaunchFile),
+		[]byte(`{"configurations": [
+    {
+        "name": "Outside", "type": "go", "request": "launch",
+        "mode": "exec", "program": "${workspaceFolder}/server",
+        "envFile": "`+secrets+`"
+    },
+    {
+        "name": "Inside", "type": "go", "request": "launch",
+        "mode": "exec", "program": "${workspaceFolder}/server",
+        "envFile": "${workspaceFolder}/.env"
+    }
+]}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"),
+		[]byte("PORT=8080\n"), 0644))
+
+	mds, _ := newTestServer(t, WithPolicy(Policy{
+		AllowedDirs: []string{dir},
+	}))
+
+	var denied ErrorResult
+	requireSession(t, mds, "outside")
+	_, failed := callStructured(t, mds, "launch_configuration",
+		map[string]any{
+			"session_id":       "outside",
+			"workspace_folder": dir,
+			"name":             "Outside",
+		}, &denied)
+	require.True(t, failed)
+	require.Contains(t, denied.Error, "env file "+secrets)
+	require.NotContains(t, denied.Error, "hunter2")
+
+	var launched LaunchResult
+	requireSession(t, mds, "inside")
+	text, failed := callStructured(t, mds, "launch_configuration",
+		map[string]any{
+			"session_id":       "inside",
+			"workspace_folder": dir,
+			"name":             "Inside",
+		}, &launched)
+	require.False(t, failed, text)
+	require.Equal(t, []string{"PORT=" + redacted}, launched.Launch.Env)
+}
@@ -2,14 +2,24 @@ package mcp
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-dap"
 	"github.com/lightningnetwork/lnd/actor"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+	"github.com/roasbeef/mcp-debug/internal/workspace"
 )
 
 // CreateSessionArgs represents the arguments for creating a debug session.
@@ -17,6 +27,12 @@ type CreateSessionArgs struct {
 	SessionID string `json:"session_id"`
 }
 
+// CloseSessionArgs represents the arguments for closing a debug session.
+type CloseSessionArgs struct {
+	SessionID string `json:"session_id"`
+	Detach    bool   `json:"detach,omitempty"`
+}
+
 // InitializeSessionArgs represents the arguments for initializing a session.
 type InitializeSessionArgs struct {
 	SessionID string `json:"session_id"`
@@ -27,6 +43,7 @@ type InitializeSessionArgs struct {
 type LaunchProgramArgs struct {
 	SessionID   string   `json:"session_id"`
 	Program     string   `json:"program"`
+	Mode        string   `json:"mode,omitempty"`
 	Name        string   `json:"name,omitempty"`
 	Args        []string `json:"args,omitempty"`
 	Env         []string `json:"env,omitempty"`
@@ -35,11 +52,35 @@ type LaunchProgramArgs struct {
 	BuildFlags  []string `json:"build_flags,omitempty"`
 }
 
+// SourceBreakpointArgs describes a source breakpoint with optional condition,
+// hit condition or log message.
+type SourceBreakpointArgs struct {
+	Line         int    `json:"line"`
+	Condition    string `json:"condition,omitempty"`
+	HitCondition string `json:"hit_condition,omitempty"`
+	LogMessage   string `json:"log_message,omitempty"`
+}
+
 // SetBreakpointsArgs represents the arguments for setting breakpoints.
 type SetBreakpointsArgs struct {
-	SessionID string `json:"session_id"`
-	File      string `json:"file"`
-	Lines     []int  `json:"lines"`
+	SessionID   string                 `json:"session_id"`
+	File        string                 `json:"file"`
+	Lines       []int                  `json:"lines,omitempty"`
+	Breakpoints []SourceBreakpointArgs `json:"breakpoints,omitempty"`
+}
+
+// SetFunctionBreakpointsArgs represents the arguments for setting function
+// breakpoints.
+type SetFunctionBreakpointsArgs struct {
+	SessionID   string                        `json:"session_id"`
+	Breakpoints []debugger.FunctionBreakpoint `json:"breakpoints"`
+}
+
+// SetExceptionBreakpointsArgs represents the arguments for setting exception
+// breakpoints.
+type SetExceptionBreakpointsArgs struct {
+	SessionID string   `json:"session_id"`
+	Filters   []string `json:"filters"`
 }
 
 // ExecutionControlArgs represents the arguments for execution control commands.
@@ -51,25 +92,35 @@ type ExecutionControlArgs struct {
 // GetThreadsArgs represents the arguments for getting threads.
 type GetThreadsArgs struct {
 	SessionID string `json:"session_id"`
+	MaxChars  int    `json:"max_chars,omitempty"`
 }
 
 // GetStackFramesArgs represents the arguments for getting stack frames.
 type GetStackFramesArgs struct {
 	SessionID string `json:"session_id"`
 	ThreadID  int    `json:"thread_id"`
+	MaxChars  int    `json:"max_chars,omitempty"`
 }
 
-// GetVariablesArgs represents the arguments for getting variables.
+// GetVariablesArgs represents the arguments for getting variables. The frame
+// is given either by FrameID or by ThreadID and Depth.
 type GetVariablesArgs struct {
 	SessionID string `json:"session_id"`
-	FrameID   int    `json:"frame_id"`
+	FrameID   int    `json:"frame_id,omitempty"`
+	ThreadID  int    `json:"thread_id,omitempty"`
+	Depth     int    `json:"depth,omitempty"`
+	Path      string `json:"path,omitempty"`
+	MaxChars  int    `json:"max_chars,omitempty"`
 }
 
 // EvaluateExpressionArgs represents the arguments for evaluating expressions.
+// The frame is given either by FrameID or by ThreadID and Depth.
 type EvaluateExpressionArgs struct {
 	SessionID  string `json:"session_id"`
 	Expression string `json:"expression"`
-	FrameID    int    `json:"frame_id"`
+	FrameID    int    `json:"frame_id,omitempty"`
+	ThreadID   int    `json:"thread_id,omitempty"`
+	Depth      int    `json:"depth,omitempty"`
 }
 
 // AttachToProcessArgs represents the arguments for attaching to a process.
@@ -82,49 +133,199 @@ type AttachToProcessArgs struct {
 	Port      int    `json:"port,omitempty"`    // for remote debugging
 }
 
+// SearchSymbolsArgs represents the arguments for searching the symbols of the
+// debugged binary.
+type SearchSymbolsArgs struct {
+	SessionID string `json:"session_id,omitempty"`
+	Pattern   string `json:"pattern"`
+	Kind      string `json:"kind,omitempty"`   // "function", "type" or "variable"
+	Binary    string `json:"binary,omitempty"` // overrides the session's binary
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// RunUntilArgs represents the arguments for running until a condition holds.
+type RunUntilArgs struct {
+	SessionID string `json:"session_id"`
+	ThreadID  int    `json:"thread_id"`
+	Condition string `json:"condition"`
+	Function  string `json:"function,omitempty"`
+	MaxSteps  int    `json:"max_steps,omitempty"`
+}
+
+// TraceExecutionArgs represents the arguments for recording an execution
+// trace.
+type TraceExecutionArgs struct {
+	SessionID   string   `json:"session_id"`
+	ThreadID    int      `json:"thread_id"`
+	MaxSteps    int      `json:"max_steps,omitempty"`
+	StepIn      bool     `json:"step_in,omitempty"`
+	Function    string   `json:"function,omitempty"`
+	Expressions []string `json:"expressions,omitempty"`
+}
+
+// ProfileFunctionsArgs represents the arguments for profiling function calls.
+type ProfileFunctionsArgs struct {
+	SessionID       string   `json:"session_id"`
+	Functions       []string `json:"functions"`
+	DurationSeconds float64  `json:"duration_seconds,omitempty"`
+	Timing          bool     `json:"timing,omitempty"`
+}
+
 // MCPDebugServer wraps our debugging functionality as an MCP server.
 type MCPDebugServer struct {
 	server   *server.MCPServer
 	debugger actor.ActorRef[*debugger.DebuggerCmd, *debugger.DebuggerResp]
-	sessions map[string]actor.ActorRef[*debugger.DAPRequest, *debugger.DAPResponse]
+	sessions *sessionRegistry
 	actorSys *actor.ActorSystem
+
+	// limits bounds the number and lifetime of sessions.
+	limits SessionLimits
+
+	// workspaces is where workspaces are saved. If nil, the default store
+	// is used.
+	workspaces *workspace.Store
+
+	// buildFlags are added before the caller's build flags whenever a
+	// program is built for debugging.
+	buildFlags []string
+
+	// buildDir is a directory only this server uses, that programs are
+	// built into. It's removed when the server is stopped. buildDirErr
+	// is why it couldn't be created, if it couldn't.
+	buildDir    string
+	buildDirErr error
+
+	// policy restricts what clients may do.
+	policy Policy
+
+	// audit records every tool call if set.
+	audit *auditLog
+
+	// logLevels holds the logging level each client set with
+	// logging/setLevel, by client session ID.
+	logLevels sync.Map
+
+	quit     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// ServerOption configures optional behaviour of an MCPDebugServer.
+type ServerOption func(*MCPDebugServer)
+
+// WithSessionLimits overrides the default session limits.
+func WithSessionLimits(limits SessionLimits) ServerOption {
+	return func(mds *MCPDebugServer) {
+		mds.limits = limits
+	}
+}
+
+// WithBuildFlags sets build flags, e.g. build tags, that are used for every
+// program built for debugging in addition to the flags given at launch.
+func WithBuildFlags(flags []string) ServerOption {
+	return func(mds *MCPDebugServer) {
+		mds.buildFlags = flags
+	}
 }
 
 // NewMCPDebugServer creates a new MCP server for debugging operations.
 func NewMCPDebugServer(actorSys *actor.ActorSystem,
-	debuggerRef actor.ActorRef[*debugger.DebuggerCmd, *debugger.DebuggerResp]) *MCPDebugServer {
-
-	mcpServer := server.NewMCPServer(
-		"Go Debug Adapter Protocol Server",
-		"1.0.0",
-	)
+	debuggerRef actor.ActorRef[*debugger.DebuggerCmd, *debugger.DebuggerResp],
+	opts ...ServerOption) *MCPDebugServer {
 
 	mds := &MCPDebugServer{
-		server:   mcpServer,
 		debugger: debuggerRef,
-		sessions: make(map[string]actor.ActorRef[*debugger.DAPRequest, *debugger.DAPResponse]),
 		actorSys: actorSys,
+		limits:   DefaultSessionLimits(),
+		quit:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(mds)
 	}
 
-	// Register all debugging tools
+	// Other servers and users share the temporary directory, so programs
+	// are built into a private directory under a name no one else can
+	// claim first.
+	mds.buildDir, mds.buildDirErr = os.MkdirTemp("", "dlv-mcp-")
+	if mds.buildDirErr != nil {
+		logging.Component("mcp").Warn("Programs can't be built until "+
+			"a build directory can be created", "err", mds.buildDirErr)
+	}
+
+	mds.sessions = newSessionRegistry(mds.limits)
+	mds.server = server.NewMCPServer(
+		"Go Debug Adapter Protocol Server",
+		"1.0.0",
+		server.WithToolHandlerMiddleware(mds.auditToolCalls),
+		server.WithToolHandlerMiddleware(mds.logToolCalls),
+		server.WithToolHandlerMiddleware(mds.measureToolCalls),
+		server.WithToolHandlerMiddleware(mds.trackActivity),
+		server.WithToolHandlerMiddleware(mds.enforcePolicy),
+		server.WithToolHandlerMiddleware(mds.enforceSessionState),
+		// mcp-go answers resources/subscribe with "method not
+		// found", so subscriptions aren't advertised. The client
+		// that owns a session is sent updates for its resources
+		// regardless, which clients that don't want them ignore.
+		server.WithResourceCapabilities(false, true),
+		server.WithPromptCapabilities(false),
+		server.WithLogging(),
+		server.WithHooks(mds.logLevelHooks()),
+	)
+
+	// Register all debugging tools, resources and prompts
 	mds.registerTools()
+	mds.registerResources()
+	mds.registerPrompts()
+
+	if mds.limits.IdleTimeout > 0 {
+		mds.wg.Add(1)
+		go mds.reapIdleSessions()
+	}
 
 	return mds
 }
 
+// Stop stops the server's background tasks and removes the programs it
+// built. Sessions are left open.
+func (mds *MCPDebugServer) Stop() {
+	mds.stopOnce.Do(func() {
+		close(mds.quit)
+	})
+	mds.wg.Wait()
+
+	if mds.buildDir != "" {
+		if err := os.RemoveAll(mds.buildDir); err != nil {
+			logging.Component("mcp").Warn("Failed to remove build "+
+				"directory", "dir", mds.buildDir, "err", err)
+		}
+	}
+}
+
 // registerTools registers all available debugging tools with the MCP server.
 func (mds *MCPDebugServer) registerTools() {
 	// Session management tools
 	mds.registerCreateSessionTool()
 	mds.registerInitializeSessionTool()
+	mds.registerCloseSessionTool()
+
+	// Workspace tools
+	mds.registerSaveWorkspaceTool()
+	mds.registerLoadWorkspaceTool()
+	mds.registerListWorkspacesTool()
 
 	// Program control tools
 	mds.registerLaunchProgramTool()
 	mds.registerAttachToProcessTool()
+	mds.registerListLaunchConfigurationsTool()
+	mds.registerLaunchConfigurationTool()
 	mds.registerConfigurationDoneTool()
+	mds.registerDebugRunTool()
 
 	// Breakpoint tools
 	mds.registerSetBreakpointsTool()
+	mds.registerSetFunctionBreakpointsTool()
+	mds.registerSetExceptionBreakpointsTool()
+	mds.registerGetBreakpointsTool()
 
 	// Execution control tools
 	mds.registerContinueTool()
@@ -132,18 +333,29 @@ func (mds *MCPDebugServer) registerTools() {
 	mds.registerStepInTool()
 	mds.registerStepOutTool()
 	mds.registerPauseTool()
+	mds.registerRunUntilTool()
+	mds.registerTraceExecutionTool()
+	mds.registerProfileFunctionsTool()
 
 	// Inspection tools
 	mds.registerGetThreadsTool()
 	mds.registerGetStackFramesTool()
 	mds.registerGetVariablesTool()
 	mds.registerEvaluateExpressionTool()
+	mds.registerSearchSymbolsTool()
+
+	// Watch tools
+	mds.registerAddWatchTool()
+	mds.registerRemoveWatchTool()
+	mds.registerListWatchesTool()
+	mds.registerGetWatchHistoryTool()
 }
 
 // registerCreateSessionTool registers the create debugging session tool.
 func (mds *MCPDebugServer) registerCreateSessionTool() {
 	tool := mcp.NewTool("create_debug_session",
 		mcp.WithDescription("Create a new debugging session"),
+		mcp.WithOutputSchema[SessionResult](),
 		mcp.WithString("session_id",
 			mcp.Required(),
 			mcp.Description("Unique identifier for the session")),
@@ -154,59 +366,320 @@ func (mds *MCPDebugServer) registerCreateSessionTool() {
 
 		sessionID := args.SessionID
 
-		// Check if session already exists
-		if _, exists := mds.sessions[sessionID]; exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s already exists", sessionID)),
-				},
-				IsError: true,
-			}, nil
+		_, err := mds.createSession(ctx, sessionID)
+		switch {
+		case errors.Is(err, errSessionExists):
+			return errorResult(
+				"Session %s already exists", sessionID), nil
+
+		case errors.Is(err, errTooManySessions),
+			errors.Is(err, errTooManyClientSessions):
+
+			return errorResult(
+				"Cannot create session %s: %v; close "+
+					"an unused session first",
+				sessionID, err), nil
+
+		case err != nil:
+			return errorResult(
+				"Failed to create session: %v", err), nil
 		}
 
-		// Create debug session
-		cmd := &debugger.CreateSessionCmd{}
-		future := mds.debugger.Ask(ctx, &debugger.DebuggerCmd{Cmd: cmd})
-		result, err := future.Await(ctx).Unpack()
-		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to create session: %v", err)),
-				},
-				IsError: true,
-			}, nil
+		return mcp.NewToolResultStructured(
+			SessionResult{SessionID: sessionID},
+			fmt.Sprintf("Created debugging session: %s", sessionID),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// createSession creates a debug session under the given ID on behalf of the
+// client that made the request.
+func (mds *MCPDebugServer) createSession(ctx context.Context,
+	sessionID string) (*debugSession, error) {
+
+	// Claim the ID up front so that concurrent calls can't both create a
+	// session under it or exceed the session limits.
+	client := clientID(ctx)
+	if err := mds.sessions.reserve(sessionID, client); err != nil {
+		return nil, err
+	}
+
+	// The command is always queued, so that a client giving up can't
+	// leave it unclear whether the debugger started a session.
+	cmd := &debugger.CreateSessionCmd{Name: sessionID}
+	future := mds.debugger.Ask(
+		context.WithoutCancel(ctx), &debugger.DebuggerCmd{Cmd: cmd},
+	)
+	result, err := future.Await(ctx).Unpack()
+	if err != nil {
+		mds.sessions.release(sessionID)
+		if ctx.Err() != nil {
+			mds.wg.Add(1)
+			go mds.closeAbandonedSession(sessionID, future)
 		}
 
-		// Store session reference
-		createResp, ok := result.Resp.(*debugger.CreateSessionResp)
-		if !ok {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent("Invalid response from debugger"),
-				},
-				IsError: true,
-			}, nil
+		return nil, err
+	}
+
+	createResp, ok := result.Resp.(*debugger.CreateSessionResp)
+	if !ok {
+		mds.sessions.release(sessionID)
+		return nil, fmt.Errorf("invalid response from debugger: %T",
+			result.Resp)
+	}
+
+	sess := &debugSession{
+		id:     createResp.ID,
+		client: client,
+		ref:    createResp.Session,
+		events: createResp.Events,
+		breakpoints: debugger.NewBreakpointTracker(
+			createResp.Events,
+		),
+		output: debugger.NewOutputBuffer(createResp.Events, 0),
+	}
+	sess.watches = debugger.NewWatchTracker(
+		createResp.Session, createResp.Events, 0,
+		func(stop *debugger.WatchStop) {
+			if stop.Location != nil {
+				sess.noteFrames(stop.Location.FrameID)
+			}
+			mds.notifyStop(client, sessionID, stop)
+		},
+	)
+	mds.sessions.insert(sessionID, sess)
+	mds.addSessionResources(sessionID, sess)
+	mds.forwardEvents(sessionID, sess)
+
+	return sess, nil
+}
+
+// closeAbandonedSession closes the session a create command started after the
+// client stopped waiting for it. Nothing refers to such a session, so its
+// Delve server would otherwise run until the MCP server exits.
+func (mds *MCPDebugServer) closeAbandonedSession(sessionID string,
+	future actor.Future[*debugger.DebuggerResp]) {
+
+	defer mds.wg.Done()
+
+	result, err := future.Await(context.Background()).Unpack()
+	if err != nil {
+		return
+	}
+	createResp, ok := result.Resp.(*debugger.CreateSessionResp)
+	if !ok {
+		return
+	}
+
+	logging.Component("mcp").Info("Closing session whose creation was "+
+		"cancelled", logging.KeySession, sessionID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	cmd := &debugger.CloseSessionCmd{ID: createResp.ID}
+	future = mds.debugger.Ask(ctx, &debugger.DebuggerCmd{Cmd: cmd})
+	if _, err := future.Await(ctx).Unpack(); err != nil {
+		logging.Component("mcp").Error("Failed to close session",
+			logging.KeySession, sessionID, "err", err)
+	}
+}
+
+// registerCloseSessionTool registers the close debugging session tool.
+func (mds *MCPDebugServer) registerCloseSessionTool() {
+	tool := mcp.NewTool("close_debug_session",
+		mcp.WithDescription("Close a debugging session, ending the debugged program and shutting down its debugger"),
+		mcp.WithOutputSchema[SessionResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithBoolean("detach",
+			mcp.Description("Leave an attached process running instead of killing it")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args CloseSessionArgs) (*mcp.CallToolResult, error) {
+
+		// Removing the session first means any concurrent call using
+		// it fails cleanly with "not found" rather than racing the
+		// shutdown.
+		sess, exists := mds.sessions.remove(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		mds.sessions[sessionID] = createResp.Session
+		err := mds.closeSession(ctx, args.SessionID, sess, !args.Detach)
+		if err != nil {
+			return errorResult(
+				"Failed to close session: %v", err), nil
+		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Created debugging session: %s", sessionID)),
-			},
-		}, nil
+		return mcp.NewToolResultStructured(
+			SessionResult{SessionID: args.SessionID},
+			fmt.Sprintf("Closed debugging session: %s",
+				args.SessionID),
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
 }
 
+// closeTimeout bounds each step of closing a session.
+const closeTimeout = time.Minute
+
+// closeSession shuts down a session that has already been removed from the
+// registry, disconnecting from the debugged program first. Once removed the
+// session can't be closed again, so closing carries on even if the caller's
+// context is cancelled, with each step bounded by closeTimeout instead.
+func (mds *MCPDebugServer) closeSession(ctx context.Context, sessionID string,
+	sess *debugSession, terminate bool) error {
+
+	ctx = context.WithoutCancel(ctx)
+
+	sess.breakpoints.Stop()
+	sess.watches.Stop()
+	sess.output.Stop()
+	if sess.stopEventForwarding != nil {
+		sess.stopEventForwarding()
+	}
+	if sess.stopTracking != nil {
+		sess.stopTracking()
+	}
+	mds.removeSessionResources(sessionID)
+
+	// A failed disconnect, e.g. because the program was never launched,
+	// shouldn't prevent the session from being torn down.
+	disconnectCtx, cancel := context.WithTimeout(ctx, closeTimeout)
+	_, err := debugger.Disconnect(disconnectCtx, sess.ref, terminate)
+	cancel()
+	if err != nil {
+		logging.Component("mcp").Warn("Failed to disconnect session",
+			logging.KeySession, sessionID, "err", err)
+	}
+
+	ctx, cancel = context.WithTimeout(ctx, closeTimeout)
+	defer cancel()
+
+	cmd := &debugger.CloseSessionCmd{ID: sess.id}
+	future := mds.debugger.Ask(ctx, &debugger.DebuggerCmd{Cmd: cmd})
+	_, err = future.Await(ctx).Unpack()
+
+	return err
+}
+
+// logToolCalls is a tool handler middleware that logs every tool call with its
+// tool name and session ID. Calls that fail are logged as warnings, so they
+// can be found without enabling debug logging.
+func (mds *MCPDebugServer) logToolCalls(
+	next server.ToolHandlerFunc) server.ToolHandlerFunc {
+
+	return func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		logger := logging.Component("mcp").With(
+			logging.KeyTool, request.Params.Name,
+		)
+		id, _ := request.GetArguments()["session_id"].(string)
+		if id != "" {
+			logger = logger.With(logging.KeySession, id)
+		}
+
+		start := time.Now()
+		result, err := next(ctx, request)
+		elapsed := time.Since(start)
+
+		switch {
+		case err != nil:
+			logger.Error("Tool call failed", "duration", elapsed,
+				"err", err)
+
+		case result != nil && result.IsError:
+			logger.Warn("Tool call returned an error",
+				"duration", elapsed, "error", resultText(result))
+
+		default:
+			logger.Debug("Tool call succeeded", "duration", elapsed)
+		}
+
+		return result, err
+	}
+}
+
+// trackActivity is a tool handler middleware that records when tool calls
+// use a session, so that sessions in use are never reaped as idle.
+func (mds *MCPDebugServer) trackActivity(
+	next server.ToolHandlerFunc) server.ToolHandlerFunc {
+
+	return func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		id, _ := request.GetArguments()["session_id"].(string)
+		if sess, ok := mds.sessions.get(id); ok {
+			sess.begin()
+			defer sess.end()
+		}
+
+		return next(ctx, request)
+	}
+}
+
+// reapIdleSessions periodically closes sessions that have not been used for
+// longer than the idle timeout. It must be run as a goroutine.
+func (mds *MCPDebugServer) reapIdleSessions() {
+	defer mds.wg.Done()
+
+	// Check often enough that sessions don't outlive the timeout by much.
+	interval := mds.limits.IdleTimeout / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-mds.quit:
+			return
+		}
+
+		cutoff := time.Now().Add(-mds.limits.IdleTimeout)
+		for id, sess := range mds.sessions.removeIdle(cutoff) {
+			logging.Component("mcp").Info("Closing idle session",
+				logging.KeySession, id,
+				"idle_timeout", mds.limits.IdleTimeout)
+
+			err := mds.closeSession(
+				context.Background(), id, sess, true,
+			)
+			if err != nil {
+				logging.Component("mcp").Error("Failed to close idle session",
+					logging.KeySession, id, "err", err)
+			}
+		}
+	}
+}
+
+// clientID returns an identifier for the MCP client that made a request, used
+// to apply per-client session limits.
+func clientID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+
+	return ""
+}
+
 // registerInitializeSessionTool registers the initialize session tool.
 func (mds *MCPDebugServer) registerInitializeSessionTool() {
 	tool := mcp.NewTool("initialize_session",
 		mcp.WithDescription("Initialize a debugging session with DAP protocol"),
+		mcp.WithOutputSchema[InitializeResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithString("client_id", mcp.Required(),
@@ -216,37 +689,25 @@ func (mds *MCPDebugServer) registerInitializeSessionTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args InitializeSessionArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
 		// Initialize the session
-		resp, err := debugger.InitializeSession(session, args.ClientID)
+		resp, err := mds.initializeSession(ctx, sess, args.ClientID)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to initialize session: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult(
+				"Failed to initialize session: %v", err), nil
 		}
 
-		respJSON, _ := json.Marshal(resp)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Session initialized successfully. Response: %s",
-					string(respJSON))),
+		return mcp.NewToolResultStructured(
+			InitializeResult{
+				SessionID:    args.SessionID,
+				Capabilities: resp.Body,
 			},
-		}, nil
+			"Session initialized successfully",
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -256,10 +717,13 @@ func (mds *MCPDebugServer) registerInitializeSessionTool() {
 func (mds *MCPDebugServer) registerLaunchProgramTool() {
 	tool := mcp.NewTool("launch_program",
 		mcp.WithDescription("Launch a Go program for debugging. Automatically detects test files and binaries, adds debug build flags (-gcflags='all=-N -l') to prevent optimization"),
+		mcp.WithOutputSchema[LaunchResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithString("program", mcp.Required(),
 			mcp.Description("Path to the Go program, test file, or pre-built binary. For tests, use the test file path or directory")),
+		mcp.WithString("mode",
+			mcp.Description("Launch mode: 'debug' to build a main package, 'test' to build a test binary or 'exec' for a pre-built binary. Detected from the program if omitted")),
 		mcp.WithString("name",
 			mcp.Description("Name for the debug session")),
 		mcp.WithArray("args",
@@ -280,21 +744,16 @@ func (mds *MCPDebugServer) registerLaunchProgramTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args LaunchProgramArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
 		// Build launch configuration
 		config := debugger.LaunchConfig{
 			Name:        getStringOrDefault(args.Name, "Debug Session"),
 			Program:     args.Program,
+			Mode:        args.Mode,
 			Args:        args.Args,
 			Env:         args.Env,
 			WorkingDir:  args.WorkingDir,
@@ -302,35 +761,129 @@ func (mds *MCPDebugServer) registerLaunchProgramTool() {
 			BuildFlags:  args.BuildFlags,
 		}
 
-		// Launch the program
-		resp, err := debugger.LaunchProgram(session, config)
+		_, err := mds.launchSession(ctx, args.SessionID, sess, config)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to launch program: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult(
+				"Failed to launch program: %v", err), nil
 		}
 
-		respJSON, _ := json.Marshal(resp)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Program launched successfully. Response: %s",
-					string(respJSON))),
+		return mcp.NewToolResultStructured(
+			LaunchResult{
+				SessionID: args.SessionID,
+				Launch:    sess.launchConfig(),
+				Binary:    sess.binaryPath(),
 			},
-		}, nil
+			fmt.Sprintf("Program %s launched successfully",
+				args.Program),
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
 }
 
+// launchSession launches a program in the given session and records the
+// launch configuration and the path of the binary being debugged.
+func (mds *MCPDebugServer) launchSession(ctx context.Context,
+	sessionID string, sess *debugSession,
+	config debugger.LaunchConfig) (*dap.LaunchResponse, error) {
+
+	if err := mds.policy.checkLaunch(config); err != nil {
+		return nil, err
+	}
+
+	// The output path is specific to this server and session, so it's
+	// never taken from the caller.
+	config.Output = ""
+	launched := config
+
+	// When building from source, direct Delve to write the binary to a
+	// known location so that its symbols can be searched. The debugger's
+	// session ID is unique to this server, unlike the client's.
+	binary := config.Program
+	if debugger.DetectLaunchMode(config) != "exec" {
+		if mds.buildDirErr != nil {
+			return nil, fmt.Errorf("no build directory: %w",
+				mds.buildDirErr)
+		}
+		binary = filepath.Join(mds.buildDir, "__debug_bin_"+sess.id)
+		config.Output = binary
+
+		// The server's flags aren't recorded with the launch
+		// configuration, so they aren't duplicated when a
+		// workspace is loaded by another server.
+		if len(mds.buildFlags) > 0 {
+			config.BuildFlags = append(
+				slices.Clone(mds.buildFlags),
+				config.BuildFlags...,
+			)
+		}
+	}
+
+	resp, err := debugger.LaunchProgram(ctx, sess.ref, config)
+	if err != nil {
+		return nil, err
+	}
+
+	sess.setBinary(binary)
+	sess.setLaunch(launched)
+	sess.setState(StateLaunched)
+
+	return resp, nil
+}
+
+// initializeSession initializes the debug adapter of a new session.
+func (mds *MCPDebugServer) initializeSession(ctx context.Context,
+	sess *debugSession, clientID string) (*dap.InitializeResponse, error) {
+
+	resp, err := debugger.InitializeSession(ctx, sess.ref, clientID)
+	if err != nil {
+		return nil, err
+	}
+	sess.setState(StateInitialized)
+
+	return resp, nil
+}
+
+// configureSession tells the debug adapter that configuration is done, which
+// starts the program. A stop reported before the response, e.g. on entry,
+// isn't overwritten.
+func (mds *MCPDebugServer) configureSession(ctx context.Context,
+	sess *debugSession) error {
+
+	if _, err := debugger.ConfigurationDone(ctx, sess.ref); err != nil {
+		return err
+	}
+	sess.advance(StateConfigured, StateLaunched, StateAttached)
+
+	return nil
+}
+
+// attachSession attaches a session to a process and records the path of the
+// binary being debugged.
+func (mds *MCPDebugServer) attachSession(ctx context.Context,
+	sess *debugSession,
+	config debugger.AttachConfig) (*dap.AttachResponse, error) {
+
+	if err := mds.policy.checkAttach(); err != nil {
+		return nil, err
+	}
+
+	resp, err := debugger.AttachToProcess(ctx, sess.ref, config)
+	if err != nil {
+		return nil, err
+	}
+
+	sess.setBinary(processBinary(config.ProcessID))
+	sess.setState(StateAttached)
+
+	return resp, nil
+}
+
 // registerConfigurationDoneTool registers the configuration done tool.
 func (mds *MCPDebugServer) registerConfigurationDoneTool() {
 	tool := mcp.NewTool("configuration_done",
 		mcp.WithDescription("Signal that configuration is complete and debugging can begin"),
+		mcp.WithOutputSchema[ExecutionResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 	)
@@ -338,36 +891,25 @@ func (mds *MCPDebugServer) registerConfigurationDoneTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args GetThreadsArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
 		// Send configuration done
-		resp, err := debugger.ConfigurationDone(session)
+		err := mds.configureSession(ctx, sess)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to send configuration done: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult(
+				"Failed to send configuration done: %v", err), nil
 		}
 
-		respJSON, _ := json.Marshal(resp)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Configuration done. Response: %s", string(respJSON))),
+		return mcp.NewToolResultStructured(
+			ExecutionResult{
+				SessionID: args.SessionID,
+				Command:   "configurationDone",
 			},
-		}, nil
+			"Configuration done",
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -377,49 +919,222 @@ func (mds *MCPDebugServer) registerConfigurationDoneTool() {
 func (mds *MCPDebugServer) registerSetBreakpointsTool() {
 	tool := mcp.NewTool("set_breakpoints",
 		mcp.WithDescription("Set breakpoints in source code"),
+		mcp.WithOutputSchema[BreakpointsResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithString("file", mcp.Required(),
 			mcp.Description("Source file path")),
-		mcp.WithArray("lines", mcp.Required(),
-			mcp.Description("Line numbers for breakpoints"),
+		mcp.WithArray("lines",
+			mcp.Description("Line numbers for plain breakpoints"),
 			mcp.Items(map[string]any{"type": "integer"})),
+		mcp.WithArray("breakpoints",
+			mcp.Description("Breakpoints with an optional condition, hit condition (e.g. '>= 5') or log message. A breakpoint with a log message is a logpoint: it logs the message, with expressions in {braces} interpolated, instead of stopping"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"line":          map[string]any{"type": "integer"},
+					"condition":     map[string]any{"type": "string"},
+					"hit_condition": map[string]any{"type": "string"},
+					"log_message":   map[string]any{"type": "string"},
+				},
+				"required": []string{"line"},
+			})),
 	)
 
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args SetBreakpointsArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		if len(args.Lines) == 0 && len(args.Breakpoints) == 0 {
+			return errorResult("Either lines or breakpoints must " +
+				"be provided"), nil
 		}
 
 		// Set breakpoints
-		resp, err := debugger.SetSourceBreakpoints(session, args.File, args.Lines)
+		var locations []debugger.BreakpointLocation
+		for _, line := range args.Lines {
+			locations = append(locations, debugger.BreakpointLocation{
+				File: args.File,
+				Line: line,
+			})
+		}
+		for _, bp := range args.Breakpoints {
+			locations = append(locations, debugger.BreakpointLocation{
+				File:         args.File,
+				Line:         bp.Line,
+				Condition:    bp.Condition,
+				HitCondition: bp.HitCondition,
+				LogMessage:   bp.LogMessage,
+			})
+		}
+
+		statuses, err := sess.breakpoints.SetBreakpoints(ctx, sess.ref, locations)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to set breakpoints: %v", err)),
+			return errorResult(
+				"Failed to set breakpoints: %v", err), nil
+		}
+		mds.notifySessionResource(
+			args.SessionID, sess, breakpointsResource,
+		)
+
+		return mcp.NewToolResultStructured(
+			BreakpointsResult{
+				SessionID:   args.SessionID,
+				File:        args.File,
+				Breakpoints: statuses,
+				Unverified:  unverifiedBreakpoints(statuses),
+			},
+			formatBreakpointStatuses(
+				fmt.Sprintf("Breakpoints set in %s:", args.File),
+				statuses),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerSetFunctionBreakpointsTool registers the set function breakpoints
+// tool.
+func (mds *MCPDebugServer) registerSetFunctionBreakpointsTool() {
+	tool := mcp.NewTool("set_function_breakpoints",
+		mcp.WithDescription("Set breakpoints on functions by name, replacing the session's previous function breakpoints"),
+		mcp.WithOutputSchema[FunctionBreakpointsResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithArray("breakpoints", mcp.Required(),
+			mcp.Description("Function breakpoints. Names are fully qualified, e.g. 'main.handleRequest' or '(*net/http.Server).Serve'. An empty list clears all function breakpoints"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":          map[string]any{"type": "string"},
+					"condition":     map[string]any{"type": "string"},
+					"hit_condition": map[string]any{"type": "string"},
 				},
-				IsError: true,
-			}, nil
+				"required": []string{"name"},
+			})),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args SetFunctionBreakpointsArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		respJSON, _ := json.Marshal(resp.Body.Breakpoints)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Breakpoints set successfully. Breakpoints: %s",
-					string(respJSON))),
+		resp, err := sess.breakpoints.SetFunctionBreakpoints(
+			ctx, sess.ref, args.Breakpoints,
+		)
+		if err != nil {
+			return errorResult(
+				"Failed to set function breakpoints: %v",
+				err), nil
+		}
+		mds.notifySessionResource(
+			args.SessionID, sess, breakpointsResource,
+		)
+
+		statuses := functionBreakpointStatuses(
+			args.Breakpoints, resp.Body.Breakpoints,
+		)
+
+		return mcp.NewToolResultStructured(
+			FunctionBreakpointsResult{
+				SessionID:   args.SessionID,
+				Breakpoints: statuses,
 			},
-		}, nil
+			formatFunctionBreakpoints(statuses),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerSetExceptionBreakpointsTool registers the set exception breakpoints
+// tool.
+func (mds *MCPDebugServer) registerSetExceptionBreakpointsTool() {
+	tool := mcp.NewTool("set_exception_breakpoints",
+		mcp.WithDescription("Set the exception filters to stop on. Delve always stops on panics and fatal errors, but the filters are recorded and saved with workspaces for debug adapters that use them"),
+		mcp.WithOutputSchema[ExceptionBreakpointsResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithArray("filters", mcp.Required(),
+			mcp.Description("Exception filter IDs"),
+			mcp.Items(map[string]any{"type": "string"})),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args SetExceptionBreakpointsArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		err := sess.breakpoints.SetExceptionBreakpoints(
+			ctx, sess.ref, args.Filters,
+		)
+		if err != nil {
+			return errorResult(
+				"Failed to set exception breakpoints: "+
+					"%v", err), nil
+		}
+		mds.notifySessionResource(
+			args.SessionID, sess, breakpointsResource,
+		)
+
+		return mcp.NewToolResultStructured(
+			ExceptionBreakpointsResult{
+				SessionID: args.SessionID,
+				Filters:   sess.breakpoints.ExceptionFilters(),
+			},
+			fmt.Sprintf("Exception filters set: %s",
+				getStringOrDefault(
+					strings.Join(args.Filters, ", "),
+					"none")),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerGetBreakpointsTool registers the get breakpoints tool.
+func (mds *MCPDebugServer) registerGetBreakpointsTool() {
+	tool := mcp.NewTool("get_breakpoints",
+		mcp.WithDescription("List the session's source breakpoints with their current verification status and actual line. The status reflects later changes reported by the debugger, e.g. breakpoints verified after the program loaded more code"),
+		mcp.WithOutputSchema[BreakpointsResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args GetThreadsArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		statuses := sess.breakpoints.Breakpoints()
+		result := BreakpointsResult{
+			SessionID:   args.SessionID,
+			Breakpoints: statuses,
+			Unverified:  unverifiedBreakpoints(statuses),
+		}
+		if len(statuses) == 0 {
+			result.Breakpoints = []debugger.BreakpointStatus{}
+			return mcp.NewToolResultStructured(
+				result, "No breakpoints set",
+			), nil
+		}
+
+		return mcp.NewToolResultStructured(
+			result, formatBreakpointStatuses("Breakpoints:", statuses),
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -429,6 +1144,7 @@ func (mds *MCPDebugServer) registerSetBreakpointsTool() {
 func (mds *MCPDebugServer) registerContinueTool() {
 	tool := mcp.NewTool("continue_execution",
 		mcp.WithDescription("Continue program execution"),
+		mcp.WithOutputSchema[ExecutionResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithNumber("thread_id", mcp.Required(),
@@ -438,36 +1154,30 @@ func (mds *MCPDebugServer) registerContinueTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args ExecutionControlArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
 		// Continue execution
-		resp, err := debugger.Continue(session, args.ThreadID)
+		restore := sess.resume()
+		resp, err := debugger.Continue(ctx, sess.ref, args.ThreadID)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to continue execution: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			restore()
+			return errorResult(
+				"Failed to continue execution: %v", err), nil
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Continued execution. All threads continued: %t",
-					resp.Body.AllThreadsContinued)),
+		return mcp.NewToolResultStructured(
+			ExecutionResult{
+				SessionID:           args.SessionID,
+				Command:             "continue",
+				ThreadID:            args.ThreadID,
+				AllThreadsContinued: resp.Body.AllThreadsContinued,
 			},
-		}, nil
+			fmt.Sprintf("Continued execution. All threads "+
+				"continued: %t", resp.Body.AllThreadsContinued),
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -477,43 +1187,41 @@ func (mds *MCPDebugServer) registerContinueTool() {
 func (mds *MCPDebugServer) registerGetThreadsTool() {
 	tool := mcp.NewTool("get_threads",
 		mcp.WithDescription("Get information about all threads in the debugged program"),
+		mcp.WithOutputSchema[ThreadsResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
+		mcp.WithNumber("max_chars",
+			mcp.Description(maxCharsDescription)),
 	)
 
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args GetThreadsArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
 		// Get threads
-		threads, err := debugger.GetThreadsInfo(session)
+		threads, err := debugger.GetThreadsInfo(ctx, sess.ref)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to get threads: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult(
+				"Failed to get threads: %v", err), nil
 		}
 
-		threadsJSON, _ := json.Marshal(threads)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Threads: %s", string(threadsJSON))),
+		shown, text := renderThreads(
+			threads, sess.stoppedThread(), sess.mainModule(),
+			renderBudget(args.MaxChars),
+		)
+
+		return mcp.NewToolResultStructured(
+			ThreadsResult{
+				SessionID: args.SessionID,
+				Threads:   shown,
+				Total:     len(threads),
 			},
-		}, nil
+			text,
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -521,7 +1229,7 @@ func (mds *MCPDebugServer) registerGetThreadsTool() {
 
 // Serve starts the MCP server using stdio transport.
 func (mds *MCPDebugServer) Serve() error {
-	log.Printf("Starting Go DAP MCP Server...")
+	logging.Component("mcp").Info("Serving MCP over stdio")
 	return server.ServeStdio(mds.server)
 }
 
@@ -535,10 +1243,109 @@ func getStringOrDefault(val, defaultVal string) string {
 	return val
 }
 
+// formatBreakpointStatuses renders one line per breakpoint that makes it
+// obvious whether it was verified, whether it moved, and why not if it wasn't,
+// followed by a summary of any unverified breakpoints.
+func formatBreakpointStatuses(header string,
+	statuses []debugger.BreakpointStatus) string {
+
+	var (
+		text       strings.Builder
+		unverified int
+	)
+	text.WriteString(header)
+	text.WriteString("\n")
+
+	for _, bp := range statuses {
+		fmt.Fprintf(&text, "- %s:%d: ", bp.File, bp.RequestedLine)
+
+		switch {
+		case !bp.Verified:
+			unverified++
+			text.WriteString("NOT VERIFIED")
+			if bp.Message != "" {
+				fmt.Fprintf(&text, " (%s)", bp.Message)
+			}
+
+		case bp.Moved():
+			fmt.Fprintf(&text, "verified, moved to line %d", bp.Line)
+
+		default:
+			text.WriteString("verified")
+		}
+
+		if bp.ID != 0 {
+			fmt.Fprintf(&text, " [id %d]", bp.ID)
+		}
+		text.WriteString("\n")
+	}
+
+	if unverified > 0 {
+		fmt.Fprintf(&text, "%d of %d breakpoints are not verified and "+
+			"will not be hit; check that the line contains code",
+			unverified, len(statuses))
+	}
+
+	return strings.TrimRight(text.String(), "\n")
+}
+
+// formatFunctionBreakpoints renders one line per function breakpoint saying
+// whether the debugger could resolve the function.
+func formatFunctionBreakpoints(statuses []FunctionBreakpointStatus) string {
+	if len(statuses) == 0 {
+		return "Function breakpoints cleared"
+	}
+
+	var text strings.Builder
+	text.WriteString("Function breakpoints:\n")
+	for _, bp := range statuses {
+		fmt.Fprintf(&text, "- %s: ", bp.Name)
+
+		if bp.Verified {
+			text.WriteString("verified")
+		} else {
+			text.WriteString("NOT VERIFIED")
+			if bp.Message != "" {
+				fmt.Fprintf(&text, " (%s)", bp.Message)
+			}
+		}
+		text.WriteString("\n")
+	}
+
+	return strings.TrimRight(text.String(), "\n")
+}
+
+// sanitizeFileName replaces any characters of a user supplied identifier that
+// aren't safe to use in a file name.
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z',
+			r >= '0' && r <= '9', r == '-', r == '_':
+
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// processBinary returns the path of the executable backing the process with
+// the given PID, or an empty string if it can't be determined on this
+// platform.
+func processBinary(pid int) string {
+	path, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
 // Placeholder implementations for remaining tools to keep file manageable
 func (mds *MCPDebugServer) registerNextTool() {
 	tool := mcp.NewTool("step_next",
 		mcp.WithDescription("Step over (execute next line without entering function calls)"),
+		mcp.WithOutputSchema[ExecutionResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithNumber("thread_id", mcp.Required(),
@@ -548,33 +1355,26 @@ func (mds *MCPDebugServer) registerNextTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args ExecutionControlArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		_, err := debugger.Next(session, args.ThreadID)
+		restore := sess.resume()
+		_, err := debugger.Next(ctx, sess.ref, args.ThreadID)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to step next: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			restore()
+			return errorResult("Failed to step next: %v", err), nil
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent("Stepped to next line successfully"),
+		return mcp.NewToolResultStructured(
+			ExecutionResult{
+				SessionID: args.SessionID,
+				Command:   "next",
+				ThreadID:  args.ThreadID,
 			},
-		}, nil
+			"Stepped to next line successfully",
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -583,6 +1383,7 @@ func (mds *MCPDebugServer) registerNextTool() {
 func (mds *MCPDebugServer) registerStepInTool() {
 	tool := mcp.NewTool("step_in",
 		mcp.WithDescription("Step into function calls"),
+		mcp.WithOutputSchema[ExecutionResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithNumber("thread_id", mcp.Required(),
@@ -592,33 +1393,26 @@ func (mds *MCPDebugServer) registerStepInTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args ExecutionControlArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		_, err := debugger.StepIn(session, args.ThreadID)
+		restore := sess.resume()
+		_, err := debugger.StepIn(ctx, sess.ref, args.ThreadID)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to step in: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			restore()
+			return errorResult("Failed to step in: %v", err), nil
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent("Stepped into function successfully"),
+		return mcp.NewToolResultStructured(
+			ExecutionResult{
+				SessionID: args.SessionID,
+				Command:   "stepIn",
+				ThreadID:  args.ThreadID,
 			},
-		}, nil
+			"Stepped into function successfully",
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -627,6 +1421,7 @@ func (mds *MCPDebugServer) registerStepInTool() {
 func (mds *MCPDebugServer) registerStepOutTool() {
 	tool := mcp.NewTool("step_out",
 		mcp.WithDescription("Step out of current function"),
+		mcp.WithOutputSchema[ExecutionResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithNumber("thread_id", mcp.Required(),
@@ -636,33 +1431,26 @@ func (mds *MCPDebugServer) registerStepOutTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args ExecutionControlArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		_, err := debugger.StepOut(session, args.ThreadID)
+		restore := sess.resume()
+		_, err := debugger.StepOut(ctx, sess.ref, args.ThreadID)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to step out: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			restore()
+			return errorResult("Failed to step out: %v", err), nil
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent("Stepped out of function successfully"),
+		return mcp.NewToolResultStructured(
+			ExecutionResult{
+				SessionID: args.SessionID,
+				Command:   "stepOut",
+				ThreadID:  args.ThreadID,
 			},
-		}, nil
+			"Stepped out of function successfully",
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -671,6 +1459,7 @@ func (mds *MCPDebugServer) registerStepOutTool() {
 func (mds *MCPDebugServer) registerPauseTool() {
 	tool := mcp.NewTool("pause_execution",
 		mcp.WithDescription("Pause program execution"),
+		mcp.WithOutputSchema[ExecutionResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithNumber("thread_id", mcp.Required(),
@@ -680,33 +1469,25 @@ func (mds *MCPDebugServer) registerPauseTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args ExecutionControlArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		_, err := debugger.Pause(session, args.ThreadID)
+		_, err := debugger.Pause(ctx, sess.ref, args.ThreadID)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to pause execution: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult(
+				"Failed to pause execution: %v", err), nil
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent("Paused execution successfully"),
+		return mcp.NewToolResultStructured(
+			ExecutionResult{
+				SessionID: args.SessionID,
+				Command:   "pause",
+				ThreadID:  args.ThreadID,
 			},
-		}, nil
+			"Paused execution successfully",
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -714,148 +1495,208 @@ func (mds *MCPDebugServer) registerPauseTool() {
 
 func (mds *MCPDebugServer) registerGetStackFramesTool() {
 	tool := mcp.NewTool("get_stack_frames",
-		mcp.WithDescription("Get stack frames for a specific thread"),
+		mcp.WithDescription("Get stack frames for a specific thread. Frame IDs are only valid until the program resumes, while a frame's depth together with the thread ID can be passed to get_variables and evaluate_expression at any stop"),
+		mcp.WithOutputSchema[StackFramesResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithNumber("thread_id", mcp.Required(),
 			mcp.Description("Thread ID to get stack frames for")),
+		mcp.WithNumber("max_chars",
+			mcp.Description(maxCharsDescription)),
 	)
 
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args GetStackFramesArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		frames, err := debugger.GetStackFrames(session, args.ThreadID)
+		frames, err := sess.stackFrames(ctx, args.ThreadID)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to get stack frames: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult(
+				"Failed to get stack frames: %v", err), nil
 		}
 
-		framesJSON, _ := json.Marshal(frames)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Stack frames: %s", string(framesJSON))),
+		shown, text := renderStack(
+			args.ThreadID, frames, sess.mainModule(),
+			renderBudget(args.MaxChars),
+		)
+
+		return mcp.NewToolResultStructured(
+			StackFramesResult{
+				SessionID: args.SessionID,
+				ThreadID:  args.ThreadID,
+				Frames:    shown,
+				Total:     len(frames),
 			},
-		}, nil
+			text,
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
 }
 
 func (mds *MCPDebugServer) registerGetVariablesTool() {
-	tool := mcp.NewTool("get_variables",
-		mcp.WithDescription("Get variables for a specific scope. Note: Frame IDs become invalid after continue/step operations - call get_stack_frames first to get fresh IDs"),
+	options := []mcp.ToolOption{
+		mcp.WithDescription("Get the variables of a frame by scope, or the fields or elements of one variable by its path. " + frameHelp),
+		mcp.WithOutputSchema[VariablesResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
-		mcp.WithNumber("frame_id", mcp.Required(),
-			mcp.Description("Frame ID from get_stack_frames (must be fresh after any continue/step)")),
-	)
+		mcp.WithString("path",
+			mcp.Description(`Path of a variable to expand instead of listing the frame's scopes, e.g. req.Header["X"] or items[2].name`)),
+		mcp.WithNumber("max_chars",
+			mcp.Description(maxCharsDescription)),
+	}
+	tool := mcp.NewTool("get_variables",
+		append(options, frameOptions()...)...)
 
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args GetVariablesArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		scopes, err := debugger.GetVariableScopes(session, args.FrameID)
+		frameID, err := resolveFrame(ctx, sess, frameAddress{
+			FrameID:  args.FrameID,
+			ThreadID: args.ThreadID,
+			Depth:    args.Depth,
+		})
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to get variable scopes: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult("Failed to find frame: %v", err), nil
 		}
 
-		allVariables := make(map[string][]debugger.Variable)
-		for _, scope := range scopes {
-			variables, err := debugger.GetVariableList(session, scope.VariablesReference)
+		var (
+			scopes       []debugger.VariableScope
+			allVariables map[string][]debugger.Variable
+		)
+		if args.Path != "" {
+			scopes, allVariables, err = readPath(
+				ctx, sess, frameID, args.Path,
+			)
 			if err != nil {
-				continue
+				return errorResult("Failed to read %s: %v",
+					args.Path, err), nil
+			}
+		} else {
+			scopes, allVariables, err = readVariables(
+				ctx, sess, frameID, true,
+			)
+			if err != nil {
+				return errorResult(
+					"Failed to get variable scopes: %v",
+					err), nil
 			}
-			allVariables[scope.Name] = variables
 		}
 
-		variablesJSON, _ := json.Marshal(allVariables)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Variables: %s", string(variablesJSON))),
+		shown, omitted, text := renderVariables(
+			scopes, allVariables, renderBudget(args.MaxChars),
+		)
+
+		return mcp.NewToolResultStructured(
+			VariablesResult{
+				SessionID: args.SessionID,
+				FrameID:   frameID,
+				Path:      args.Path,
+				Scopes:    shown,
+				Omitted:   omitted,
 			},
-		}, nil
+			text,
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
 }
 
+// readVariables reads the variables of each scope of a frame, skipping
+// expensive scopes such as globals unless asked for. Scopes whose variables
+// can't be read are left out. It returns the scopes that were read, in the
+// order they were reported, and their variables by scope name.
+func readVariables(ctx context.Context, sess *debugSession, frameID int,
+	expensive bool) ([]debugger.VariableScope,
+	map[string][]debugger.Variable, error) {
+
+	scopes, err := debugger.GetVariableScopes(ctx, sess.ref, frameID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		read      []debugger.VariableScope
+		variables = make(map[string][]debugger.Variable)
+	)
+	for _, scope := range scopes {
+		if scope.Expensive && !expensive {
+			continue
+		}
+
+		vars, err := debugger.GetVariableList(
+			ctx, sess.ref, scope.VariablesReference,
+		)
+		if err != nil {
+			continue
+		}
+		read = append(read, scope)
+		variables[scope.Name] = vars
+	}
+
+	return read, variables, nil
+}
+
 func (mds *MCPDebugServer) registerEvaluateExpressionTool() {
-	tool := mcp.NewTool("evaluate_expression",
-		mcp.WithDescription("Evaluate an expression in the context of a specific frame. Note: Frame IDs become invalid after continue/step operations - call get_stack_frames first to get fresh IDs"),
+	options := []mcp.ToolOption{
+		mcp.WithDescription("Evaluate an expression in the context of a frame. " + frameHelp),
+		mcp.WithOutputSchema[EvaluationResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithString("expression", mcp.Required(),
 			mcp.Description("Expression to evaluate")),
-		mcp.WithNumber("frame_id", mcp.Required(),
-			mcp.Description("Frame ID from get_stack_frames (must be fresh after any continue/step)")),
-	)
+	}
+	tool := mcp.NewTool("evaluate_expression",
+		append(options, frameOptions()...)...)
 
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args EvaluateExpressionArgs) (*mcp.CallToolResult, error) {
 
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
-		result, err := debugger.EvaluateExpressionResult(session, args.Expression, args.FrameID)
+		frameID, err := resolveFrame(ctx, sess, frameAddress{
+			FrameID:  args.FrameID,
+			ThreadID: args.ThreadID,
+			Depth:    args.Depth,
+		})
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf(
-						"Failed to evaluate expression: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult("Failed to find frame: %v", err), nil
+		}
+
+		result, err := debugger.EvaluateExpressionResult(ctx, sess.ref, args.Expression, frameID)
+		if err != nil {
+			return errorResult(
+				"Failed to evaluate expression: %v", err), nil
 		}
 
-		resultJSON, _ := json.Marshal(result)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf(
-					"Evaluation result: %s", string(resultJSON))),
+		text := fmt.Sprintf("%s = %s", args.Expression, result.Result)
+		if result.Type != "" {
+			text += fmt.Sprintf(" (%s)", result.Type)
+		}
+
+		return mcp.NewToolResultStructured(
+			EvaluationResult{
+				SessionID:          args.SessionID,
+				FrameID:            frameID,
+				Expression:         args.Expression,
+				Result:             result.Result,
+				Type:               result.Type,
+				VariablesReference: result.VariablesReference,
 			},
-		}, nil
+			text,
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -865,6 +1706,7 @@ func (mds *MCPDebugServer) registerEvaluateExpressionTool() {
 func (mds *MCPDebugServer) registerAttachToProcessTool() {
 	tool := mcp.NewTool("attach_to_process",
 		mcp.WithDescription("Attach debugger to an existing running process"),
+		mcp.WithOutputSchema[LaunchResult](),
 		mcp.WithString("session_id", mcp.Required(),
 			mcp.Description("Session identifier")),
 		mcp.WithNumber("process_id", mcp.Required(),
@@ -882,14 +1724,9 @@ func (mds *MCPDebugServer) registerAttachToProcessTool() {
 	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
 		request mcp.CallToolRequest, args AttachToProcessArgs) (*mcp.CallToolResult, error) {
 		
-		session, exists := mds.sessions[args.SessionID]
+		sess, exists := mds.sessions.get(args.SessionID)
 		if !exists {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf("Session %s not found", args.SessionID)),
-				},
-				IsError: true,
-			}, nil
+			return sessionNotFound(args.SessionID), nil
 		}
 
 		// Convert MCP args to debugger.AttachConfig
@@ -901,23 +1738,20 @@ func (mds *MCPDebugServer) registerAttachToProcessTool() {
 			Port:      args.Port,
 		}
 
-		resp, err := debugger.AttachToProcess(session, config)
+		_, err := mds.attachSession(ctx, sess, config)
 		if err != nil {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.NewTextContent(fmt.Sprintf("Failed to attach to process: %v", err)),
-				},
-				IsError: true,
-			}, nil
+			return errorResult("Failed to attach to process: %v", err), nil
 		}
 
-		respJSON, _ := json.Marshal(resp)
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.NewTextContent(fmt.Sprintf("Successfully attached to process %d. Response: %s", 
-					args.ProcessID, string(respJSON))),
+		return mcp.NewToolResultStructured(
+			LaunchResult{
+				SessionID: args.SessionID,
+				Attach:    &config,
+				Binary:    sess.binaryPath(),
 			},
-		}, nil
+			fmt.Sprintf("Successfully attached to process %d",
+				args.ProcessID),
+		), nil
 	})
 
 	mds.server.AddTool(tool, handler)
@@ -927,8 +1761,458 @@ func (mds *MCPDebugServer) registerAttachToProcessTool() {
 // GetSessions returns a copy of the current sessions map for monitoring.
 func (mds *MCPDebugServer) GetSessions() map[string]actor.ActorRef[*debugger.DAPRequest, *debugger.DAPResponse] {
 	sessionsCopy := make(map[string]actor.ActorRef[*debugger.DAPRequest, *debugger.DAPResponse])
-	for k, v := range mds.sessions {
-		sessionsCopy[k] = v
+	for k, v := range mds.sessions.snapshot() {
+		sessionsCopy[k] = v.ref
 	}
 	return sessionsCopy
 }
+
+// SessionInfo describes a debug session for monitoring.
+type SessionInfo struct {
+	ID string
+
+	// Client is the MCP client session that created the debug session.
+	Client string
+
+	// Program is the launched program or, for attached sessions, the
+	// binary of the process. It is empty until either is known.
+	Program string
+
+	State SessionState
+
+	// Breakpoints is the number of source breakpoints set.
+	Breakpoints int
+
+	// LastActive is when the last tool call using the session finished.
+	LastActive time.Time
+}
+
+// SessionInfos returns a description of each current session, sorted by ID.
+func (mds *MCPDebugServer) SessionInfos() []SessionInfo {
+	sessions := mds.sessions.snapshot()
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for id, sess := range sessions {
+		info := SessionInfo{
+			ID:          id,
+			Client:      sess.client,
+			Program:     sess.binaryPath(),
+			State:       sess.currentState(),
+			Breakpoints: len(sess.breakpoints.SourceBreakpoints()),
+			LastActive:  sess.lastActivity(),
+		}
+		if launch := sess.launchConfig(); launch != nil {
+			info.Program = launch.Program
+		}
+
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+
+	return infos
+}
+
+// registerSearchSymbolsTool registers the symbol search tool.
+func (mds *MCPDebugServer) registerSearchSymbolsTool() {
+	tool := mcp.NewTool("search_symbols",
+		mcp.WithDescription("Search the functions, types and package-level variables of the debugged binary by regular expression. Function names are returned in the form expected by function breakpoints, e.g. main.(*Worker).process"),
+		mcp.WithOutputSchema[SymbolsResult](),
+		mcp.WithString("session_id",
+			mcp.Description("Session whose launched or attached binary should be searched")),
+		mcp.WithString("pattern", mcp.Required(),
+			mcp.Description("Regular expression matched against fully qualified symbol names, e.g. '^main\\.'")),
+		mcp.WithString("kind",
+			mcp.Description("Restrict results to 'function', 'type' or 'variable'")),
+		mcp.WithString("binary",
+			mcp.Description("Path of a binary to search instead of the session's binary")),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of symbols to return (default: 100)")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args SearchSymbolsArgs) (*mcp.CallToolResult, error) {
+
+		binary := args.Binary
+		if binary == "" {
+			sess, exists := mds.sessions.get(args.SessionID)
+			if !exists {
+				return sessionNotFound(args.SessionID), nil
+			}
+
+			binary = sess.binaryPath()
+			if binary == "" {
+				return errorResult(
+					"Session %s has no known binary yet; "+
+						"launch a program first or pass 'binary'",
+					args.SessionID), nil
+			}
+		}
+
+		symbols, err := debugger.SearchSymbols(
+			binary, args.Pattern, debugger.SymbolKind(args.Kind),
+		)
+		if err != nil {
+			return errorResult(
+				"Failed to search symbols: %v", err), nil
+		}
+
+		limit := args.Limit
+		if limit <= 0 {
+			limit = 100
+		}
+
+		result := SymbolsResult{
+			Pattern: args.Pattern,
+			Total:   len(symbols),
+			Symbols: symbols,
+		}
+
+		var text strings.Builder
+		fmt.Fprintf(&text, "Found %d symbols matching %q",
+			len(symbols), args.Pattern)
+		if len(symbols) > limit {
+			fmt.Fprintf(&text, " (showing first %d)", limit)
+			result.Symbols = symbols[:limit]
+		}
+		text.WriteString(":\n")
+
+		for _, sym := range result.Symbols {
+			fmt.Fprintf(&text, "%-8s %s", sym.Kind, sym.Name)
+			if sym.File != "" {
+				fmt.Fprintf(&text, " (%s:%d)", sym.File, sym.Line)
+			}
+			text.WriteString("\n")
+		}
+		if result.Symbols == nil {
+			result.Symbols = []debugger.Symbol{}
+		}
+
+		return mcp.NewToolResultStructured(result, text.String()), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerRunUntilTool registers the tool that steps until a condition holds.
+func (mds *MCPDebugServer) registerRunUntilTool() {
+	tool := mcp.NewTool("run_until",
+		mcp.WithDescription("Step a stopped thread line by line until a Go expression evaluates to true, the step limit is reached, or the program stops for another reason (e.g. a breakpoint). If 'function' is given, the program instead continues until that function is entered with the expression true, using a temporary conditional breakpoint"),
+		mcp.WithOutputSchema[RunUntilResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithNumber("thread_id", mcp.Required(),
+			mcp.Description("Thread ID to step")),
+		mcp.WithString("condition", mcp.Required(),
+			mcp.Description("Go expression to wait for, e.g. 'count < 0'")),
+		mcp.WithString("function",
+			mcp.Description("Fully qualified function to break in instead of stepping, e.g. 'main.(*Worker).process'")),
+		mcp.WithNumber("max_steps",
+			mcp.Description("Maximum number of line steps (default: 100)")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args RunUntilArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		// Watches are only evaluated once the program stops for the
+		// last time, not at every intermediate step.
+		resume := sess.watches.Suspend()
+		defer resume()
+
+		result, err := debugger.RunUntil(
+			ctx, sess.ref, sess.events, sess.breakpoints,
+			debugger.RunUntilConfig{
+				ThreadID:  args.ThreadID,
+				Condition: args.Condition,
+				Function:  args.Function,
+				MaxSteps:  args.MaxSteps,
+			},
+		)
+		if err != nil {
+			return errorResult(
+				"Failed to run until %q: %v",
+				args.Condition, err), nil
+		}
+		if result.Location != nil {
+			sess.noteFrames(result.Location.FrameID)
+		}
+
+		return mcp.NewToolResultStructured(
+			RunUntilResult{
+				SessionID: args.SessionID,
+				Condition: args.Condition,
+				Result:    result,
+			},
+			formatRunUntilResult(args.Condition, result),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// formatRunUntilResult renders the outcome of a run_until call.
+func formatRunUntilResult(condition string,
+	result *debugger.RunUntilResult) string {
+
+	var text strings.Builder
+	switch result.Reason {
+	case debugger.RunUntilConditionMet:
+		fmt.Fprintf(&text, "Condition %q became true", condition)
+
+	case debugger.RunUntilStepLimit:
+		fmt.Fprintf(&text, "Condition %q still false after step limit",
+			condition)
+
+	case debugger.RunUntilOtherStop:
+		fmt.Fprintf(&text, "Stopped (reason: %s) before condition %q "+
+			"became true", result.StopReason, condition)
+
+	case debugger.RunUntilTerminated:
+		fmt.Fprintf(&text, "Program terminated before condition %q "+
+			"became true", condition)
+
+	case debugger.RunUntilTimeout:
+		fmt.Fprintf(&text, "Program did not stop in time and was "+
+			"paused before condition %q became true", condition)
+	}
+	fmt.Fprintf(&text, " after %d steps\n", result.Steps)
+
+	if loc := result.Location; loc != nil {
+		fmt.Fprintf(&text, "Location: %s at %s:%d (thread %d, "+
+			"frame %d)\n", loc.Function, loc.File, loc.Line,
+			loc.ThreadID, loc.FrameID)
+	}
+	if result.LastError != "" {
+		fmt.Fprintf(&text, "Last evaluation error: %s\n",
+			result.LastError)
+	}
+
+	return text.String()
+}
+
+// registerTraceExecutionTool registers the execution trace recording tool.
+func (mds *MCPDebugServer) registerTraceExecutionTool() {
+	tool := mcp.NewTool("trace_execution",
+		mcp.WithDescription("Single-step a stopped thread and record every visited line together with the values of chosen expressions, returning the whole trace as a table. Stops after max_steps, when the given function returns, or when the program stops for another reason"),
+		mcp.WithOutputSchema[TraceResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithNumber("thread_id", mcp.Required(),
+			mcp.Description("Thread ID to trace")),
+		mcp.WithNumber("max_steps",
+			mcp.Description("Maximum number of steps to record (default: 50)")),
+		mcp.WithBoolean("step_in",
+			mcp.Description("Step into function calls instead of over them")),
+		mcp.WithString("function",
+			mcp.Description("End the trace once this function, e.g. 'main.process', returns")),
+		mcp.WithArray("expressions",
+			mcp.Description("Expressions to evaluate at every step, e.g. ['i', 'len(queue)']"),
+			mcp.Items(map[string]any{"type": "string"})),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args TraceExecutionArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		resume := sess.watches.Suspend()
+		defer resume()
+
+		result, err := debugger.TraceExecution(
+			ctx, sess.ref, sess.events, debugger.TraceConfig{
+				ThreadID:    args.ThreadID,
+				MaxSteps:    args.MaxSteps,
+				StepIn:      args.StepIn,
+				Function:    args.Function,
+				Expressions: args.Expressions,
+			},
+		)
+		if err != nil {
+			return errorResult(
+				"Failed to trace execution: %v", err), nil
+		}
+
+		return mcp.NewToolResultStructured(
+			TraceResult{SessionID: args.SessionID, Trace: result},
+			formatTrace(result),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// formatTrace renders an execution trace as a table with one row per step.
+// The function is only shown when it changes to keep the table compact.
+func formatTrace(result *debugger.TraceResult) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Recorded %d lines (ended: %s", len(result.Steps),
+		result.Reason)
+	if result.StopReason != "" {
+		fmt.Fprintf(&text, ", stop reason: %s", result.StopReason)
+	}
+	text.WriteString(")\n\n")
+
+	w := tabwriter.NewWriter(&text, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "step\tlocation\tfunction")
+	for _, expr := range result.Expressions {
+		fmt.Fprintf(w, "\t%s", expr)
+	}
+	fmt.Fprintln(w)
+
+	var lastFunction string
+	for _, step := range result.Steps {
+		function := step.Function
+		if function == lastFunction {
+			function = "\""
+		}
+		lastFunction = step.Function
+
+		fmt.Fprintf(w, "%d\t%s:%d\t%s", step.Step,
+			filepath.Base(step.File), step.Line, function)
+		for _, value := range step.Values {
+			fmt.Fprintf(w, "\t%s", value)
+		}
+		fmt.Fprintln(w)
+	}
+	_ = w.Flush()
+
+	return text.String()
+}
+
+// registerProfileFunctionsTool registers the function call profiling tool.
+func (mds *MCPDebugServer) registerProfileFunctionsTool() {
+	tool := mcp.NewTool("profile_functions",
+		mcp.WithDescription("Let a stopped program run for a while and count the calls to the given functions, broken down by caller, using breakpoints that resume automatically. Optionally measures wall-clock call durations, which include debugger overhead. Existing function breakpoints are suspended while profiling and the program is paused afterwards"),
+		mcp.WithOutputSchema[ProfileResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithArray("functions", mcp.Required(),
+			mcp.Description("Fully qualified functions to count, e.g. ['main.(*Worker).process']"),
+			mcp.Items(map[string]any{"type": "string"})),
+		mcp.WithNumber("duration_seconds",
+			mcp.Description("How long to let the program run (default: 5)")),
+		mcp.WithBoolean("timing",
+			mcp.Description("Measure call durations by stepping out of every call (much slower)")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args ProfileFunctionsArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		resume := sess.watches.Suspend()
+		defer resume()
+
+		result, err := debugger.ProfileFunctions(
+			ctx, sess.ref, sess.events, sess.breakpoints,
+			debugger.ProfileConfig{
+				Functions: args.Functions,
+				Duration: time.Duration(
+					args.DurationSeconds * float64(time.Second),
+				),
+				Timing: args.Timing,
+			},
+		)
+		if err != nil {
+			text := fmt.Sprintf("Failed to profile functions: %v", err)
+			if result != nil {
+				text += "\n" + formatProfile(result)
+			}
+
+			return errorResult("%s", text), nil
+		}
+
+		return mcp.NewToolResultStructured(
+			ProfileResult{SessionID: args.SessionID, Profile: result},
+			formatProfile(result),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// formatProfile renders the call counts, callers and durations collected by a
+// profile_functions call.
+func formatProfile(result *debugger.ProfileResult) string {
+	var text strings.Builder
+	if result.Reason != "" {
+		fmt.Fprintf(&text, "Profiled for %v (ended: %s",
+			result.Elapsed.Round(time.Millisecond), result.Reason)
+		if result.StopReason != "" {
+			fmt.Fprintf(&text, ", stop reason: %s",
+				result.StopReason)
+		}
+		text.WriteString(")\n")
+	}
+
+	for _, fn := range result.Functions {
+		fmt.Fprintf(&text, "\n%s: %d calls\n", fn.Name, fn.Calls)
+		for _, caller := range fn.Callers {
+			fmt.Fprintf(&text, "  %6d  from %s\n", caller.Calls,
+				caller.Caller)
+		}
+
+		if fn.Histogram == nil {
+			continue
+		}
+		if fn.Returns == 0 {
+			text.WriteString("  no returns observed\n")
+			continue
+		}
+
+		fmt.Fprintf(&text, "  duration over %d returns: min %v, "+
+			"mean %v, max %v\n", fn.Returns, fn.Min, fn.Mean(),
+			fn.Max)
+		for _, bucket := range fn.Histogram {
+			bound := "> " + debugger.ProfileHistogramBounds[len(
+				debugger.ProfileHistogramBounds)-1].String()
+			if bucket.UpperBound != 0 {
+				bound = "<= " + bucket.UpperBound.String()
+			}
+			fmt.Fprintf(&text, "  %8s  %d\n", bound, bucket.Count)
+		}
+	}
+
+	if len(result.Unresolved) > 0 {
+		text.WriteString("\nCould not set breakpoints on:\n")
+		names := make([]string, 0, len(result.Unresolved))
+		for name := range result.Unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(&text, "  %s: %s\n", name,
+				result.Unresolved[name])
+		}
+	}
+
+	if len(result.Unmatched) > 0 {
+		text.WriteString("\nBreakpoint hits not attributed to a " +
+			"profiled function:\n")
+		names := make([]string, 0, len(result.Unmatched))
+		for name := range result.Unmatched {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(&text, "  %s: %d\n", name,
+				result.Unmatched[name])
+		}
+	}
+
+	return text.String()
+}
@@ -0,0 +1,777 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
+	"github.com/lightningnetwork/lnd/fn/v2"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDebugger is a debugger actor that creates in-memory sessions instead of
+// launching Delve.
+type fakeDebugger struct {
+	system *actor.ActorSystem
+
+	nextID  atomic.Int64
+	created atomic.Int64
+	closed  atomic.Int64
+
+	// launches receives the arguments of every launch request.
+	launches chan json.RawMessage
+
+	// stopOnConfigurationDone, if set, is the event sessions publish once
+	// configuration is done, as if the program stopped right away.
+	stopOnConfigurationDone atomic.Pointer[dap.StoppedEvent]
+
+	// createGate, if set, holds up creating sessions until it's closed,
+	// as starting Delve would.
+	createGate chan struct{}
+}
+
+// sessionReceive records launch requests before answering them like
+// fakeSessionReceive.
+func (d *fakeDebugger) sessionReceive(actorCtx context.Context,
+	msg *debugger.DAPRequest) fn.Result[*debugger.DAPResponse] {
+
+	if req, ok := msg.Request.(*dap.LaunchRequest); ok {
+		select {
+		case d.launches <- req.Arguments:
+		default:
+		}
+	}
+
+	return fakeSessionReceive(actorCtx, msg)
+}
+
+// Receive implements the actor Receive method for the fake debugger.
+func (d *fakeDebugger) Receive(actorCtx context.Context,
+	msg *debugger.DebuggerCmd) fn.Result[*debugger.DebuggerResp] {
+
+	switch cmd := msg.Cmd.(type) {
+	case *debugger.CreateSessionCmd:
+		if d.createGate != nil {
+			<-d.createGate
+		}
+
+		id := fmt.Sprintf("session-%d", d.nextID.Add(1))
+		key := actor.NewServiceKey[*debugger.DAPRequest,
+			*debugger.DAPResponse](id)
+		events := debugger.NewEventBus()
+		receive := func(actorCtx context.Context,
+			msg *debugger.DAPRequest) fn.Result[*debugger.DAPResponse] {
+
+			_, done := msg.Request.(*dap.ConfigurationDoneRequest)
+			if stop := d.stopOnConfigurationDone.Load(); done &&
+				stop != nil {
+
+				defer events.Publish(stop)
+			}
+
+			return d.sessionReceive(actorCtx, msg)
+		}
+		ref := actor.RegisterWithSystem(
+			d.system, id, key, actor.NewFunctionBehavior(receive),
+		)
+		d.created.Add(1)
+
+		return fn.Ok(&debugger.DebuggerResp{
+			Resp: &debugger.CreateSessionResp{
+				ID:      id,
+				Session: ref,
+				Events:  events,
+			},
+		})
+
+	case *debugger.CloseSessionCmd:
+		key := actor.NewServiceKey[*debugger.DAPRequest,
+			*debugger.DAPResponse](cmd.ID)
+		if key.UnregisterAll(d.system) != 1 {
+			return fn.Err[*debugger.DebuggerResp](
+				fmt.Errorf("unknown session: %s", cmd.ID))
+		}
+		d.closed.Add(1)
+
+		return fn.Ok(&debugger.DebuggerResp{
+			Resp: &debugger.CloseSessionResp{},
+		})
+
+	default:
+		return fn.Err[*debugger.DebuggerResp](
+			fmt.Errorf("unknown command type: %T", cmd))
+	}
+}
+
+// fakeSessionReceive answers the DAP requests used by the tests.
+func fakeSessionReceive(actorCtx context.Context,
+	msg *debugger.DAPRequest) fn.Result[*debugger.DAPResponse] {
+
+	var resp dap.Message
+	switch req := msg.Request.(type) {
+	case *dap.InitializeRequest:
+		resp = &dap.InitializeResponse{
+			Response: dap.Response{Success: true},
+		}
+
+	case *dap.LaunchRequest:
+		resp = &dap.LaunchResponse{Response: dap.Response{Success: true}}
+
+	case *dap.AttachRequest:
+		resp = &dap.AttachResponse{Response: dap.Response{Success: true}}
+
+	case *dap.SetBreakpointsRequest:
+		bps := make([]dap.Breakpoint, len(req.Arguments.Breakpoints))
+		for i, bp := range req.Arguments.Breakpoints {
+			bps[i] = dap.Breakpoint{
+				Id: i + 1, Verified: true, Line: bp.Line,
+			}
+		}
+		resp = &dap.SetBreakpointsResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.SetBreakpointsResponseBody{
+				Breakpoints: bps,
+			},
+		}
+
+	case *dap.SetFunctionBreakpointsRequest:
+		bps := make([]dap.Breakpoint, len(req.Arguments.Breakpoints))
+		for i := range bps {
+			bps[i] = dap.Breakpoint{Id: i + 1, Verified: true}
+		}
+		resp = &dap.SetFunctionBreakpointsResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.SetFunctionBreakpointsResponseBody{
+				Breakpoints: bps,
+			},
+		}
+
+	case *dap.SetExceptionBreakpointsRequest:
+		resp = &dap.SetExceptionBreakpointsResponse{
+			Response: dap.Response{Success: true},
+		}
+
+	case *dap.ConfigurationDoneRequest:
+		resp = &dap.ConfigurationDoneResponse{
+			Response: dap.Response{Success: true},
+		}
+
+	case *dap.ContinueRequest:
+		resp = &dap.ContinueResponse{
+			Response: dap.Response{Success: true},
+		}
+
+	case *dap.PauseRequest:
+		resp = &dap.PauseResponse{Response: dap.Response{Success: true}}
+
+	case *dap.ThreadsRequest:
+		resp = &dap.ThreadsResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.ThreadsResponseBody{
+				Threads: []dap.Thread{{Id: 1, Name: "main"}},
+			},
+		}
+
+	case *dap.StackTraceRequest:
+		resp = &dap.StackTraceResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.StackTraceResponseBody{
+				StackFrames: []dap.StackFrame{{
+					Id:     1000,
+					Name:   "main.main",
+					Line:   42,
+					Source: &dap.Source{Path: "/src/main.go"},
+				}},
+			},
+		}
+
+	case *dap.EvaluateRequest:
+		resp = &dap.EvaluateResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.EvaluateResponseBody{
+				Result: fmt.Sprintf("len(%s)",
+					req.Arguments.Expression),
+				Type: "int",
+			},
+		}
+
+		// Variables named req are structs with fields to expand.
+		if req.Arguments.Expression == "req" {
+			resp = &dap.EvaluateResponse{
+				Response: dap.Response{Success: true},
+				Body: dap.EvaluateResponseBody{
+					Result:             "http.Request {...}",
+					Type:               "*net/http.Request",
+					VariablesReference: 3,
+				},
+			}
+		}
+
+	case *dap.ScopesRequest:
+		resp = &dap.ScopesResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.ScopesResponseBody{
+				Scopes: []dap.Scope{
+					{Name: "Locals", VariablesReference: 1},
+					{
+						Name:               "Globals",
+						VariablesReference: 2,
+						Expensive:          true,
+					},
+				},
+			},
+		}
+
+	case *dap.VariablesRequest:
+		resp = &dap.VariablesResponse{
+			Response: dap.Response{Success: true},
+			Body: dap.VariablesResponseBody{
+				Variables: []dap.Variable{
+					{Name: "count", Value: "3", Type: "int"},
+				},
+			},
+		}
+
+	case *dap.DisconnectRequest:
+		resp = &dap.DisconnectResponse{
+			Response: dap.Response{Success: true},
+		}
+
+	default:
+		return fn.Err[*debugger.DAPResponse](
+			fmt.Errorf("unexpected request: %T", req))
+	}
+
+	return fn.Ok(&debugger.DAPResponse{Response: resp})
+}
+
+// fakeClient is an MCP client session used to attribute tool calls to
+// different clients.
+type fakeClient struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	level         atomic.Value
+}
+
+func (c *fakeClient) Initialize()       {}
+func (c *fakeClient) Initialized() bool { return true }
+func (c *fakeClient) SessionID() string { return c.id }
+
+func (c *fakeClient) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return c.notifications
+}
+
+func (c *fakeClient) SetLogLevel(level mcp.LoggingLevel) {
+	c.level.Store(level)
+}
+
+func (c *fakeClient) GetLogLevel() mcp.LoggingLevel {
+	level, ok := c.level.Load().(mcp.LoggingLevel)
+	if !ok {
+		return mcp.LoggingLevelError
+	}
+
+	return level
+}
+
+// nextNotification waits for the next notification with the given method sent
+// to the client, skipping any others.
+func (c *fakeClient) nextNotification(t *testing.T,
+	method string) mcp.JSONRPCNotification {
+
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case notification := <-c.notifications:
+			if notification.Method == method {
+				return notification
+			}
+
+		case <-timeout:
+			t.Fatalf("no %s notification", method)
+		}
+	}
+}
+
+// clientContext returns a context that attributes tool calls to the named
+// client.
+func clientContext(mds *MCPDebugServer, id string) context.Context {
+	return mds.server.WithContext(context.Background(), &fakeClient{
+		id:            id,
+		notifications: make(chan mcp.JSONRPCNotification, 10),
+	})
+}
+
+// newTestServer creates an MCP server backed by a fake debugger. Session
+// limits are disabled unless overridden by the given options.
+func newTestServer(t *testing.T,
+	opts ...ServerOption) (*MCPDebugServer, *fakeDebugger) {
+	t.Helper()
+
+	system := actor.NewActorSystem()
+	t.Cleanup(func() { _ = system.Shutdown() })
+
+	fake := &fakeDebugger{
+		system:   system,
+		launches: make(chan json.RawMessage, 10),
+	}
+	key := actor.NewServiceKey[*debugger.DebuggerCmd,
+		*debugger.DebuggerResp]("debugger")
+	ref := actor.RegisterWithSystem(
+		system, "debugger", key, actor.NewFunctionBehavior(fake.Receive),
+	)
+
+	opts = append([]ServerOption{WithSessionLimits(SessionLimits{})},
+		opts...)
+	mds := NewMCPDebugServer(system, ref, opts...)
+	t.Cleanup(mds.Stop)
+
+	return mds, fake
+}
+
+// callTool dispatches a tool call through the MCP server the same way a
+// client request would be. It is safe to call from any goroutine.
+func callTool(ctx context.Context, mds *MCPDebugServer, name string,
+	args map[string]any) (*mcp.CallToolResult, error) {
+
+	msg, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]any{
+			"name":      name,
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := mds.server.HandleMessage(ctx, msg)
+	rpcResp, ok := resp.(mcp.JSONRPCResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response: %#v", resp)
+	}
+
+	result, ok := rpcResp.Result.(mcp.CallToolResult)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result: %#v",
+			rpcResp.Result)
+	}
+
+	return &result, nil
+}
+
+// TestConcurrentSessionLifecycle creates, uses and closes many sessions from
+// concurrent tool calls while the session list is being read, as the TUI
+// does. It is meant to be run with the race detector.
+func TestConcurrentSessionLifecycle(t *testing.T) {
+	mds, fake := newTestServer(t)
+	ctx := context.Background()
+
+	const numSessions = 20
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = mds.GetSessions()
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < numSessions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			id := fmt.Sprintf("agent-%d", i)
+			steps := []struct {
+				tool string
+				args map[string]any
+			}{
+				{"create_debug_session", map[string]any{
+					"session_id": id,
+				}},
+				{"initialize_session", map[string]any{
+					"session_id": id,
+					"client_id":  "test",
+				}},
+				{"launch_program", map[string]any{
+					"session_id": id,
+					"program":    "/usr/bin/true",
+				}},
+				{"get_threads", map[string]any{
+					"session_id": id,
+				}},
+				{"close_debug_session", map[string]any{
+					"session_id": id,
+				}},
+			}
+			for _, step := range steps {
+				result, err := callTool(ctx, mds, step.tool, step.args)
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.False(t, result.IsError, "%s failed: %v",
+					step.tool, result.Content)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	close(done)
+	readers.Wait()
+
+	require.Empty(t, mds.GetSessions())
+	require.EqualValues(t, numSessions, fake.created.Load())
+	require.EqualValues(t, numSessions, fake.closed.Load())
+}
+
+// TestConcurrentCreateSameID tests that only one of several concurrent
+// creates for the same session ID succeeds.
+func TestConcurrentCreateSameID(t *testing.T) {
+	mds, fake := newTestServer(t)
+	ctx := context.Background()
+
+	const numCallers = 10
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+	)
+	for i := 0; i < numCallers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := callTool(ctx, mds, "create_debug_session",
+				map[string]any{"session_id": "shared"})
+			if assert.NoError(t, err) && !result.IsError {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 1, succeeded.Load())
+	require.EqualValues(t, 1, fake.created.Load())
+	require.Len(t, mds.GetSessions(), 1)
+}
+
+// TestConcurrentCloseSameID tests that a session closed by several callers at
+// once is only torn down once, and that later calls report it as missing.
+func TestConcurrentCloseSameID(t *testing.T) {
+	mds, fake := newTestServer(t)
+	ctx := context.Background()
+
+	result, err := callTool(ctx, mds, "create_debug_session",
+		map[string]any{"session_id": "shared"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	const numCallers = 10
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+	)
+	for i := 0; i < numCallers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := callTool(ctx, mds, "close_debug_session",
+				map[string]any{"session_id": "shared"})
+			if assert.NoError(t, err) && !result.IsError {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.EqualValues(t, 1, succeeded.Load())
+	require.EqualValues(t, 1, fake.closed.Load())
+
+	result, err = callTool(ctx, mds, "get_threads",
+		map[string]any{"session_id": "shared"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
+
+// TestCancelledSessionCalls tests that sessions are still closed when the
+// client gives up on creating or closing them, so that their Delve servers
+// don't leak.
+func TestCancelledSessionCalls(t *testing.T) {
+	mds, fake := newTestServer(t)
+	fake.createGate = make(chan struct{})
+
+	// The client gives up while Delve is starting.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	result, err := callTool(ctx, mds, "create_debug_session",
+		map[string]any{"session_id": "abandoned"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	close(fake.createGate)
+	require.Eventually(t, func() bool {
+		return fake.created.Load() == 1 && fake.closed.Load() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Empty(t, mds.GetSessions())
+
+	// A close whose context is already done still tears the session
+	// down.
+	result, err = callTool(context.Background(), mds,
+		"create_debug_session", map[string]any{"session_id": "closed"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	result, err = callTool(ctx, mds, "close_debug_session",
+		map[string]any{"session_id": "closed"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.EqualValues(t, 2, fake.closed.Load())
+}
+
+// TestSessionLimits tests that the server-wide and per-client session limits
+// are enforced and that closing a session frees up its slot.
+func TestSessionLimits(t *testing.T) {
+	mds, fake := newTestServer(t, WithSessionLimits(SessionLimits{
+		MaxSessions:          3,
+		MaxSessionsPerClient: 2,
+	}))
+
+	alice := clientContext(mds, "alice")
+	bob := clientContext(mds, "bob")
+
+	create := func(ctx context.Context, id string) *mcp.CallToolResult {
+		result, err := callTool(ctx, mds, "create_debug_session",
+			map[string]any{"session_id": id})
+		require.NoError(t, err)
+
+		return result
+	}
+
+	require.False(t, create(alice, "a1").IsError)
+	require.False(t, create(alice, "a2").IsError)
+
+	// Alice has used up her share, but Bob can still create one.
+	result := create(alice, "a3")
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text,
+		"too many debug sessions for this client")
+
+	require.False(t, create(bob, "b1").IsError)
+
+	// The server is now full.
+	result = create(bob, "b2")
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text,
+		"limit is 3")
+
+	// Closing one of Alice's sessions makes room for her again.
+	result, err := callTool(alice, mds, "close_debug_session",
+		map[string]any{"session_id": "a1"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.False(t, create(alice, "a3").IsError)
+
+	require.EqualValues(t, 4, fake.created.Load())
+}
+
+// TestIdleSessionReaping tests that sessions without tool calls are closed
+// after the idle timeout while sessions in use are kept.
+func TestIdleSessionReaping(t *testing.T) {
+	mds, fake := newTestServer(t, WithSessionLimits(SessionLimits{
+		IdleTimeout: 200 * time.Millisecond,
+	}))
+	ctx := context.Background()
+
+	for _, id := range []string{"idle", "busy"} {
+		result, err := callTool(ctx, mds, "create_debug_session",
+			map[string]any{"session_id": id})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+	}
+
+	// Keep one session busy by calling a tool on it periodically.
+	deadline := time.Now().Add(600 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		result, err := callTool(ctx, mds, "get_breakpoints",
+			map[string]any{"session_id": "busy"})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	require.Eventually(t, func() bool {
+		return fake.closed.Load() == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	sessions := mds.GetSessions()
+	require.Len(t, sessions, 1)
+	require.Contains(t, sessions, "busy")
+}
+
+// TestBuildFlags tests that the server's build flags are used for every build
+// but not recorded with the session's launch configuration.
+func TestBuildFlags(t *testing.T) {
+	mds, fake := newTestServer(t, WithBuildFlags([]string{"-tags", "ci"}))
+
+	requireSession(t, mds, "flags")
+	requireTool(t, mds, "launch_program", map[string]any{
+		"session_id":  "flags",
+		"program":     "./cmd/app",
+		"mode":        "debug",
+		"build_flags": []string{"-race"},
+	})
+
+	var args struct {
+		BuildFlags []string `json:"buildFlags"`
+	}
+	require.NoError(t, json.Unmarshal(<-fake.launches, &args))
+	require.Equal(t, []string{
+		"-gcflags", "all=-N -l", "-tags", "ci", "-race",
+	}, args.BuildFlags)
+
+	sess, ok := mds.sessions.get("flags")
+	require.True(t, ok)
+	require.Equal(t, []string{"-race"}, sess.launchConfig().BuildFlags)
+}
+
+// TestBuildOutput tests that programs are built into a private directory of
+// each server, so that servers using the same session ID don't overwrite each
+// other's binaries, and that the directory is removed on stop.
+func TestBuildOutput(t *testing.T) {
+	output := func(mds *MCPDebugServer, fake *fakeDebugger) string {
+		requireSession(t, mds, "test")
+		requireTool(t, mds, "launch_program", map[string]any{
+			"session_id": "test",
+			"program":    "./cmd/app",
+			"mode":       "debug",
+		})
+
+		var args struct {
+			Output string `json:"output"`
+		}
+		require.NoError(t, json.Unmarshal(<-fake.launches, &args))
+
+		return args.Output
+	}
+
+	first, firstFake := newTestServer(t)
+	second, secondFake := newTestServer(t)
+
+	firstOutput := output(first, firstFake)
+	secondOutput := output(second, secondFake)
+	require.NotEqual(t, firstOutput, secondOutput)
+	require.Equal(t, first.buildDir, filepath.Dir(firstOutput))
+	require.Equal(t, second.buildDir, filepath.Dir(secondOutput))
+
+	info, err := os.Stat(first.buildDir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	first.Stop()
+	_, err = os.Stat(first.buildDir)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// lockedBuffer is a buffer that is safe to write to from several goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// records decodes the JSON log records written so far.
+func (b *lockedBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(b.buf.Bytes()))
+	for scanner.Scan() {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// TestToolCallLogging tests that tool calls are logged with their tool name
+// and session ID, and that failed calls are logged as warnings.
+func TestToolCallLogging(t *testing.T) {
+	var buf lockedBuffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(&buf, logging.Options{
+		Level:  slog.LevelDebug,
+		Format: logging.FormatJSON,
+	})))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	mds, _ := newTestServer(t)
+
+	requireTool(t, mds, "create_debug_session", map[string]any{
+		"session_id": "logged",
+	})
+	result, err := callTool(context.Background(), mds, "list_watches",
+		map[string]any{"session_id": "missing"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	var calls []map[string]any
+	for _, record := range buf.records(t) {
+		if _, ok := record[logging.KeyTool]; ok {
+			calls = append(calls, record)
+		}
+	}
+	require.Len(t, calls, 2)
+
+	require.Equal(t, "DEBUG", calls[0]["level"])
+	require.Equal(t, "create_debug_session", calls[0][logging.KeyTool])
+	require.Equal(t, "logged", calls[0][logging.KeySession])
+	require.Equal(t, "mcp", calls[0][logging.KeyComponent])
+
+	require.Equal(t, "WARN", calls[1]["level"])
+	require.Equal(t, "list_watches", calls[1][logging.KeyTool])
+	require.Equal(t, "missing", calls[1][logging.KeySession])
+	require.Contains(t, calls[1]["error"], "missing")
+}
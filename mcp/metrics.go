@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/roasbeef/mcp-debug/internal/metrics"
+)
+
+// measureToolCalls is a tool handler middleware that counts tool calls by
+// outcome and observes their duration.
+func (mds *MCPDebugServer) measureToolCalls(
+	next server.ToolHandlerFunc) server.ToolHandlerFunc {
+
+	return func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		start := time.Now()
+		result, err := next(ctx, request)
+
+		tool := request.Params.Name
+		metrics.ToolCallDuration.Observe(
+			time.Since(start).Seconds(), tool,
+		)
+		metrics.ToolCalls.Inc(tool, toolOutcome(result, err))
+
+		return result, err
+	}
+}
+
+// toolOutcome classifies the result of a tool call as AuditOK, AuditError or
+// AuditFailed.
+func toolOutcome(result *mcp.CallToolResult, err error) string {
+	switch {
+	case err != nil:
+		return AuditFailed
+
+	case result != nil && result.IsError:
+		return AuditError
+
+	default:
+		return AuditOK
+	}
+}
@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roasbeef/mcp-debug/internal/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMetrics tests that tool calls and open sessions are counted. The
+// metrics are shared by all servers in the process, so only changes are
+// checked.
+func TestMetrics(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	calls := func(tool, outcome string) float64 {
+		return metrics.ToolCalls.Sum(map[string]string{
+			"tool": tool, "outcome": outcome,
+		})
+	}
+	created := calls("create_debug_session", AuditOK)
+	failed := calls("list_watches", AuditError)
+	observed := metrics.ToolCallDuration.Count("create_debug_session")
+	sessions := metrics.ActiveSessions.Value()
+
+	requireTool(t, mds, "create_debug_session", map[string]any{
+		"session_id": "measured",
+	})
+	require.Equal(t, sessions+1, metrics.ActiveSessions.Value())
+
+	result, err := callTool(context.Background(), mds, "list_watches",
+		map[string]any{"session_id": "missing"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	requireTool(t, mds, "close_debug_session", map[string]any{
+		"session_id": "measured",
+	})
+	require.Equal(t, sessions, metrics.ActiveSessions.Value())
+
+	require.Equal(t, created+1, calls("create_debug_session", AuditOK))
+	require.Equal(t, failed+1, calls("list_watches", AuditError))
+	require.Equal(t, observed+1,
+		metrics.ToolCallDuration.Count("create_debug_session"))
+}
@@ -0,0 +1,300 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/roasbeef/mcp-debug/debugger"
+)
+
+// Policy restricts what MCP clients may do through the server, so that it can
+// be given to autonomous agents without handing them a shell. The zero value
+// allows everything.
+type Policy struct {
+	// AllowedDirs, if not empty, are the only directories programs may be
+	// launched from or run in. Binaries and launch.json files outside
+	// them can't be read either.
+	AllowedDirs []string
+
+	// DisableAttach prevents attaching to running processes.
+	DisableAttach bool
+
+	// ReadOnly prevents expressions from calling functions in the debugged
+	// program or running Delve commands, which could change its state.
+	ReadOnly bool
+
+	// DeniedEnv lists environment variables that launched programs may
+	// not be given. Entries may be patterns such as 'LD_*'.
+	DeniedEnv []string
+}
+
+// WithPolicy restricts what clients may do through the server.
+func WithPolicy(policy Policy) ServerOption {
+	return func(mds *MCPDebugServer) {
+		mds.policy = policy
+	}
+}
+
+// errAttachDisabled is returned when attaching is disabled by the policy.
+var errAttachDisabled = errors.New("attaching to processes is disabled by " +
+	"the server's policy")
+
+// sideEffectExpr matches expressions that Delve doesn't just evaluate: function
+// calls injected into the program and Delve commands.
+var sideEffectExpr = regexp.MustCompile(`^\s*(call|dlv)\s`)
+
+// pathArgs are the tool arguments naming files or directories that the server
+// reads or runs.
+var pathArgs = []string{"program", "working_dir", "binary", "workspace_folder"}
+
+// restrictedBuildFlags run other programs during the build, which would get
+// around the directory allowlist.
+var restrictedBuildFlags = []string{"-toolexec", "-exec"}
+
+// restrictedLinkerFlags are linker flags, passed in -ldflags, that run other
+// programs during the build.
+var restrictedLinkerFlags = []string{"-extld"}
+
+// enforcePolicy is a tool handler middleware that rejects tool calls the
+// policy doesn't allow before they reach the handler.
+func (mds *MCPDebugServer) enforcePolicy(
+	next server.ToolHandlerFunc) server.ToolHandlerFunc {
+
+	return func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		err := mds.policy.checkToolCall(
+			request.Params.Name, request.GetArguments(),
+		)
+		if err != nil {
+			return errorResult("Not allowed: %v", err), nil
+		}
+
+		return next(ctx, request)
+	}
+}
+
+// checkToolCall checks the arguments of a tool call against the policy. Launch
+// configurations are checked again once they're resolved, see checkLaunch.
+func (p *Policy) checkToolCall(tool string, args map[string]any) error {
+	if tool == "attach_to_process" && p.DisableAttach {
+		return errAttachDisabled
+	}
+
+	if p.ReadOnly {
+		var expressions []string
+		for _, key := range []string{
+			"expression", "condition", "path",
+		} {
+			if expr, ok := args[key].(string); ok {
+				expressions = append(expressions, expr)
+			}
+		}
+		for _, key := range []string{"expressions", "watches"} {
+			list, _ := args[key].([]any)
+			for _, expr := range list {
+				if expr, ok := expr.(string); ok {
+					expressions = append(expressions, expr)
+				}
+			}
+		}
+
+		for _, expr := range expressions {
+			if err := p.checkExpression(expr); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range pathArgs {
+		if path, ok := args[key].(string); ok && path != "" {
+			if err := p.checkPath(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The launch file is relative to the workspace folder.
+	if file, ok := args["launch_file"].(string); ok && file != "" {
+		if folder, ok := args["workspace_folder"].(string); ok &&
+			!filepath.IsAbs(file) {
+
+			file = filepath.Join(folder, file)
+		}
+		if err := p.checkPath(file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkExpression rejects expressions with side effects in read-only mode.
+func (p *Policy) checkExpression(expr string) error {
+	if p.ReadOnly && sideEffectExpr.MatchString(expr) {
+		return fmt.Errorf("%q has side effects, which the server's "+
+			"read-only policy doesn't allow", expr)
+	}
+
+	return nil
+}
+
+// checkLaunch checks a resolved launch configuration against the policy.
+func (p *Policy) checkLaunch(config debugger.LaunchConfig) error {
+	if err := p.checkPath(config.Program); err != nil {
+		return err
+	}
+	if config.WorkingDir != "" {
+		if err := p.checkPath(config.WorkingDir); err != nil {
+			return err
+		}
+	}
+
+	for _, env := range config.Env {
+		name, _, _ := strings.Cut(env, "=")
+		for _, pattern := range p.DeniedEnv {
+			if match, _ := path.Match(pattern, name); match {
+				return fmt.Errorf("setting %s is not allowed by "+
+					"the server's policy", name)
+			}
+		}
+	}
+
+	if len(p.AllowedDirs) > 0 {
+		if flag := restrictedBuildFlag(config.BuildFlags); flag != "" {
+			return fmt.Errorf("build flag %s is not allowed by the "+
+				"server's policy", flag)
+		}
+	}
+
+	return nil
+}
+
+// restrictedBuildFlag returns the first restricted flag among build flags, or
+// an empty string if there is none. Build flags are matched by name, and
+// linker flags by name among the tokens of -ldflags, whose value may follow
+// an = or be the next flag. Flags that merely start alike, such as
+// -extldflags, are allowed.
+func restrictedBuildFlag(flags []string) string {
+	for i := 0; i < len(flags); i++ {
+		name, value, hasValue := strings.Cut(flagName(flags[i]), "=")
+		if slices.Contains(restrictedBuildFlags, "-"+name) {
+			return "-" + name
+		}
+		if name != "ldflags" {
+			continue
+		}
+
+		if !hasValue {
+			if i+1 == len(flags) {
+				continue
+			}
+			i++
+			value = flags[i]
+		}
+
+		// The linker flags may be quoted and prefixed by a package
+		// pattern, as in all=-s.
+		value = strings.Trim(value, `'"`)
+		if pattern, rest, ok := strings.Cut(value, "="); ok &&
+			!strings.HasPrefix(pattern, "-") {
+
+			value = rest
+		}
+
+		for _, token := range strings.Fields(value) {
+			token = strings.Trim(token, `'"`)
+			name, _, _ := strings.Cut(flagName(token), "=")
+			if slices.Contains(restrictedLinkerFlags, "-"+name) {
+				return "-ldflags -" + name
+			}
+		}
+	}
+
+	return ""
+}
+
+// flagName returns a command line flag without its leading dashes, or an
+// empty string if it isn't a flag.
+func flagName(flag string) string {
+	if !strings.HasPrefix(flag, "-") {
+		return ""
+	}
+
+	return strings.TrimLeft(flag, "-")
+}
+
+// checkAttach checks whether attaching is allowed.
+func (p *Policy) checkAttach() error {
+	if p.DisableAttach {
+		return errAttachDisabled
+	}
+
+	return nil
+}
+
+// checkPath checks that a path is inside one of the allowed directories, after
+// resolving symbolic links. Relative paths are relative to the server's working
+// directory, as they are for Delve.
+func (p *Policy) checkPath(path string) error {
+	if len(p.AllowedDirs) == 0 {
+		return nil
+	}
+
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range p.AllowedDirs {
+		allowed, err := resolvePath(dir)
+		if err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(allowed, resolved)
+		if err == nil && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is outside the directories allowed by the "+
+		"server's policy", path)
+}
+
+// resolvePath returns the absolute path with symbolic links resolved. Parts of
+// the path that don't exist yet are kept as they are.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append(
+				[]string{resolved}, missing...,
+			)...), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) ||
+			dir == filepath.Dir(dir) {
+
+			return "", err
+		}
+
+		missing = append([]string{filepath.Base(dir)}, missing...)
+	}
+}
@@ -0,0 +1,150 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// requireDenied calls a tool and requires it to be rejected for the given
+// reason.
+func requireDenied(t *testing.T, mds *MCPDebugServer, name string,
+	args map[string]any, reason string) {
+
+	t.Helper()
+
+	result, err := callTool(context.Background(), mds, name, args)
+	require.NoError(t, err)
+	require.True(t, result.IsError, "%s wasn't rejected", name)
+
+	text := result.Content[0].(mcp.TextContent).Text
+	require.Contains(t, text, reason)
+}
+
+// TestPolicy tests that tool calls the policy doesn't allow are rejected before
+// they reach the debugger.
+func TestPolicy(t *testing.T) {
+	allowed := t.TempDir()
+	outside := t.TempDir()
+
+	// A link inside the allowed directory that leads out of it.
+	escape := filepath.Join(allowed, "escape")
+	require.NoError(t, os.Symlink(outside, escape))
+
+	mds, fake := newTestServer(t, WithPolicy(Policy{
+		AllowedDirs:   []string{allowed},
+		DisableAttach: true,
+		ReadOnly:      true,
+		DeniedEnv:     []string{"LD_*"},
+	}))
+
+	for _, id := range []string{"inside", "denied"} {
+		requireSession(t, mds, id)
+	}
+
+	launch := func(id, program string, extra map[string]any) map[string]any {
+		args := map[string]any{
+			"session_id": id,
+			"program":    program,
+			"mode":       "debug",
+		}
+		for key, value := range extra {
+			args[key] = value
+		}
+
+		return args
+	}
+
+	requireDenied(t, mds, "launch_program", launch(
+		"denied", filepath.Join(outside, "app"), nil,
+	), "outside the directories")
+	requireDenied(t, mds, "launch_program", launch(
+		"denied", filepath.Join(escape, "app"), nil,
+	), "outside the directories")
+	requireDenied(t, mds, "launch_program", launch(
+		"denied", filepath.Join(allowed, "app"), map[string]any{
+			"working_dir": outside,
+		},
+	), "outside the directories")
+	requireDenied(t, mds, "launch_program", launch(
+		"denied", filepath.Join(allowed, "app"), map[string]any{
+			"env": []string{"LD_PRELOAD=/tmp/evil.so"},
+		},
+	), "LD_PRELOAD")
+	requireDenied(t, mds, "launch_program", launch(
+		"denied", filepath.Join(allowed, "app"), map[string]any{
+			"build_flags": []string{"-ldflags=-extld=sh"},
+		},
+	), "-extld")
+	requireDenied(t, mds, "launch_program", launch(
+		"denied", filepath.Join(allowed, "app"), map[string]any{
+			"build_flags": []string{"-toolexec", "sh"},
+		},
+	), "-toolexec")
+
+	requireDenied(t, mds, "attach_to_process", map[string]any{
+		"session_id": "denied",
+		"process_id": 1234,
+	}, "attaching")
+
+	requireDenied(t, mds, "evaluate_expression", map[string]any{
+		"session_id": "inside",
+		"expression": "call os.Exit(1)",
+		"frame_id":   1,
+	}, "read-only")
+	requireDenied(t, mds, "get_variables", map[string]any{
+		"session_id": "inside",
+		"path":       "call os.Exit(1)",
+	}, "read-only")
+	requireDenied(t, mds, "add_watch", map[string]any{
+		"session_id":  "inside",
+		"expressions": []string{"x", "dlv config max-string-len 1"},
+	}, "read-only")
+
+	// Nothing that was rejected reached the debugger.
+	require.Empty(t, fake.launches)
+
+	requireTool(t, mds, "launch_program", launch(
+		"inside", filepath.Join(allowed, "app"), map[string]any{
+			"working_dir": allowed,
+			"env":         []string{"GOTRACEBACK=all"},
+			"build_flags": []string{"-ldflags=-extldflags=-static"},
+		},
+	))
+	require.Len(t, fake.launches, 1)
+
+	requireTool(t, mds, "add_watch", map[string]any{
+		"session_id":  "inside",
+		"expressions": []string{"len(called)"},
+	})
+}
+
+// TestRestrictedBuildFlag tests that flags running other programs are found
+// by name, without rejecting flags that merely contain their names.
+func TestRestrictedBuildFlag(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{[]string{"-ldflags=-extldflags=-static"}, ""},
+		{[]string{"-ldflags", "-s -w -extldflags '-static'"}, ""},
+		{[]string{"-tags", "exec"}, ""},
+		{[]string{"-ldflags", "-toolexec"}, ""},
+		{[]string{"-gcflags", "all=-N -l"}, ""},
+		{[]string{"-toolexec=/tmp/x"}, "-toolexec"},
+		{[]string{"-tags", "x", "--exec", "sh"}, "-exec"},
+		{[]string{"-ldflags=-extld=sh"}, "-ldflags -extld"},
+		{[]string{"-ldflags", "-s -extld sh"}, "-ldflags -extld"},
+		{[]string{"-ldflags=all=-extld=sh"}, "-ldflags -extld"},
+		{[]string{`-ldflags='-extld=sh'`}, "-ldflags -extld"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.want, restrictedBuildFlag(test.flags),
+			"%q", test.flags)
+	}
+}
@@ -0,0 +1,324 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// registerPrompts registers the prompts that walk an agent through common
+// debugging workflows.
+func (mds *MCPDebugServer) registerPrompts() {
+	mds.registerInvestigatePanicPrompt()
+	mds.registerStuckGoroutinePrompt()
+	mds.registerBisectValuePrompt()
+}
+
+// promptTarget is the program a workflow prompt debugs.
+type promptTarget struct {
+	// sessionID is the session the prompt tells the agent to create.
+	sessionID string
+
+	// program is the package, file or binary to launch.
+	program string
+
+	// test is the test to run, if the program is a test package.
+	test string
+}
+
+// newPromptTarget reads the target of a workflow prompt from its arguments.
+// The program is read from the given argument, and the session ID defaults to
+// fallback.
+func newPromptTarget(args map[string]string, programArg,
+	fallback string) (promptTarget, error) {
+
+	target := promptTarget{
+		sessionID: args["session_id"],
+		program:   args[programArg],
+		test:      args["test"],
+	}
+	if target.program == "" {
+		return target, fmt.Errorf("%s is required", programArg)
+	}
+	if target.sessionID == "" {
+		target.sessionID = fallback
+	}
+
+	return target, nil
+}
+
+// writeSetup writes the steps that create a session and launch the target up
+// to the point where breakpoints can be set, numbering them from 1. It returns
+// the number of the next step.
+func (p promptTarget) writeSetup(text *strings.Builder) int {
+	fmt.Fprintf(text, "1. Call create_debug_session with session_id %q.\n",
+		p.sessionID)
+	fmt.Fprintf(text, "2. Call initialize_session with session_id %q and "+
+		"a client_id naming yourself. Launching before the session "+
+		"is initialized fails.\n", p.sessionID)
+
+	if p.test != "" {
+		fmt.Fprintf(text, "3. Call launch_program with program %q, "+
+			"mode \"test\" and args [\"-test.run\", \"^%s$\"] so "+
+			"only that test runs.\n", p.program, p.test)
+	} else {
+		fmt.Fprintf(text, "3. Call launch_program with program %q.\n",
+			p.program)
+	}
+
+	return 4
+}
+
+// writeStart writes the step that starts the launched program.
+func (p promptTarget) writeStart(text *strings.Builder, step int) int {
+	fmt.Fprintf(text, "%d. Call configuration_done. The program only "+
+		"starts running now, so every breakpoint must be set before "+
+		"this call. Don't call continue_execution to start it.\n", step)
+
+	return step + 1
+}
+
+// writeCleanup writes the closing notes shared by all workflow prompts.
+func (p promptTarget) writeCleanup(text *strings.Builder) {
+	fmt.Fprintf(text, "\nStops, output and the exit of the program are "+
+		"also sent as notifications for session %q. Frame IDs are "+
+		"only valid until the program resumes, so after a continue "+
+		"or step address frames by thread_id and depth instead. When "+
+		"you are done, call close_debug_session with session_id %q.",
+		p.sessionID, p.sessionID)
+}
+
+// promptResult wraps the text of a workflow prompt in a prompt result.
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
+
+// registerInvestigatePanicPrompt registers the prompt for investigating a
+// panic in a test.
+func (mds *MCPDebugServer) registerInvestigatePanicPrompt() {
+	prompt := mcp.NewPrompt("investigate_panic",
+		mcp.WithPromptDescription("Investigate a panic in a Go test: run the test under the debugger, stop at the panic and inspect the state that caused it"),
+		mcp.WithArgument("package", mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Directory of the package containing the test, e.g. './internal/store'")),
+		mcp.WithArgument("test", mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Name of the panicking test, e.g. 'TestStoreGet'")),
+		mcp.WithArgument("session_id",
+			mcp.ArgumentDescription("Session identifier to use (default: 'panic')")),
+	)
+
+	mds.server.AddPrompt(prompt, func(ctx context.Context,
+		request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+
+		target, err := newPromptTarget(
+			request.Params.Arguments, "package", "panic",
+		)
+		if err != nil {
+			return nil, err
+		}
+		if target.test == "" {
+			return nil, fmt.Errorf("test is required")
+		}
+
+		var text strings.Builder
+		fmt.Fprintf(&text, "Find the cause of the panic in %s of package "+
+			"%s using the debug tools, in this order:\n\n", target.test,
+			target.program)
+
+		step := target.writeSetup(&text)
+		fmt.Fprintf(&text, "%d. Optionally call set_exception_breakpoints "+
+			"with filters [\"panic\"]. Delve stops on unrecovered "+
+			"panics anyway, so no breakpoint is needed to catch "+
+			"it.\n", step)
+		step = target.writeStart(&text, step+1)
+		fmt.Fprintf(&text, "%d. Wait for the stop notification, or call "+
+			"get_threads and look for the stopped goroutine. If the "+
+			"program exits instead, the test didn't panic under the "+
+			"debugger; report that.\n", step)
+		fmt.Fprintf(&text, "%d. Call get_stack_frames for the stopped "+
+			"thread. Skip the runtime frames (runtime.gopanic, "+
+			"runtime.panicmem and the like) and find the first frame "+
+			"in the package's own code.\n", step+1)
+		fmt.Fprintf(&text, "%d. Call get_variables with that frame's depth "+
+			"and use evaluate_expression to check the values the "+
+			"panicking line uses, e.g. nil pointers, map keys or "+
+			"slice indexes and lengths.\n", step+2)
+		fmt.Fprintf(&text, "%d. Walk up the stack with get_variables on "+
+			"the caller frames until you find where the bad value "+
+			"came from.\n", step+3)
+		fmt.Fprintf(&text, "%d. If the bad value was produced earlier, "+
+			"close the session, start over and set breakpoints with "+
+			"set_breakpoints on the lines that produce it before "+
+			"configuration_done.\n", step+4)
+
+		target.writeCleanup(&text)
+		text.WriteString("\n\nReport the panic, the line that caused " +
+			"it and the values that explain it.")
+
+		return promptResult(
+			fmt.Sprintf("Investigate the panic in %s", target.test),
+			text.String(),
+		), nil
+	})
+}
+
+// registerStuckGoroutinePrompt registers the prompt for finding out why a
+// goroutine doesn't make progress.
+func (mds *MCPDebugServer) registerStuckGoroutinePrompt() {
+	prompt := mcp.NewPrompt("find_stuck_goroutine",
+		mcp.WithPromptDescription("Find out why a goroutine is stuck: pause the hung program, find the blocked goroutines and what they are waiting on"),
+		mcp.WithArgument("program", mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Package directory, Go file or binary of the program that hangs")),
+		mcp.WithArgument("test",
+			mcp.ArgumentDescription("Name of the test that hangs, if the program is a test package")),
+		mcp.WithArgument("function",
+			mcp.ArgumentDescription("Fully qualified function the stuck goroutine is expected to run, e.g. 'main.(*Worker).process'")),
+		mcp.WithArgument("session_id",
+			mcp.ArgumentDescription("Session identifier to use (default: 'stuck')")),
+	)
+
+	mds.server.AddPrompt(prompt, func(ctx context.Context,
+		request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+
+		target, err := newPromptTarget(
+			request.Params.Arguments, "program", "stuck",
+		)
+		if err != nil {
+			return nil, err
+		}
+		function := request.Params.Arguments["function"]
+
+		var text strings.Builder
+		fmt.Fprintf(&text, "Find out why a goroutine in %s is stuck "+
+			"using the debug tools, in this order:\n\n",
+			target.program)
+
+		step := target.writeSetup(&text)
+		step = target.writeStart(&text, step)
+		fmt.Fprintf(&text, "%d. Give the program time to reach the hang, "+
+			"then call get_threads and pause_execution with any of "+
+			"the thread IDs. Pausing stops all goroutines.\n", step)
+		fmt.Fprintf(&text, "%d. Call get_threads again to list the "+
+			"goroutines and call get_stack_frames for each one that "+
+			"runs the program's own code.\n", step+1)
+		if function != "" {
+			fmt.Fprintf(&text, "%d. Look for the goroutine with %s "+
+				"on its stack. If there is none, it has already "+
+				"returned or never started; use search_symbols to "+
+				"check the name and find its callers.\n", step+2,
+				function)
+		} else {
+			fmt.Fprintf(&text, "%d. Look for goroutines whose top "+
+				"frames are runtime.gopark under "+
+				"runtime.chanrecv, runtime.chansend, "+
+				"runtime.selectgo, sync.(*Mutex).Lock, "+
+				"sync.(*WaitGroup).Wait or sync.(*Cond).Wait.\n",
+				step+2)
+		}
+		fmt.Fprintf(&text, "%d. For the blocked goroutine, call "+
+			"get_variables on the first frame in the program's own "+
+			"code to find the channel, mutex or wait group it is "+
+			"waiting on, and evaluate_expression to inspect it, e.g. "+
+			"len(ch) and cap(ch).\n", step+3)
+		fmt.Fprintf(&text, "%d. Find the goroutine that should send, "+
+			"receive, unlock or call Done, and check whether it is "+
+			"blocked itself, which indicates a deadlock, or has "+
+			"already exited.\n", step+4)
+
+		target.writeCleanup(&text)
+		text.WriteString("\n\nReport which goroutine is stuck, what " +
+			"it is waiting on and why nothing will wake it up.")
+
+		return promptResult(
+			fmt.Sprintf("Find the stuck goroutine in %s",
+				target.program),
+			text.String(),
+		), nil
+	})
+}
+
+// registerBisectValuePrompt registers the prompt for finding where a value
+// goes wrong.
+func (mds *MCPDebugServer) registerBisectValuePrompt() {
+	prompt := mcp.NewPrompt("bisect_bad_value",
+		mcp.WithPromptDescription("Narrow down where a variable first gets a wrong value by stepping through a function and checking the value at every line"),
+		mcp.WithArgument("program", mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Package directory, Go file or binary to debug")),
+		mcp.WithArgument("function", mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Fully qualified function in which the value goes wrong, e.g. 'main.computeTotal'")),
+		mcp.WithArgument("expression", mcp.RequiredArgument(),
+			mcp.ArgumentDescription("Go expression for the value, e.g. 'total' or 'len(s.items)'")),
+		mcp.WithArgument("condition",
+			mcp.ArgumentDescription("Go expression that is true once the value is wrong, e.g. 'total < 0'")),
+		mcp.WithArgument("test",
+			mcp.ArgumentDescription("Name of the test to run, if the program is a test package")),
+		mcp.WithArgument("session_id",
+			mcp.ArgumentDescription("Session identifier to use (default: 'bisect')")),
+	)
+
+	mds.server.AddPrompt(prompt, func(ctx context.Context,
+		request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+
+		args := request.Params.Arguments
+		target, err := newPromptTarget(args, "program", "bisect")
+		if err != nil {
+			return nil, err
+		}
+
+		function, expression := args["function"], args["expression"]
+		if function == "" || expression == "" {
+			return nil, fmt.Errorf("function and expression are " +
+				"required")
+		}
+
+		var text strings.Builder
+		fmt.Fprintf(&text, "Find the line in %s where %s first goes "+
+			"wrong using the debug tools, in this order:\n\n",
+			function, expression)
+
+		step := target.writeSetup(&text)
+		fmt.Fprintf(&text, "%d. Call set_function_breakpoints with "+
+			"breakpoints [{\"name\": %q}]. Use search_symbols if the "+
+			"name is rejected.\n", step, function)
+		step = target.writeStart(&text, step+1)
+		fmt.Fprintf(&text, "%d. Once the program stops in %s, call "+
+			"add_watch with expressions [%q] so its value is "+
+			"reported at every later stop.\n", step, function,
+			expression)
+
+		if condition := args["condition"]; condition != "" {
+			fmt.Fprintf(&text, "%d. Call run_until with the stopped "+
+				"thread ID and condition %q. It steps line by line "+
+				"and stops right after the line that made the "+
+				"value wrong. Raise max_steps if the limit is "+
+				"reached first.\n", step+1, condition)
+		} else {
+			fmt.Fprintf(&text, "%d. Call trace_execution with the "+
+				"stopped thread ID, function %q and expressions "+
+				"[%q] to get the value after every line of the "+
+				"function. Find the first line after which it is "+
+				"wrong.\n", step+1, function, expression)
+		}
+
+		fmt.Fprintf(&text, "%d. Call get_stack_frames and get_variables "+
+			"at that point and use evaluate_expression on the "+
+			"inputs of the line to see why it computed the wrong "+
+			"value.\n", step+2)
+		fmt.Fprintf(&text, "%d. If the value was already wrong when %s "+
+			"was entered, repeat with its caller, which "+
+			"get_stack_frames shows below it.\n", step+3, function)
+
+		target.writeCleanup(&text)
+		text.WriteString("\n\nReport the line where the value goes " +
+			"wrong, its value before and after, and why.")
+
+		return promptResult(
+			fmt.Sprintf("Find where %s goes wrong in %s", expression,
+				function),
+			text.String(),
+		), nil
+	})
+}
@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// getPrompt gets a prompt with the given arguments and returns the text of
+// its message.
+func getPrompt(t *testing.T, mds *MCPDebugServer, name string,
+	args map[string]string) string {
+
+	t.Helper()
+
+	var result struct {
+		Messages []struct {
+			Role    mcp.Role        `json:"role"`
+			Content mcp.TextContent `json:"content"`
+		} `json:"messages"`
+	}
+	sendRequest(t, context.Background(), mds, "prompts/get",
+		map[string]any{"name": name, "arguments": args}, &result)
+	require.Len(t, result.Messages, 1)
+	require.Equal(t, mcp.RoleUser, result.Messages[0].Role)
+
+	return result.Messages[0].Content.Text
+}
+
+// TestPrompts tests that the workflow prompts are listed and explain the
+// session setup in the right order.
+func TestPrompts(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	var list mcp.ListPromptsResult
+	sendRequest(t, context.Background(), mds, "prompts/list", nil, &list)
+	names := make([]string, len(list.Prompts))
+	for i, prompt := range list.Prompts {
+		names[i] = prompt.Name
+	}
+	require.ElementsMatch(t, []string{
+		"investigate_panic", "find_stuck_goroutine", "bisect_bad_value",
+	}, names)
+
+	text := getPrompt(t, mds, "investigate_panic", map[string]string{
+		"package": "./store",
+		"test":    "TestGet",
+	})
+	require.Contains(t, text, `session_id "panic"`)
+	require.Contains(t, text, `program "./store", mode "test" and args `+
+		`["-test.run", "^TestGet$"]`)
+
+	// The session must be initialized before launching, and breakpoints
+	// set before configuration is done.
+	order := []string{
+		"create_debug_session", "initialize_session", "launch_program",
+		"set_exception_breakpoints", "configuration_done",
+		"get_stack_frames", "close_debug_session",
+	}
+	last := -1
+	for _, tool := range order {
+		idx := indexAfter(text, tool, last)
+		require.Greater(t, idx, last, "%s out of order", tool)
+		last = idx
+	}
+
+	text = getPrompt(t, mds, "bisect_bad_value", map[string]string{
+		"program":    "./cmd/app",
+		"function":   "main.total",
+		"expression": "sum",
+		"condition":  "sum < 0",
+		"session_id": "mine",
+	})
+	require.Contains(t, text, `session_id "mine"`)
+	require.Contains(t, text, `[{"name": "main.total"}]`)
+	require.Contains(t, text, `condition "sum < 0"`)
+	require.NotContains(t, text, "trace_execution")
+
+	text = getPrompt(t, mds, "find_stuck_goroutine", map[string]string{
+		"program": "./cmd/app",
+	})
+	require.Contains(t, text, "pause_execution")
+	require.Contains(t, text, "runtime.chanrecv")
+
+	// Missing required arguments are rejected.
+	msg, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "prompts/get",
+		"params": map[string]any{
+			"name":      "bisect_bad_value",
+			"arguments": map[string]string{"program": "./cmd/app"},
+		},
+	})
+	require.NoError(t, err)
+	resp := mds.server.HandleMessage(context.Background(), msg)
+	_, ok := resp.(mcp.JSONRPCError)
+	require.True(t, ok, "expected error, got %+v", resp)
+}
+
+// indexAfter returns the index of the first occurrence of substr in s after
+// the given index, or -1.
+func indexAfter(s, substr string, after int) int {
+	idx := strings.Index(s[after+1:], substr)
+	if idx < 0 {
+		return -1
+	}
+
+	return after + 1 + idx
+}
@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// RemoteServer is a connection to a server running in another process, served
+// over one of the HTTP transports, through which its debug sessions can be
+// monitored.
+type RemoteServer struct {
+	client *client.Client
+}
+
+// DialServer connects to a server listening on addr with the given HTTP
+// transport. As for Listen, addr is a TCP host:port or unix:<path> for a Unix
+// socket.
+func DialServer(ctx context.Context, addr string,
+	kind Transport) (*RemoteServer, error) {
+
+	// Requests over a Unix socket still need a host, for which localhost
+	// passes the server's Host check.
+	httpClient := &http.Client{}
+	host := addr
+	if path, ok := strings.CutPrefix(addr, unixAddressPrefix); ok {
+		var dialer net.Dialer
+		httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _,
+				_ string) (net.Conn, error) {
+
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		}
+		host = "localhost"
+	}
+
+	var (
+		c   *client.Client
+		err error
+	)
+	switch kind {
+	case TransportSSE:
+		c, err = client.NewSSEMCPClient("http://"+host+"/sse",
+			client.WithHTTPClient(httpClient))
+
+	case TransportStreamableHTTP:
+		c, err = client.NewStreamableHttpClient("http://"+host+"/mcp",
+			transport.WithHTTPBasicClient(httpClient))
+
+	default:
+		return nil, fmt.Errorf("%s is not an HTTP transport", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The SSE stream lives as long as the context passed to Start, so it
+	// mustn't end with the caller's.
+	if err := c.Start(context.WithoutCancel(ctx)); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("unable to connect to %s: %w", addr, err)
+	}
+
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: "dlv-mcp-monitor"}
+	if _, err := c.Initialize(ctx, init); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("unable to initialize connection to "+
+			"%s: %w", addr, err)
+	}
+
+	return &RemoteServer{client: c}, nil
+}
+
+// SessionInfos returns information about the remote server's debug sessions,
+// read from its sessions resource.
+func (r *RemoteServer) SessionInfos(ctx context.Context) ([]SessionInfo,
+	error) {
+
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = sessionsResourceURI
+	result, err := r.client.ReadResource(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Contents) != 1 {
+		return nil, fmt.Errorf("expected one content for %s, got %d",
+			sessionsResourceURI, len(result.Contents))
+	}
+	text, ok := result.Contents[0].(mcp.TextResourceContents)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a text resource",
+			sessionsResourceURI)
+	}
+
+	var summaries []sessionSummary
+	if err := json.Unmarshal([]byte(text.Text), &summaries); err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w",
+			sessionsResourceURI, err)
+	}
+
+	infos := make([]SessionInfo, 0, len(summaries))
+	for _, summary := range summaries {
+		state, err := ParseSessionState(summary.State)
+		if err != nil {
+			return nil, err
+		}
+
+		info := SessionInfo{
+			ID:          summary.ID,
+			Client:      summary.Client,
+			Program:     summary.Binary,
+			State:       state,
+			Breakpoints: summary.Breakpoints,
+			LastActive:  summary.LastActive,
+		}
+		if summary.Program != "" {
+			info.Program = summary.Program
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// Close closes the connection to the remote server.
+func (r *RemoteServer) Close() error {
+	return r.client.Close()
+}
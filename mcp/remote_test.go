@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDialServer tests that the sessions of a server can be read from another
+// process over each HTTP transport, on TCP and Unix sockets.
+func TestDialServer(t *testing.T) {
+	mds, _ := newTestServer(t)
+	requireSession(t, mds, "shared")
+	requireTool(t, mds, "launch_program", map[string]any{
+		"session_id": "shared",
+		"program":    "./cmd/app",
+	})
+	requireTool(t, mds, "set_breakpoints", map[string]any{
+		"session_id": "shared",
+		"file":       "/src/main.go",
+		"lines":      []int{42},
+	})
+
+	sockPath := filepath.Join(t.TempDir(), "mcp.sock")
+	ln, err := Listen(unixAddressPrefix + sockPath)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- mds.ServeListener(ctx, ln, TransportStreamableHTTP)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	addrs := map[string]Transport{
+		unixAddressPrefix + sockPath: TransportStreamableHTTP,
+	}
+	for _, transport := range []Transport{
+		TransportSSE, TransportStreamableHTTP,
+	} {
+		addr := serveTransport(t, mds, transport)
+		addrs[strings.TrimPrefix(addr, "http://")] = transport
+	}
+
+	for addr, transport := range addrs {
+		remote, err := DialServer(context.Background(), addr, transport)
+		require.NoError(t, err, addr)
+
+		infos, err := remote.SessionInfos(context.Background())
+		require.NoError(t, err)
+		require.Len(t, infos, 1)
+		require.Equal(t, "shared", infos[0].ID)
+		require.Equal(t, StateLaunched, infos[0].State)
+		require.Equal(t, "./cmd/app", infos[0].Program)
+		require.Equal(t, 1, infos[0].Breakpoints)
+		require.False(t, infos[0].LastActive.IsZero())
+
+		require.NoError(t, remote.Close())
+	}
+
+	_, err = DialServer(context.Background(), "localhost:1", TransportStdio)
+	require.Error(t, err)
+}
@@ -0,0 +1,465 @@
+package mcp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/roasbeef/mcp-debug/debugger"
+)
+
+const (
+	// defaultRenderBudget is the approximate number of characters the
+	// inspection tools return unless a client asks for another budget.
+	// At about four characters per token it keeps a result near 2000
+	// tokens.
+	defaultRenderBudget = 8000
+
+	// minRenderBudget is the smallest budget a client can ask for, so
+	// that a result always says something useful.
+	minRenderBudget = 200
+
+	// maxValueChars is the longest variable value that is rendered in
+	// full. Longer values are cut short with a marker.
+	maxValueChars = 256
+
+	// gapReserve is the room kept for each "… N more" marker when lines
+	// are left out to fit the budget.
+	gapReserve = 32
+)
+
+// maxCharsDescription describes the max_chars argument of the inspection
+// tools.
+const maxCharsDescription = "Approximate size limit of the result in " +
+	"characters (default: 8000). Long values are truncated and the least " +
+	"useful entries, such as runtime frames, are summarized to fit"
+
+// renderBudget returns the character budget for a call given the client's
+// max_chars argument.
+func renderBudget(maxChars int) int {
+	switch {
+	case maxChars <= 0:
+		return defaultRenderBudget
+	case maxChars < minRenderBudget:
+		return minRenderBudget
+	default:
+		return maxChars
+	}
+}
+
+// codeKind says whose code a function belongs to, which decides how useful it
+// is to show it to an agent debugging its own program.
+type codeKind int
+
+const (
+	// codeRuntime is the Go runtime and internal packages.
+	codeRuntime codeKind = iota
+
+	// codeStdlib is the rest of the standard library.
+	codeStdlib
+
+	// codeDependency is code from the module cache.
+	codeDependency
+
+	// codeUser is the program's own code.
+	codeUser
+)
+
+// functionPackage returns the import path of the package a fully qualified
+// function name, such as "net/http.(*Server).Serve", belongs to.
+func functionPackage(name string) string {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return name
+	}
+
+	return name[:slash+1+dot]
+}
+
+// classifyCode decides whose code a function is from its name and, if known,
+// its source file and the path of the program's main module. Without the
+// module path, packages whose first path element has no dot are taken to be
+// the standard library, which misclassifies modules named like "myapp".
+func classifyCode(function, file, module string) codeKind {
+	pkg := functionPackage(function)
+	first, _, _ := strings.Cut(pkg, "/")
+
+	switch {
+	case pkg == "runtime" || strings.HasPrefix(pkg, "runtime/") ||
+		first == "internal":
+
+		return codeRuntime
+
+	case module != "" && (pkg == module ||
+		strings.HasPrefix(pkg, module+"/")):
+
+		return codeUser
+
+	case strings.Contains(filepath.ToSlash(file), "/pkg/mod/"):
+		return codeDependency
+
+	case pkg != "main" && !strings.Contains(first, "."):
+		return codeStdlib
+
+	default:
+		return codeUser
+	}
+}
+
+// renderLine is a line of a rendering that may be left out if the budget is
+// too small.
+type renderLine struct {
+	text string
+
+	// priority orders which lines are kept, highest first. Lines of
+	// equal priority are kept in order.
+	priority int
+
+	// count is the number of items, e.g. frames, the line stands for.
+	// It is reported in the marker if the line is left out.
+	count int
+}
+
+// fitLines selects the lines that fit the budget, most important first, and
+// renders them in their original order. Runs of left out lines are replaced
+// by a marker saying how many items they stood for. It returns the rendering
+// and whether each line was kept.
+func fitLines(header string, lines []renderLine, budget int,
+	marker func(n int) string) (string, []bool) {
+
+	kept := make([]bool, len(lines))
+
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return lines[order[a]].priority > lines[order[b]].priority
+	})
+
+	used := len(header) + 1
+	for n, i := range order {
+		cost := len(lines[i].text) + 1
+		if n < len(lines)-1 {
+			cost += gapReserve
+		}
+
+		// The most important line is always kept.
+		if n > 0 && used+cost > budget {
+			break
+		}
+		kept[i] = true
+		used += cost
+	}
+
+	var (
+		text    strings.Builder
+		skipped int
+	)
+	text.WriteString(header)
+	for i, line := range lines {
+		if !kept[i] {
+			skipped += line.count
+			continue
+		}
+		if skipped > 0 {
+			fmt.Fprintf(&text, "\n%s", marker(skipped))
+			skipped = 0
+		}
+		fmt.Fprintf(&text, "\n%s", line.text)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&text, "\n%s", marker(skipped))
+	}
+
+	return text.String(), kept
+}
+
+// plural returns the singular or plural form of a noun for a count.
+func plural(n int, singular, pluralForm string) string {
+	if n == 1 {
+		return singular
+	}
+	return pluralForm
+}
+
+// truncateValue cuts a value longer than max characters short, saying how
+// much was left out.
+func truncateValue(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+
+	cut := max
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s… %d more chars", value[:cut], len(value)-cut)
+}
+
+// renderThreads renders the threads that fit the budget. The thread that last
+// stopped comes first in priority, followed by threads in user code, which is
+// told apart using the program's main module path if known. It returns the
+// threads that were kept and the rendering.
+func renderThreads(threads []debugger.ThreadInfo, stopped int, module string,
+	budget int) ([]debugger.ThreadInfo, string) {
+
+	lines := make([]renderLine, len(threads))
+	for i, thread := range threads {
+		priority := int(classifyCode(
+			threadFunction(thread.Name), "", module,
+		))
+		if thread.ID == stopped {
+			priority = int(codeUser) + 1
+		}
+
+		lines[i] = renderLine{
+			text:     fmt.Sprintf("- %d: %s", thread.ID, thread.Name),
+			priority: priority,
+			count:    1,
+		}
+	}
+
+	text, kept := fitLines(
+		fmt.Sprintf("%d %s:", len(threads),
+			plural(len(threads), "thread", "threads")),
+		lines, budget, func(n int) string {
+			return fmt.Sprintf("… %d more %s", n,
+				plural(n, "thread", "threads"))
+		},
+	)
+
+	shown := make([]debugger.ThreadInfo, 0, len(threads))
+	for i, thread := range threads {
+		if kept[i] {
+			shown = append(shown, thread)
+		}
+	}
+
+	return shown, text
+}
+
+// threadFunction returns the function a Delve thread name such as
+// "* [Go 1] main.main (Thread 42)" says the goroutine is in.
+func threadFunction(name string) string {
+	if _, rest, ok := strings.Cut(name, "] "); ok {
+		name = rest
+	}
+	name, _, _ = strings.Cut(name, " ")
+
+	return name
+}
+
+// renderStack renders the frames of a stack that fit the budget. If the whole
+// stack doesn't fit, runs of runtime frames are collapsed first, then frames
+// outside the program's own code are left out, keeping the innermost frame.
+// The program's own code is told apart using its main module path if known.
+// It returns the frames that were kept and the rendering.
+func renderStack(threadID int, frames []debugger.StackFrame, module string,
+	budget int) ([]Frame, string) {
+
+	header := fmt.Sprintf("Stack of thread %d (%d %s):", threadID,
+		len(frames), plural(len(frames), "frame", "frames"))
+
+	frameLine := func(depth int) renderLine {
+		frame := frames[depth]
+		kind := classifyCode(frame.Name, frame.Source.Path, module)
+
+		priority := int(kind)
+		if depth == 0 {
+			priority = int(codeUser) + 1
+		}
+
+		return renderLine{
+			text: fmt.Sprintf("#%d %s at %s:%d [frame %d]", depth,
+				frame.Name, frame.Source.Path, frame.Line,
+				frame.ID),
+			priority: priority,
+			count:    1,
+		}
+	}
+
+	// The frames each line stands for, so that the kept frames can be
+	// returned.
+	var (
+		lines  []renderLine
+		depths [][]int
+		size   = len(header)
+	)
+	for depth := range frames {
+		line := frameLine(depth)
+		lines = append(lines, line)
+		depths = append(depths, []int{depth})
+		size += len(line.text) + 1
+	}
+
+	if size > budget {
+		lines, depths = collapseRuntimeFrames(
+			frames, lines, depths, module,
+		)
+	}
+
+	text, kept := fitLines(header, lines, budget, func(n int) string {
+		return fmt.Sprintf("… %d more %s", n,
+			plural(n, "frame", "frames"))
+	})
+
+	var shown []Frame
+	for i, line := range depths {
+		// Collapsed runs of runtime frames aren't returned.
+		if !kept[i] || len(line) != 1 {
+			continue
+		}
+
+		shown = append(shown, Frame{
+			Depth:      line[0],
+			StackFrame: frames[line[0]],
+		})
+	}
+	if shown == nil {
+		shown = []Frame{}
+	}
+
+	return shown, text
+}
+
+// collapseRuntimeFrames replaces runs of two or more runtime frames, other
+// than the innermost frame, with a single line.
+func collapseRuntimeFrames(frames []debugger.StackFrame, lines []renderLine,
+	depths [][]int, module string) ([]renderLine, [][]int) {
+
+	var (
+		collapsedLines  []renderLine
+		collapsedDepths [][]int
+	)
+	for i := 0; i < len(lines); {
+		end := i
+		for i > 0 && end < len(frames) && classifyCode(
+			frames[end].Name, frames[end].Source.Path, module,
+		) == codeRuntime {
+			end++
+		}
+
+		if end-i < 2 {
+			collapsedLines = append(collapsedLines, lines[i])
+			collapsedDepths = append(collapsedDepths, depths[i])
+			i++
+			continue
+		}
+
+		run := make([]int, 0, end-i)
+		for depth := i; depth < end; depth++ {
+			run = append(run, depth)
+		}
+		collapsedLines = append(collapsedLines, renderLine{
+			text: fmt.Sprintf("… %d runtime frames (%s … %s)",
+				end-i, frames[i].Name, frames[end-1].Name),
+			priority: int(codeRuntime),
+			count:    end - i,
+		})
+		collapsedDepths = append(collapsedDepths, run)
+		i = end
+	}
+
+	return collapsedLines, collapsedDepths
+}
+
+// renderVariables renders the variables of each scope, in the order the
+// scopes were reported, that fit the budget. Long values are truncated, and
+// variables of expensive scopes such as globals are the first to be left out.
+// It returns the variables that were kept by scope, with truncated values,
+// the number left out of each scope and the rendering.
+func renderVariables(scopes []debugger.VariableScope,
+	variables map[string][]debugger.Variable,
+	budget int) (map[string][]debugger.Variable, map[string]int, string) {
+
+	if len(scopes) == 0 {
+		return map[string][]debugger.Variable{}, nil, "No variables"
+	}
+
+	type position struct {
+		scope string
+		index int
+	}
+
+	var (
+		lines     []renderLine
+		positions []position
+	)
+	for _, scope := range scopes {
+		// Scope headers are always kept so that it's clear which
+		// scope the markers refer to.
+		lines = append(lines, renderLine{
+			text:     scope.Name + ":",
+			priority: 3,
+		})
+		positions = append(positions, position{index: -1})
+
+		if len(variables[scope.Name]) == 0 {
+			lines = append(lines, renderLine{
+				text:     "  (none)",
+				priority: 3,
+			})
+			positions = append(positions, position{index: -1})
+		}
+
+		priority := 2
+		if scope.Expensive {
+			priority = 1
+		}
+		for i, v := range variables[scope.Name] {
+			text := fmt.Sprintf("  %s %s = %s", v.Name, v.Type,
+				truncateValue(v.Value, maxValueChars))
+			if v.VariablesReference != 0 {
+				text += fmt.Sprintf(" [ref %d]",
+					v.VariablesReference)
+			}
+
+			lines = append(lines, renderLine{
+				text:     text,
+				priority: priority,
+				count:    1,
+			})
+			positions = append(positions, position{
+				scope: scope.Name,
+				index: i,
+			})
+		}
+	}
+
+	text, kept := fitLines("", lines, budget, func(n int) string {
+		return fmt.Sprintf("  … %d more %s", n,
+			plural(n, "variable", "variables"))
+	})
+
+	var (
+		shown   = make(map[string][]debugger.Variable)
+		omitted map[string]int
+	)
+	for _, scope := range scopes {
+		shown[scope.Name] = []debugger.Variable{}
+	}
+	for i, pos := range positions {
+		if pos.index < 0 {
+			continue
+		}
+
+		if !kept[i] {
+			if omitted == nil {
+				omitted = make(map[string]int)
+			}
+			omitted[pos.scope]++
+			continue
+		}
+
+		v := variables[pos.scope][pos.index]
+		v.Value = truncateValue(v.Value, maxValueChars)
+		shown[pos.scope] = append(shown[pos.scope], v)
+	}
+
+	return shown, omitted, strings.TrimPrefix(text, "\n")
+}
@@ -0,0 +1,207 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClassifyCode tests that functions are attributed to the runtime, the
+// standard library, dependencies or the program itself.
+func TestClassifyCode(t *testing.T) {
+	tests := []struct {
+		function string
+		file     string
+		module   string
+		want     codeKind
+	}{
+		{"runtime.gopark", "", "", codeRuntime},
+		{"runtime/internal/atomic.Load", "", "", codeRuntime},
+		{"internal/poll.(*FD).Read", "", "", codeRuntime},
+		{"net/http.(*Server).Serve", "", "", codeStdlib},
+		{
+			"github.com/lib/pq.(*conn).query",
+			"/go/pkg/mod/github.com/lib/pq@v1.10.9/conn.go", "",
+			codeDependency,
+		},
+		{"github.com/acme/app/store.(*DB).Get", "/src/store/db.go", "",
+			codeUser},
+		{"main.main", "/src/main.go", "", codeUser},
+
+		// Modules whose path has no dot are only told apart from the
+		// standard library by the main module path.
+		{"myapp/store.(*DB).Get", "/src/store/db.go", "", codeStdlib},
+		{"myapp/store.(*DB).Get", "/src/store/db.go", "myapp",
+			codeUser},
+		{"myapp.run", "/src/run.go", "myapp", codeUser},
+		{"myapplication.run", "", "myapp", codeStdlib},
+		{"net/http.(*Server).Serve", "", "myapp", codeStdlib},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.want, classifyCode(
+			test.function, test.file, test.module,
+		), test.function)
+	}
+}
+
+// TestRenderStackBudget tests that runtime frames are collapsed and frames
+// outside user code are left out when a stack doesn't fit the budget.
+func TestRenderStackBudget(t *testing.T) {
+	frame := func(id int, name, path string) debugger.StackFrame {
+		var frame debugger.StackFrame
+		frame.ID = id
+		frame.Name = name
+		frame.Source.Path = path
+		frame.Line = id
+
+		return frame
+	}
+
+	frames := []debugger.StackFrame{
+		frame(1, "runtime.gopark", "/usr/go/src/runtime/proc.go"),
+		frame(2, "main.handle", "/src/main.go"),
+	}
+	for i := 0; i < 20; i++ {
+		frames = append(frames, frame(3+i, "runtime.mcall",
+			"/usr/go/src/runtime/asm.s"))
+	}
+	frames = append(frames, frame(23, "main.main", "/src/main.go"))
+
+	// Everything fits in the default budget.
+	shown, text := renderStack(7, frames, "", defaultRenderBudget)
+	require.Len(t, shown, len(frames))
+	require.NotContains(t, text, "…")
+
+	// The run of runtime frames is collapsed into a single line.
+	shown, text = renderStack(7, frames, "", 400)
+	require.LessOrEqual(t, len(text), 400)
+	require.Equal(t, "Stack of thread 7 (23 frames):\n"+
+		"#0 runtime.gopark at /usr/go/src/runtime/proc.go:1 [frame 1]\n"+
+		"#1 main.handle at /src/main.go:2 [frame 2]\n"+
+		"… 20 runtime frames (runtime.mcall … runtime.mcall)\n"+
+		"#22 main.main at /src/main.go:23 [frame 23]", text)
+
+	// The innermost frame is kept even though it's in the runtime, while
+	// the collapsed run is left out of the structured frames.
+	require.Equal(t, []int{0, 1, 22}, depths(shown))
+
+	// With less room the collapsed run is the first to go.
+	shown, text = renderStack(7, frames, "", 300)
+	require.LessOrEqual(t, len(text), 300)
+	require.Contains(t, text, "\n… 20 more frames\n#22 main.main")
+	require.Equal(t, []int{0, 1, 22}, depths(shown))
+}
+
+// TestRenderStackDotlessModule tests that frames of a main module whose path
+// has no dot are kept as user code rather than left out as the standard
+// library, and that the module path is read from the debugged binary.
+func TestRenderStackDotlessModule(t *testing.T) {
+	frame := func(id int, name string) debugger.StackFrame {
+		var frame debugger.StackFrame
+		frame.ID = id
+		frame.Name = name
+		frame.Source.Path = "/src/file.go"
+		frame.Line = id
+
+		return frame
+	}
+
+	frames := []debugger.StackFrame{frame(1, "sync.(*Mutex).Lock")}
+	for i := 0; i < 10; i++ {
+		frames = append(frames,
+			frame(2+i, "net/http.HandlerFunc.ServeHTTP"))
+	}
+	frames = append(frames, frame(12, "myapp/server.(*Server).handle"))
+
+	shown, text := renderStack(7, frames, "", 250)
+	require.NotContains(t, text, "myapp/server")
+	require.NotContains(t, depths(shown), 11)
+
+	shown, text = renderStack(7, frames, "myapp", 250)
+	require.Contains(t, text, "#11 myapp/server.(*Server).handle")
+	require.Contains(t, depths(shown), 11)
+
+	// The test binary's main module is this repository's.
+	binary, err := os.Executable()
+	require.NoError(t, err)
+
+	sess := &debugSession{}
+	require.Empty(t, sess.mainModule())
+	sess.setBinary(binary)
+	require.Equal(t, "github.com/roasbeef/mcp-debug", sess.mainModule())
+}
+
+// depths returns the depths of frames.
+func depths(frames []Frame) []int {
+	depths := make([]int, len(frames))
+	for i, frame := range frames {
+		depths[i] = frame.Depth
+	}
+
+	return depths
+}
+
+// TestRenderThreadsStoppedFirst tests that the stopped thread is kept when
+// there are more threads than fit the budget.
+func TestRenderThreadsStoppedFirst(t *testing.T) {
+	var threads []debugger.ThreadInfo
+	for i := 1; i <= 100; i++ {
+		threads = append(threads, debugger.ThreadInfo{
+			ID:   i,
+			Name: fmt.Sprintf("[Go %d] runtime.gopark", i),
+		})
+	}
+	threads[41].Name = "[Go 42] main.worker"
+	threads[76].Name = "[Go 77] net/http.(*conn).serve"
+
+	shown, text := renderThreads(threads, 99, "", minRenderBudget)
+	require.LessOrEqual(t, len(text), minRenderBudget)
+	require.True(t, strings.HasPrefix(text, "100 threads:"))
+	require.Equal(t, 99, shown[len(shown)-1].ID)
+	require.Contains(t, text, "- 42: [Go 42] main.worker")
+	require.Contains(t, text, "more threads")
+}
+
+// TestRenderVariables tests that long values are truncated and that
+// variables of expensive scopes are left out first.
+func TestRenderVariables(t *testing.T) {
+	long := strings.Repeat("x", 1000)
+
+	scopes := []debugger.VariableScope{
+		{Name: "Locals"},
+		{Name: "Globals", Expensive: true},
+	}
+	variables := map[string][]debugger.Variable{
+		"Locals": {
+			{Name: "buf", Type: "string", Value: long},
+			{Name: "n", Type: "int", Value: "3"},
+		},
+	}
+	for i := 0; i < 50; i++ {
+		variables["Globals"] = append(variables["Globals"],
+			debugger.Variable{
+				Name:  fmt.Sprintf("global%d", i),
+				Type:  "int",
+				Value: "0",
+			})
+	}
+
+	shown, omitted, text := renderVariables(scopes, variables, 600)
+	require.LessOrEqual(t, len(text), 600)
+	require.True(t, strings.HasPrefix(text, "Locals:\n  buf string = "))
+	require.Contains(t, text, "… 744 more chars")
+	require.Contains(t, text, "\n  n int = 3\nGlobals:\n")
+	require.Contains(t, text, "more variables")
+
+	require.Len(t, shown["Locals"], 2)
+	require.Len(t, shown["Locals"][0].Value,
+		maxValueChars+len("… 744 more chars"))
+	require.Equal(t, 50, len(shown["Globals"])+omitted["Globals"])
+	require.NotZero(t, omitted["Globals"])
+	require.NotContains(t, omitted, "Locals")
+}
@@ -0,0 +1,419 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+)
+
+const (
+	// sessionsResourceURI is the URI of the resource listing all debug
+	// sessions.
+	sessionsResourceURI = "debug://sessions"
+
+	// jsonMIMEType is the MIME type of the JSON resources.
+	jsonMIMEType = "application/json"
+)
+
+// Per-session resources, found at debug://sessions/<session_id>/<name>.
+const (
+	stackResource       = "stack"
+	localsResource      = "locals"
+	breakpointsResource = "breakpoints"
+	outputResource      = "output"
+)
+
+// sessionResourceURI returns the URI of one of a session's resources.
+func sessionResourceURI(sessionID, name string) string {
+	return fmt.Sprintf("%s/%s/%s", sessionsResourceURI,
+		url.PathEscape(sessionID), name)
+}
+
+// sessionSummary describes a session in the sessions resource.
+type sessionSummary struct {
+	ID            string    `json:"id"`
+	Client        string    `json:"client,omitempty"`
+	Program       string    `json:"program,omitempty"`
+	Binary        string    `json:"binary,omitempty"`
+	State         string    `json:"state"`
+	StoppedThread int       `json:"stopped_thread,omitempty"`
+	Breakpoints   int       `json:"breakpoints"`
+	LastActive    time.Time `json:"last_active"`
+	Watches       []string  `json:"watches,omitempty"`
+	Resources     []string  `json:"resources"`
+}
+
+// stackResourceView is the content of a session's stack resource.
+type stackResourceView struct {
+	Stopped  bool                  `json:"stopped"`
+	ThreadID int                   `json:"thread_id,omitempty"`
+	Frames   []debugger.StackFrame `json:"frames,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// localsResourceView is the content of a session's locals resource.
+type localsResourceView struct {
+	Stopped  bool                           `json:"stopped"`
+	ThreadID int                            `json:"thread_id,omitempty"`
+	Location *debugger.StopLocation         `json:"location,omitempty"`
+	Scopes   map[string][]debugger.Variable `json:"scopes,omitempty"`
+	Error    string                         `json:"error,omitempty"`
+}
+
+// breakpointsResourceView is the content of a session's breakpoints resource.
+type breakpointsResourceView struct {
+	Breakpoints         []debugger.BreakpointStatus   `json:"breakpoints"`
+	FunctionBreakpoints []debugger.FunctionBreakpoint `json:"function_breakpoints"`
+	ExceptionFilters    []string                      `json:"exception_filters"`
+}
+
+// registerResources registers the resources that exist independently of any
+// session.
+func (mds *MCPDebugServer) registerResources() {
+	resource := mcp.NewResource(sessionsResourceURI, "Debug sessions",
+		mcp.WithResourceDescription("All debug sessions with their client, program, state, stopped thread, breakpoint count, last activity, watches and resource URIs"),
+		mcp.WithMIMEType(jsonMIMEType),
+	)
+
+	mds.server.AddResource(resource, func(ctx context.Context,
+		request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+
+		sessions := mds.sessions.snapshot()
+		ids := make([]string, 0, len(sessions))
+		for id := range sessions {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		summaries := make([]sessionSummary, 0, len(ids))
+		for _, id := range ids {
+			sess := sessions[id]
+			summary := sessionSummary{
+				ID:            id,
+				Client:        sess.client,
+				Binary:        sess.binaryPath(),
+				State:         sess.currentState().String(),
+				StoppedThread: sess.stoppedThread(),
+				Breakpoints: len(
+					sess.breakpoints.SourceBreakpoints(),
+				),
+				LastActive: sess.lastActivity(),
+				Watches:    sess.watchList(),
+			}
+			if launch := sess.launchConfig(); launch != nil {
+				summary.Program = launch.Program
+			}
+			for _, name := range []string{
+				stackResource, localsResource,
+				breakpointsResource, outputResource,
+			} {
+				summary.Resources = append(summary.Resources,
+					sessionResourceURI(id, name))
+			}
+
+			summaries = append(summaries, summary)
+		}
+
+		return jsonResource(request.Params.URI, summaries)
+	})
+}
+
+// addSessionResources registers the resources of a new session, starts
+// tracking its state and sends update notifications for its resources to the
+// client that owns it.
+func (mds *MCPDebugServer) addSessionResources(sessionID string,
+	sess *debugSession) {
+
+	resources := []server.ServerResource{
+		{
+			Resource: mcp.NewResource(
+				sessionResourceURI(sessionID, stackResource),
+				fmt.Sprintf("Stack of %s", sessionID),
+				mcp.WithResourceDescription("Call stack of the thread that last stopped"),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			Handler: mds.sessionResourceHandler(
+				sessionID, readStackResource,
+			),
+		},
+		{
+			Resource: mcp.NewResource(
+				sessionResourceURI(sessionID, localsResource),
+				fmt.Sprintf("Locals of %s", sessionID),
+				mcp.WithResourceDescription("Variables in the top frame of the thread that last stopped, by scope"),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			Handler: mds.sessionResourceHandler(
+				sessionID, readLocalsResource,
+			),
+		},
+		{
+			Resource: mcp.NewResource(
+				sessionResourceURI(sessionID, breakpointsResource),
+				fmt.Sprintf("Breakpoints of %s", sessionID),
+				mcp.WithResourceDescription("Source breakpoints with their verification status, function breakpoints and exception filters"),
+				mcp.WithMIMEType(jsonMIMEType),
+			),
+			Handler: mds.sessionResourceHandler(
+				sessionID, readBreakpointsResource,
+			),
+		},
+		{
+			Resource: mcp.NewResource(
+				sessionResourceURI(sessionID, outputResource),
+				fmt.Sprintf("Output of %s", sessionID),
+				mcp.WithResourceDescription("Recent output of the debugged program and the debugger"),
+				mcp.WithMIMEType("text/plain"),
+			),
+			Handler: mds.sessionResourceHandler(
+				sessionID, readOutputResource,
+			),
+		},
+	}
+	mds.server.AddResources(resources...)
+
+	sess.stopTracking = sess.events.Subscribe(
+		func(event dap.EventMessage) {
+			mds.handleResourceEvent(sessionID, sess, event)
+		},
+	)
+
+	mds.notifySessionsUpdated()
+}
+
+// removeSessionResources unregisters the resources of a closed session.
+func (mds *MCPDebugServer) removeSessionResources(sessionID string) {
+	for _, name := range []string{
+		stackResource, localsResource, breakpointsResource,
+		outputResource,
+	} {
+		mds.server.RemoveResource(sessionResourceURI(sessionID, name))
+	}
+
+	mds.notifySessionsUpdated()
+}
+
+// handleResourceEvent tracks the session's state and stopped thread and
+// tells the owning client which resources an event changed. It is called
+// from the session's read loop, so it must not issue requests to the session.
+func (mds *MCPDebugServer) handleResourceEvent(sessionID string,
+	sess *debugSession, event dap.EventMessage) {
+
+	sess.trackEvent(event)
+
+	var changed []string
+	switch event.(type) {
+	case *dap.StoppedEvent, *dap.ContinuedEvent, *dap.ExitedEvent,
+		*dap.TerminatedEvent:
+
+		changed = []string{stackResource, localsResource}
+
+	case *dap.BreakpointEvent:
+		changed = []string{breakpointsResource}
+
+	case *dap.OutputEvent:
+		// Output can arrive in bursts, so only one update is sent
+		// until the client reads the output again.
+		if !sess.outputUnread.CompareAndSwap(false, true) {
+			return
+		}
+		changed = []string{outputResource}
+
+	default:
+		return
+	}
+
+	for _, name := range changed {
+		mds.notifyResourceUpdated(
+			sess.client, sessionResourceURI(sessionID, name),
+		)
+	}
+}
+
+// notifyResourceUpdated tells a client that a resource changed.
+func (mds *MCPDebugServer) notifyResourceUpdated(client, uri string) {
+	if client == "" {
+		return
+	}
+
+	err := mds.server.SendNotificationToSpecificClient(
+		client, mcp.MethodNotificationResourceUpdated,
+		map[string]any{"uri": uri},
+	)
+	if err != nil {
+		logging.Component("mcp").Warn("Failed to notify client of resource update",
+			"uri", uri, "err", err)
+	}
+}
+
+// notifySessionsUpdated tells all clients that the sessions resource changed.
+func (mds *MCPDebugServer) notifySessionsUpdated() {
+	mds.server.SendNotificationToAllClients(
+		mcp.MethodNotificationResourceUpdated,
+		map[string]any{"uri": sessionsResourceURI},
+	)
+}
+
+// notifySessionResource tells the client that owns a session that one of its
+// resources changed.
+func (mds *MCPDebugServer) notifySessionResource(sessionID string,
+	sess *debugSession, name string) {
+
+	mds.notifyResourceUpdated(
+		sess.client, sessionResourceURI(sessionID, name),
+	)
+}
+
+// sessionResourceHandler returns a handler that reads a resource of the given
+// session, failing if the session has been closed.
+func (mds *MCPDebugServer) sessionResourceHandler(sessionID string,
+	read func(context.Context, *debugSession) (any, error),
+) server.ResourceHandlerFunc {
+
+	return func(ctx context.Context,
+		request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+
+		sess, exists := mds.sessions.get(sessionID)
+		if !exists {
+			return nil, fmt.Errorf("session %s not found", sessionID)
+		}
+
+		content, err := read(ctx, sess)
+		if err != nil {
+			return nil, err
+		}
+
+		if text, ok := content.(string); ok {
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      request.Params.URI,
+					MIMEType: "text/plain",
+					Text:     text,
+				},
+			}, nil
+		}
+
+		return jsonResource(request.Params.URI, content)
+	}
+}
+
+// readStackResource returns the stack of the thread that last stopped.
+func readStackResource(ctx context.Context,
+	sess *debugSession) (any, error) {
+
+	threadID := sess.stoppedThread()
+	if threadID == 0 {
+		return stackResourceView{}, nil
+	}
+
+	view := stackResourceView{Stopped: true, ThreadID: threadID}
+	frames, err := sess.stackFrames(ctx, threadID)
+	if err != nil {
+		view.Error = err.Error()
+	}
+	view.Frames = frames
+
+	return view, nil
+}
+
+// readLocalsResource returns the variables in the top frame of the thread
+// that last stopped. Expensive scopes, such as globals, are left out.
+func readLocalsResource(ctx context.Context,
+	sess *debugSession) (any, error) {
+
+	threadID := sess.stoppedThread()
+	if threadID == 0 {
+		return localsResourceView{}, nil
+	}
+
+	view := localsResourceView{Stopped: true, ThreadID: threadID}
+	location, err := debugger.TopStopLocation(ctx, sess.ref, threadID)
+	if err != nil {
+		view.Error = err.Error()
+		return view, nil
+	}
+	view.Location = location
+	sess.noteFrames(location.FrameID)
+
+	scopes, err := debugger.GetVariableScopes(
+		ctx, sess.ref, location.FrameID,
+	)
+	if err != nil {
+		view.Error = err.Error()
+		return view, nil
+	}
+
+	view.Scopes = make(map[string][]debugger.Variable)
+	for _, scope := range scopes {
+		if scope.Expensive {
+			continue
+		}
+
+		variables, err := debugger.GetVariableList(
+			ctx, sess.ref, scope.VariablesReference,
+		)
+		if err != nil {
+			view.Error = err.Error()
+			continue
+		}
+		view.Scopes[scope.Name] = variables
+	}
+
+	return view, nil
+}
+
+// readBreakpointsResource returns all of the session's breakpoints.
+func readBreakpointsResource(_ context.Context,
+	sess *debugSession) (any, error) {
+
+	return breakpointsResourceView{
+		Breakpoints:         sess.breakpoints.Breakpoints(),
+		FunctionBreakpoints: sess.breakpoints.FunctionBreakpoints(),
+		ExceptionFilters:    sess.breakpoints.ExceptionFilters(),
+	}, nil
+}
+
+// readOutputResource returns the session's recent output.
+func readOutputResource(_ context.Context,
+	sess *debugSession) (any, error) {
+
+	sess.outputUnread.Store(false)
+
+	chunks, dropped := sess.output.Chunks()
+
+	var text strings.Builder
+	if dropped > 0 {
+		fmt.Fprintf(&text, "[%d earlier bytes discarded]\n", dropped)
+	}
+	for _, chunk := range chunks {
+		text.WriteString(chunk.Output)
+	}
+
+	return text.String(), nil
+}
+
+// jsonResource renders a value as the JSON content of a resource.
+func jsonResource(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: jsonMIMEType,
+			Text:     string(data),
+		},
+	}, nil
+}
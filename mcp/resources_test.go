@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// sendRequest dispatches a JSON-RPC request through the MCP server and
+// decodes the result into v.
+func sendRequest(t *testing.T, ctx context.Context, mds *MCPDebugServer,
+	method string, params map[string]any, v any) {
+
+	t.Helper()
+
+	msg, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	require.NoError(t, err)
+
+	resp := mds.server.HandleMessage(ctx, msg)
+	rpcResp, ok := resp.(mcp.JSONRPCResponse)
+	require.True(t, ok, "%s failed: %+v", method, resp)
+
+	data, err := json.Marshal(rpcResp.Result)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, v))
+}
+
+// readResource reads a resource and returns its text.
+func readResource(t *testing.T, mds *MCPDebugServer, uri string) string {
+	t.Helper()
+
+	var result struct {
+		Contents []mcp.TextResourceContents `json:"contents"`
+	}
+	sendRequest(t, context.Background(), mds, "resources/read",
+		map[string]any{"uri": uri}, &result)
+	require.Len(t, result.Contents, 1)
+
+	return result.Contents[0].Text
+}
+
+// listResources returns the URIs of all resources.
+func listResources(t *testing.T, mds *MCPDebugServer) []string {
+	t.Helper()
+
+	var result mcp.ListResourcesResult
+	sendRequest(t, context.Background(), mds, "resources/list", nil,
+		&result)
+
+	uris := make([]string, len(result.Resources))
+	for i, resource := range result.Resources {
+		uris[i] = resource.URI
+	}
+
+	return uris
+}
+
+// nextResourceUpdate waits for an update notification for the given resource,
+// skipping any others.
+func (c *fakeClient) nextResourceUpdate(t *testing.T, uri string) {
+	t.Helper()
+
+	for {
+		notification := c.nextNotification(
+			t, mcp.MethodNotificationResourceUpdated,
+		)
+		if notification.Params.AdditionalFields["uri"] == uri {
+			return
+		}
+	}
+}
+
+// TestSessionResources tests that session state is exposed as resources and
+// that the owning client is told when a stop, output or breakpoint change
+// affects them.
+func TestSessionResources(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	client := &fakeClient{
+		id:            "agent",
+		notifications: make(chan mcp.JSONRPCNotification, 20),
+	}
+	require.NoError(t, mds.server.RegisterSession(
+		context.Background(), client,
+	))
+	ctx := mds.server.WithContext(context.Background(), client)
+
+	result, err := callTool(ctx, mds, "create_debug_session",
+		map[string]any{"session_id": "app"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	client.nextNotification(t, mcp.MethodNotificationResourcesListChanged)
+
+	stackURI := "debug://sessions/app/stack"
+	require.ElementsMatch(t, []string{
+		"debug://sessions",
+		stackURI,
+		"debug://sessions/app/locals",
+		"debug://sessions/app/breakpoints",
+		"debug://sessions/app/output",
+	}, listResources(t, mds))
+
+	var sessions []sessionSummary
+	require.NoError(t, json.Unmarshal(
+		[]byte(readResource(t, mds, "debug://sessions")), &sessions,
+	))
+	require.Len(t, sessions, 1)
+	require.Equal(t, "app", sessions[0].ID)
+	require.Contains(t, sessions[0].Resources, stackURI)
+
+	require.JSONEq(t, `{"stopped": false}`, readResource(t, mds, stackURI))
+
+	sess, ok := mds.sessions.get("app")
+	require.True(t, ok)
+	sess.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{Reason: "breakpoint", ThreadId: 7},
+	})
+
+	client.nextResourceUpdate(t, stackURI)
+
+	var stack stackResourceView
+	require.NoError(t, json.Unmarshal(
+		[]byte(readResource(t, mds, stackURI)), &stack,
+	))
+	require.True(t, stack.Stopped)
+	require.Equal(t, 7, stack.ThreadID)
+	require.Len(t, stack.Frames, 1)
+
+	var locals localsResourceView
+	require.NoError(t, json.Unmarshal(
+		[]byte(readResource(t, mds, "debug://sessions/app/locals")),
+		&locals,
+	))
+	require.Equal(t, 42, locals.Location.Line)
+	require.Len(t, locals.Scopes, 1)
+	require.Equal(t, "count", locals.Scopes["Locals"][0].Name)
+
+	// Only one update is sent for a burst of output until it's read.
+	outputURI := "debug://sessions/app/output"
+	for _, line := range []string{"first\n", "second\n"} {
+		sess.events.Publish(&dap.OutputEvent{
+			Body: dap.OutputEventBody{
+				Category: "stdout", Output: line,
+			},
+		})
+	}
+	client.nextResourceUpdate(t, outputURI)
+	for len(client.notifications) > 0 {
+		notification := <-client.notifications
+		require.NotEqual(t, outputURI,
+			notification.Params.AdditionalFields["uri"])
+	}
+	require.Equal(t, "first\nsecond\n", readResource(t, mds, outputURI))
+
+	sess.events.Publish(&dap.OutputEvent{
+		Body: dap.OutputEventBody{Category: "stdout", Output: "third\n"},
+	})
+	client.nextResourceUpdate(t, outputURI)
+
+	requireTool(t, mds, "set_breakpoints", map[string]any{
+		"session_id": "app",
+		"file":       "/src/main.go",
+		"lines":      []int{42},
+	})
+	client.nextResourceUpdate(t, "debug://sessions/app/breakpoints")
+
+	requireTool(t, mds, "close_debug_session", map[string]any{
+		"session_id": "app",
+	})
+	require.Equal(t, []string{"debug://sessions"}, listResources(t, mds))
+}
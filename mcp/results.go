@@ -0,0 +1,323 @@
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/workspace"
+)
+
+// The types below are the structured content returned by the tools, declared
+// as their output schemas. Every result also has a concise text rendering for
+// clients that only read text.
+
+// ErrorResult is the structured content of every failed tool call.
+type ErrorResult struct {
+	Error string `json:"error"`
+}
+
+// SessionResult is the result of creating or closing a session.
+type SessionResult struct {
+	SessionID string `json:"session_id"`
+}
+
+// InitializeResult is the result of initializing a session.
+type InitializeResult struct {
+	SessionID    string           `json:"session_id"`
+	Capabilities dap.Capabilities `json:"capabilities"`
+}
+
+// LaunchResult is the result of launching a program or attaching to a
+// process. Exactly one of Launch and Attach is set.
+type LaunchResult struct {
+	SessionID string `json:"session_id"`
+
+	// Configuration is the name of the launch.json configuration used,
+	// if any.
+	Configuration string `json:"configuration,omitempty"`
+
+	Launch *debugger.LaunchConfig `json:"launch,omitempty"`
+	Attach *debugger.AttachConfig `json:"attach,omitempty"`
+
+	// Binary is the path of the binary being debugged, if known.
+	Binary string `json:"binary,omitempty"`
+}
+
+// ExecutionResult is the result of the execution control tools. Command is
+// the DAP command that was sent, e.g. "continue" or "stepIn".
+type ExecutionResult struct {
+	SessionID           string `json:"session_id"`
+	Command             string `json:"command"`
+	ThreadID            int    `json:"thread_id,omitempty"`
+	AllThreadsContinued bool   `json:"all_threads_continued,omitempty"`
+}
+
+// BreakpointsResult lists source breakpoints as reported by the debugger.
+type BreakpointsResult struct {
+	SessionID   string                      `json:"session_id"`
+	File        string                      `json:"file,omitempty"`
+	Breakpoints []debugger.BreakpointStatus `json:"breakpoints"`
+
+	// Unverified is the number of breakpoints that will never be hit.
+	Unverified int `json:"unverified"`
+}
+
+// FunctionBreakpointStatus describes whether the debugger could resolve a
+// function breakpoint.
+type FunctionBreakpointStatus struct {
+	Name     string `json:"name"`
+	Verified bool   `json:"verified"`
+	Message  string `json:"message,omitempty"`
+}
+
+// FunctionBreakpointsResult is the result of setting function breakpoints.
+type FunctionBreakpointsResult struct {
+	SessionID   string                     `json:"session_id"`
+	Breakpoints []FunctionBreakpointStatus `json:"breakpoints"`
+}
+
+// ExceptionBreakpointsResult is the result of setting exception filters.
+type ExceptionBreakpointsResult struct {
+	SessionID string   `json:"session_id"`
+	Filters   []string `json:"filters"`
+}
+
+// ThreadsResult lists the threads of the debugged program that fit the
+// call's budget.
+type ThreadsResult struct {
+	SessionID string                `json:"session_id"`
+	Threads   []debugger.ThreadInfo `json:"threads"`
+
+	// Total is the number of threads, including those left out.
+	Total int `json:"total"`
+}
+
+// Frame is a stack frame with its depth in the stack, the innermost frame
+// being at depth zero.
+type Frame struct {
+	Depth int `json:"depth"`
+	debugger.StackFrame
+}
+
+// StackFramesResult lists the stack frames of a thread that fit the call's
+// budget, innermost first.
+type StackFramesResult struct {
+	SessionID string  `json:"session_id"`
+	ThreadID  int     `json:"thread_id"`
+	Frames    []Frame `json:"frames"`
+
+	// Total is the depth of the stack, including the frames left out.
+	Total int `json:"total"`
+}
+
+// VariablesResult holds the variables of a frame that fit the call's budget
+// by scope name. Long values are truncated. If a path was given, the single
+// scope is named after it and holds the variable's fields or elements.
+type VariablesResult struct {
+	SessionID string `json:"session_id"`
+
+	// FrameID is the frame's ID at the current stop, resolved from its
+	// thread and depth if those were given.
+	FrameID int                            `json:"frame_id"`
+	Path    string                         `json:"path,omitempty"`
+	Scopes  map[string][]debugger.Variable `json:"scopes"`
+
+	// Omitted is the number of variables left out of each scope.
+	Omitted map[string]int `json:"omitted,omitempty"`
+}
+
+// DebugRunResult is the result of launching a program and running it to its
+// first stop. The location, stack and variables are only set if the program
+// stopped.
+type DebugRunResult struct {
+	SessionID           string                      `json:"session_id"`
+	Breakpoints         []debugger.BreakpointStatus `json:"breakpoints"`
+	FunctionBreakpoints []FunctionBreakpointStatus  `json:"function_breakpoints"`
+
+	// Unverified is the number of source breakpoints that will never be
+	// hit.
+	Unverified int `json:"unverified"`
+
+	// Outcome is "stopped" if the program stopped, "exited" if it exited
+	// first or "running" if it didn't stop before the timeout.
+	Outcome string `json:"outcome"`
+
+	// StopReason is the debugger's reason for the stop, e.g.
+	// "breakpoint", "entry" or "exception".
+	StopReason string `json:"stop_reason,omitempty"`
+
+	// ExitCode is the program's exit code if it exited and reported one.
+	ExitCode *int `json:"exit_code,omitempty"`
+
+	Location  *debugger.StopLocation `json:"location,omitempty"`
+	Stack     *StackFramesResult     `json:"stack,omitempty"`
+	Variables *VariablesResult       `json:"variables,omitempty"`
+}
+
+// EvaluationResult is the result of evaluating an expression.
+type EvaluationResult struct {
+	SessionID  string `json:"session_id"`
+	FrameID    int    `json:"frame_id"`
+	Expression string `json:"expression"`
+	Result     string `json:"result"`
+	Type       string `json:"type,omitempty"`
+
+	// VariablesReference can be used to expand a structured result until
+	// the program resumes. It is zero for scalar values. Passing the
+	// expression as the path of get_variables expands it at any stop.
+	VariablesReference int `json:"variables_reference,omitempty"`
+}
+
+// SymbolsResult lists the symbols matching a search.
+type SymbolsResult struct {
+	Pattern string `json:"pattern"`
+
+	// Total is the number of matching symbols, which may be more than
+	// were returned.
+	Total   int               `json:"total"`
+	Symbols []debugger.Symbol `json:"symbols"`
+}
+
+// RunUntilResult is the result of running until a condition holds.
+type RunUntilResult struct {
+	SessionID string                   `json:"session_id"`
+	Condition string                   `json:"condition"`
+	Result    *debugger.RunUntilResult `json:"result"`
+}
+
+// TraceResult is the result of recording an execution trace.
+type TraceResult struct {
+	SessionID string                `json:"session_id"`
+	Trace     *debugger.TraceResult `json:"trace"`
+}
+
+// ProfileResult is the result of profiling function calls. Durations are in
+// nanoseconds.
+type ProfileResult struct {
+	SessionID string                  `json:"session_id"`
+	Profile   *debugger.ProfileResult `json:"profile"`
+}
+
+// WatchesResult is the result of adding or removing watch expressions.
+type WatchesResult struct {
+	SessionID string   `json:"session_id"`
+	Added     []string `json:"added,omitempty"`
+	Removed   []string `json:"removed,omitempty"`
+
+	// Watches are the session's watch expressions after the change.
+	Watches []string `json:"watches"`
+}
+
+// WatchStatus is a watch expression with its most recent value, which is
+// missing if it hasn't been evaluated yet.
+type WatchStatus struct {
+	Expression string                `json:"expression"`
+	Latest     *debugger.WatchSample `json:"latest,omitempty"`
+}
+
+// WatchListResult lists a session's watch expressions.
+type WatchListResult struct {
+	SessionID string        `json:"session_id"`
+	Watches   []WatchStatus `json:"watches"`
+}
+
+// WatchHistoryResult holds the recorded values of a watch expression, oldest
+// first.
+type WatchHistoryResult struct {
+	SessionID  string                 `json:"session_id"`
+	Expression string                 `json:"expression"`
+	History    []debugger.WatchSample `json:"history"`
+}
+
+// WorkspaceResult is the result of saving a workspace.
+type WorkspaceResult struct {
+	Workspace *workspace.Workspace `json:"workspace"`
+}
+
+// LoadWorkspaceResult is the result of loading a workspace into a new
+// session.
+type LoadWorkspaceResult struct {
+	SessionID           string                      `json:"session_id"`
+	Workspace           *workspace.Workspace        `json:"workspace"`
+	Breakpoints         []debugger.BreakpointStatus `json:"breakpoints"`
+	FunctionBreakpoints []FunctionBreakpointStatus  `json:"function_breakpoints"`
+}
+
+// WorkspacesResult lists the saved workspaces.
+type WorkspacesResult struct {
+	Workspaces []*workspace.Workspace `json:"workspaces"`
+
+	// Unreadable maps the names of workspaces that couldn't be read to
+	// the reason.
+	Unreadable map[string]string `json:"unreadable,omitempty"`
+}
+
+// LaunchConfigurationSummary describes a Go configuration of a launch.json
+// file.
+type LaunchConfigurationSummary struct {
+	Name        string `json:"name"`
+	Request     string `json:"request"`
+	Description string `json:"description"`
+}
+
+// LaunchConfigurationsResult lists the Go configurations of a launch.json
+// file.
+type LaunchConfigurationsResult struct {
+	Configurations []LaunchConfigurationSummary `json:"configurations"`
+}
+
+// errorResult returns a failed tool result whose text and structured content
+// carry the formatted message.
+func errorResult(format string, args ...any) *mcp.CallToolResult {
+	text := fmt.Sprintf(format, args...)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(text),
+		},
+		StructuredContent: ErrorResult{Error: text},
+		IsError:           true,
+	}
+}
+
+// sessionNotFound returns the result of a tool call naming an unknown
+// session.
+func sessionNotFound(sessionID string) *mcp.CallToolResult {
+	return errorResult("Session %s not found", sessionID)
+}
+
+// unverifiedBreakpoints returns the number of breakpoints that the debugger
+// could not verify.
+func unverifiedBreakpoints(statuses []debugger.BreakpointStatus) int {
+	var unverified int
+	for _, bp := range statuses {
+		if !bp.Verified {
+			unverified++
+		}
+	}
+
+	return unverified
+}
+
+// functionBreakpointStatuses pairs the requested function breakpoints with the
+// breakpoints the debug adapter returned for them.
+func functionBreakpointStatuses(requested []debugger.FunctionBreakpoint,
+	actual []dap.Breakpoint) []FunctionBreakpointStatus {
+
+	statuses := make([]FunctionBreakpointStatus, 0, len(requested))
+	for i, bp := range requested {
+		status := FunctionBreakpointStatus{Name: bp.Name}
+		if i < len(actual) {
+			status.Verified = actual[i].Verified
+			status.Message = actual[i].Message
+		} else {
+			status.Message = "no breakpoint returned by debug adapter"
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/stretchr/testify/require"
+)
+
+// callStructured calls a tool like a client would, decoding its structured
+// content into v and returning its text and whether it failed.
+func callStructured(t *testing.T, mds *MCPDebugServer, name string,
+	args map[string]any, v any) (string, bool) {
+
+	t.Helper()
+
+	var result struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		StructuredContent json.RawMessage `json:"structuredContent"`
+		IsError           bool            `json:"isError"`
+	}
+	sendRequest(t, context.Background(), mds, "tools/call",
+		map[string]any{"name": name, "arguments": args}, &result)
+
+	require.NotEmpty(t, result.StructuredContent, name)
+	require.NoError(t, json.Unmarshal(result.StructuredContent, v), name)
+	require.Len(t, result.Content, 1, name)
+
+	return result.Content[0].Text, result.IsError
+}
+
+// TestStructuredResults tests that every tool declares an output schema and
+// that results carry structured content alongside their text.
+func TestStructuredResults(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	var list struct {
+		Tools []struct {
+			Name         string         `json:"name"`
+			OutputSchema map[string]any `json:"outputSchema"`
+		} `json:"tools"`
+	}
+	sendRequest(t, context.Background(), mds, "tools/list",
+		map[string]any{}, &list)
+	require.NotEmpty(t, list.Tools)
+	for _, tool := range list.Tools {
+		require.Equal(t, "object", tool.OutputSchema["type"], tool.Name)
+		require.NotEmpty(t, tool.OutputSchema["properties"], tool.Name)
+	}
+
+	var session SessionResult
+	_, failed := callStructured(t, mds, "create_debug_session",
+		map[string]any{"session_id": "app"}, &session)
+	require.False(t, failed)
+	require.Equal(t, "app", session.SessionID)
+
+	requireTool(t, mds, "initialize_session", map[string]any{
+		"session_id": "app",
+		"client_id":  "test",
+	})
+	stopSession(t, mds, "app")
+
+	var threads ThreadsResult
+	text, failed := callStructured(t, mds, "get_threads",
+		map[string]any{"session_id": "app"}, &threads)
+	require.False(t, failed)
+	require.Equal(t, []debugger.ThreadInfo{{ID: 1, Name: "main"}},
+		threads.Threads)
+	require.Equal(t, "1 thread:\n- 1: main", text)
+
+	var frames StackFramesResult
+	text, failed = callStructured(t, mds, "get_stack_frames",
+		map[string]any{"session_id": "app", "thread_id": 1}, &frames)
+	require.False(t, failed)
+	require.Len(t, frames.Frames, 1)
+	require.Equal(t, 1000, frames.Frames[0].ID)
+	require.Equal(t, "/src/main.go", frames.Frames[0].Source.Path)
+	require.Contains(t, text, "#0 main.main at /src/main.go:42 [frame 1000]")
+
+	var variables VariablesResult
+	text, failed = callStructured(t, mds, "get_variables",
+		map[string]any{"session_id": "app", "frame_id": 1000},
+		&variables)
+	require.False(t, failed)
+	require.Equal(t, "3", variables.Scopes["Locals"][0].Value)
+	require.Contains(t, text, "Locals:\n  count int = 3")
+
+	var eval EvaluationResult
+	text, failed = callStructured(t, mds, "evaluate_expression",
+		map[string]any{
+			"session_id": "app",
+			"frame_id":   1000,
+			"expression": "queue",
+		}, &eval)
+	require.False(t, failed)
+	require.Equal(t, "len(queue)", eval.Result)
+	require.Equal(t, "int", eval.Type)
+	require.Equal(t, "queue = len(queue) (int)", text)
+
+	var bps BreakpointsResult
+	_, failed = callStructured(t, mds, "set_breakpoints", map[string]any{
+		"session_id": "app",
+		"file":       "/src/main.go",
+		"lines":      []int{10, 20},
+	}, &bps)
+	require.False(t, failed)
+	require.Len(t, bps.Breakpoints, 2)
+	require.Zero(t, bps.Unverified)
+
+	// Failures carry the error message as structured content too.
+	var errResult ErrorResult
+	text, failed = callStructured(t, mds, "get_threads",
+		map[string]any{"session_id": "missing"}, &errResult)
+	require.True(t, failed)
+	require.Equal(t, "Session missing not found", errResult.Error)
+	require.Equal(t, errResult.Error, text)
+}
@@ -0,0 +1,400 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+)
+
+const (
+	// defaultRunTimeout is how long debug_run waits for the program to
+	// stop once it's running unless a client asks for another timeout.
+	defaultRunTimeout = 30 * time.Second
+
+	// defaultRunClientID is the DAP client ID debug_run initializes
+	// sessions with.
+	defaultRunClientID = "dlv-mcp-server"
+)
+
+// The outcomes of debug_run.
+const (
+	// runStopped means the program stopped, e.g. at a breakpoint.
+	runStopped = "stopped"
+
+	// runExited means the program exited without stopping.
+	runExited = "exited"
+
+	// runRunning means the program didn't stop before the timeout and is
+	// still running.
+	runRunning = "running"
+)
+
+// RunBreakpointArgs describes a source breakpoint set by debug_run.
+type RunBreakpointArgs struct {
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Condition    string `json:"condition,omitempty"`
+	HitCondition string `json:"hit_condition,omitempty"`
+	LogMessage   string `json:"log_message,omitempty"`
+}
+
+// DebugRunArgs represents the arguments for launching a program and running
+// it to its first stop.
+type DebugRunArgs struct {
+	SessionID           string                        `json:"session_id,omitempty"`
+	Program             string                        `json:"program"`
+	Mode                string                        `json:"mode,omitempty"`
+	Args                []string                      `json:"args,omitempty"`
+	Env                 []string                      `json:"env,omitempty"`
+	WorkingDir          string                        `json:"working_dir,omitempty"`
+	BuildFlags          []string                      `json:"build_flags,omitempty"`
+	StopOnEntry         bool                          `json:"stop_on_entry,omitempty"`
+	Breakpoints         []RunBreakpointArgs           `json:"breakpoints,omitempty"`
+	FunctionBreakpoints []debugger.FunctionBreakpoint `json:"function_breakpoints,omitempty"`
+	TimeoutSeconds      float64                       `json:"timeout_seconds,omitempty"`
+	MaxChars            int                           `json:"max_chars,omitempty"`
+}
+
+// registerDebugRunTool registers the tool that performs the whole launch
+// sequence in one call.
+func (mds *MCPDebugServer) registerDebugRunTool() {
+	tool := mcp.NewTool("debug_run",
+		mcp.WithDescription("Launch a Go program in a new session and run it to its first stop in one call: creates and initializes the session, launches the program, sets the breakpoints, completes configuration and waits for the program to stop. Returns the session ID with the stop location, the stack of the stopped thread and the local variables of its top frame. The session stays open for further inspection and stepping"),
+		mcp.WithOutputSchema[DebugRunResult](),
+		mcp.WithString("program", mcp.Required(),
+			mcp.Description("Path to the Go program, test file, or pre-built binary. For tests, use the test file path or directory")),
+		mcp.WithString("session_id",
+			mcp.Description("Identifier for the new session. Defaults to a name derived from the program")),
+		mcp.WithString("mode",
+			mcp.Description("Launch mode: 'debug' to build a main package, 'test' to build a test binary or 'exec' for a pre-built binary. Detected from the program if omitted")),
+		mcp.WithArray("args",
+			mcp.Description("Command line arguments. For tests, use ['-test.run', 'TestName']"),
+			mcp.Items(map[string]any{"type": "string"})),
+		mcp.WithArray("env",
+			mcp.Description("Environment variables (KEY=value format)"),
+			mcp.Items(map[string]any{"type": "string"})),
+		mcp.WithString("working_dir",
+			mcp.Description("Working directory for the program")),
+		mcp.WithArray("build_flags",
+			mcp.Description("Go build flags. Debug flags (-gcflags 'all=-N -l') are added automatically"),
+			mcp.Items(map[string]any{"type": "string"})),
+		mcp.WithBoolean("stop_on_entry",
+			mcp.Description("Stop at program entry point")),
+		mcp.WithArray("breakpoints",
+			mcp.Description("Source breakpoints with an optional condition, hit condition or log message"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"file":          map[string]any{"type": "string"},
+					"line":          map[string]any{"type": "integer"},
+					"condition":     map[string]any{"type": "string"},
+					"hit_condition": map[string]any{"type": "string"},
+					"log_message":   map[string]any{"type": "string"},
+				},
+				"required": []string{"file", "line"},
+			})),
+		mcp.WithArray("function_breakpoints",
+			mcp.Description("Function breakpoints. Names are fully qualified, e.g. 'main.handleRequest'"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":          map[string]any{"type": "string"},
+					"condition":     map[string]any{"type": "string"},
+					"hit_condition": map[string]any{"type": "string"},
+				},
+				"required": []string{"name"},
+			})),
+		mcp.WithNumber("timeout_seconds",
+			mcp.Description("How long to wait for the program to stop once it runs (default: 30). If it doesn't stop in time it keeps running and can be paused with pause_execution")),
+		mcp.WithNumber("max_chars",
+			mcp.Description(maxCharsDescription)),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args DebugRunArgs) (*mcp.CallToolResult, error) {
+
+		sessionID, sess, err := mds.createRunSession(
+			ctx, args.SessionID, args.Program,
+		)
+		if err != nil {
+			return errorResult(
+				"Cannot create session: %v", err), nil
+		}
+
+		// The program starts running once configuration is done, so
+		// its first stop is subscribed to beforehand.
+		stops, unsubscribe := sess.events.NextStop()
+		defer unsubscribe()
+
+		result, err := mds.startRun(ctx, sessionID, sess, args)
+		if err != nil {
+			// Don't leave a half configured session behind, so the
+			// call can simply be retried.
+			if sess, ok := mds.sessions.remove(sessionID); ok {
+				cerr := mds.closeSession(ctx, sessionID, sess, true)
+				if cerr != nil {
+					logging.Component("mcp").Error("Failed to close "+
+						"session", logging.KeySession,
+						sessionID, "err", cerr)
+				}
+			}
+
+			return errorResult(
+				"Failed to run %s: %v", args.Program, err), nil
+		}
+
+		timeout := defaultRunTimeout
+		if args.TimeoutSeconds > 0 {
+			timeout = time.Duration(
+				args.TimeoutSeconds * float64(time.Second),
+			)
+		}
+
+		var stop dap.EventMessage
+		select {
+		case stop = <-stops:
+			// The stop is tracked here too, so that the session is
+			// already stopped for the client's next call.
+			sess.trackEvent(stop)
+
+		case <-time.After(timeout):
+		case <-ctx.Done():
+			return errorResult(
+				"Gave up waiting for session %s to stop: %v",
+				sessionID, ctx.Err()), nil
+		}
+
+		text, err := mds.describeRunStop(
+			ctx, sess, result, stop, renderBudget(args.MaxChars),
+		)
+		if err != nil {
+			return errorResult(
+				"Program stopped in session %s, but its state "+
+					"could not be read: %v", sessionID, err), nil
+		}
+
+		return mcp.NewToolResultStructured(result, text), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// createRunSession creates the session for debug_run. Without an explicit ID,
+// the session is named after the program, numbered if the name is taken.
+func (mds *MCPDebugServer) createRunSession(ctx context.Context,
+	sessionID, program string) (string, *debugSession, error) {
+
+	if sessionID != "" {
+		sess, err := mds.createSession(ctx, sessionID)
+		return sessionID, sess, err
+	}
+
+	base := sanitizeFileName(strings.TrimSuffix(
+		filepath.Base(filepath.Clean(program)), ".go",
+	))
+	for n := 1; ; n++ {
+		sessionID = base
+		if n > 1 {
+			sessionID = fmt.Sprintf("%s-%d", base, n)
+		}
+
+		sess, err := mds.createSession(ctx, sessionID)
+		if !errors.Is(err, errSessionExists) {
+			return sessionID, sess, err
+		}
+	}
+}
+
+// startRun initializes and launches a new debug_run session, sets its
+// breakpoints and completes configuration so the program starts running. It
+// returns the result recording the breakpoints.
+func (mds *MCPDebugServer) startRun(ctx context.Context, sessionID string,
+	sess *debugSession, args DebugRunArgs) (*DebugRunResult, error) {
+
+	_, err := mds.initializeSession(ctx, sess, defaultRunClientID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize session: %w",
+			err)
+	}
+
+	config := debugger.LaunchConfig{
+		Name:        sessionID,
+		Program:     args.Program,
+		Mode:        args.Mode,
+		Args:        args.Args,
+		Env:         args.Env,
+		WorkingDir:  args.WorkingDir,
+		StopOnEntry: args.StopOnEntry,
+		BuildFlags:  args.BuildFlags,
+	}
+	if _, err := mds.launchSession(ctx, sessionID, sess, config); err != nil {
+		return nil, fmt.Errorf("unable to launch program: %w", err)
+	}
+
+	result := &DebugRunResult{
+		SessionID:           sessionID,
+		Breakpoints:         []debugger.BreakpointStatus{},
+		FunctionBreakpoints: []FunctionBreakpointStatus{},
+	}
+
+	// Breakpoints are set one file at a time, keeping the given order.
+	var (
+		files  []string
+		byFile = make(map[string][]debugger.BreakpointLocation)
+	)
+	for _, bp := range args.Breakpoints {
+		if _, ok := byFile[bp.File]; !ok {
+			files = append(files, bp.File)
+		}
+		byFile[bp.File] = append(byFile[bp.File],
+			debugger.BreakpointLocation{
+				File:         bp.File,
+				Line:         bp.Line,
+				Condition:    bp.Condition,
+				HitCondition: bp.HitCondition,
+				LogMessage:   bp.LogMessage,
+			})
+	}
+	for _, file := range files {
+		statuses, err := sess.breakpoints.SetBreakpoints(
+			ctx, sess.ref, byFile[file],
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to set breakpoints "+
+				"in %s: %w", file, err)
+		}
+		result.Breakpoints = append(result.Breakpoints, statuses...)
+	}
+	result.Unverified = unverifiedBreakpoints(result.Breakpoints)
+
+	if len(args.FunctionBreakpoints) > 0 {
+		resp, err := sess.breakpoints.SetFunctionBreakpoints(
+			ctx, sess.ref, args.FunctionBreakpoints,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("unable to set function "+
+				"breakpoints: %w", err)
+		}
+		result.FunctionBreakpoints = functionBreakpointStatuses(
+			args.FunctionBreakpoints, resp.Body.Breakpoints,
+		)
+	}
+	if len(files) > 0 || len(args.FunctionBreakpoints) > 0 {
+		mds.notifySessionResource(
+			sessionID, sess, breakpointsResource,
+		)
+	}
+
+	if err := mds.configureSession(ctx, sess); err != nil {
+		return nil, fmt.Errorf("unable to complete configuration: %w",
+			err)
+	}
+
+	return result, nil
+}
+
+// describeRunStop records how the program's run ended in the result, reading
+// the stack and locals of the stopped thread within the budget, and returns
+// the text rendering of the result. A nil stop means the program is still
+// running.
+func (mds *MCPDebugServer) describeRunStop(ctx context.Context,
+	sess *debugSession, result *DebugRunResult, stop dap.EventMessage,
+	budget int) (string, error) {
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Session %s: ", result.SessionID)
+
+	switch e := stop.(type) {
+	case nil:
+		result.Outcome = runRunning
+		text.WriteString("the program is still running; call " +
+			"pause_execution to stop it or wait for a stopped " +
+			"notification")
+
+	case *dap.ExitedEvent:
+		result.Outcome = runExited
+		exitCode := e.Body.ExitCode
+		result.ExitCode = &exitCode
+		fmt.Fprintf(&text, "the program exited with code %d without "+
+			"stopping", exitCode)
+
+	case *dap.TerminatedEvent:
+		result.Outcome = runExited
+		text.WriteString("the program exited without stopping")
+
+	case *dap.StoppedEvent:
+		result.Outcome = runStopped
+		result.StopReason = e.Body.Reason
+
+		threadID := e.Body.ThreadId
+		frames, err := sess.stackFrames(ctx, threadID)
+		if err != nil {
+			return "", err
+		}
+		if len(frames) == 0 {
+			return "", fmt.Errorf("thread %d has no stack frames",
+				threadID)
+		}
+		top := frames[0]
+		result.Location = &debugger.StopLocation{
+			ThreadID: threadID,
+			FrameID:  top.ID,
+			Function: top.Name,
+			File:     top.Source.Path,
+			Line:     top.Line,
+		}
+		fmt.Fprintf(&text, "stopped (%s) in %s at %s:%d", e.Body.Reason,
+			top.Name, top.Source.Path, top.Line)
+
+		// The stack and the locals share the budget.
+		shown, stackText := renderStack(
+			threadID, frames, sess.mainModule(), budget/2,
+		)
+		result.Stack = &StackFramesResult{
+			SessionID: result.SessionID,
+			ThreadID:  threadID,
+			Frames:    shown,
+			Total:     len(frames),
+		}
+
+		scopes, variables, err := readVariables(
+			ctx, sess, top.ID, false,
+		)
+		if err != nil {
+			return "", err
+		}
+		vars, omitted, varsText := renderVariables(
+			scopes, variables, budget/2,
+		)
+		result.Variables = &VariablesResult{
+			SessionID: result.SessionID,
+			FrameID:   top.ID,
+			Scopes:    vars,
+			Omitted:   omitted,
+		}
+
+		fmt.Fprintf(&text, "\n\n%s\n\n%s", stackText, varsText)
+	}
+
+	if result.Unverified > 0 {
+		fmt.Fprintf(&text, "\n\n%s", formatBreakpointStatuses(
+			"Breakpoints:", result.Breakpoints))
+	}
+	for _, bp := range result.FunctionBreakpoints {
+		if !bp.Verified {
+			fmt.Fprintf(&text, "\n\n%s", formatFunctionBreakpoints(
+				result.FunctionBreakpoints))
+			break
+		}
+	}
+
+	return text.String(), nil
+}
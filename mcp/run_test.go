@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDebugRun tests that debug_run launches a program with its breakpoints
+// and returns the first stop, and that the session is left open.
+func TestDebugRun(t *testing.T) {
+	mds, fake := newTestServer(t)
+	fake.stopOnConfigurationDone.Store(&dap.StoppedEvent{
+		Event: dap.Event{Event: "stopped"},
+		Body: dap.StoppedEventBody{
+			Reason:   "breakpoint",
+			ThreadId: 1,
+		},
+	})
+
+	var result DebugRunResult
+	text, failed := callStructured(t, mds, "debug_run", map[string]any{
+		"program": "/src/cmd/app",
+		"args":    []string{"-v"},
+		"breakpoints": []map[string]any{
+			{"file": "/src/main.go", "line": 42},
+			{"file": "/src/util.go", "line": 7},
+			{"file": "/src/main.go", "line": 50},
+		},
+	}, &result)
+	require.False(t, failed, text)
+
+	// The session is named after the program.
+	require.Equal(t, "app", result.SessionID)
+	_, ok := mds.sessions.get("app")
+	require.True(t, ok)
+
+	var launch map[string]any
+	require.NoError(t, json.Unmarshal(<-fake.launches, &launch))
+	require.Equal(t, "/src/cmd/app", launch["program"])
+	require.Equal(t, []any{"-v"}, launch["args"])
+
+	require.Len(t, result.Breakpoints, 3)
+	require.Zero(t, result.Unverified)
+
+	require.Equal(t, runStopped, result.Outcome)
+	require.Equal(t, "breakpoint", result.StopReason)
+	require.Equal(t, 1000, result.Location.FrameID)
+	require.Equal(t, "/src/main.go", result.Location.File)
+	require.Equal(t, 42, result.Location.Line)
+	require.Len(t, result.Stack.Frames, 1)
+	require.Equal(t, "3", result.Variables.Scopes["Locals"][0].Value)
+
+	// Expensive scopes such as globals aren't read.
+	require.NotContains(t, result.Variables.Scopes, "Globals")
+
+	require.Contains(t, text, "Session app: stopped (breakpoint) in "+
+		"main.main at /src/main.go:42")
+	require.Contains(t, text, "Locals:\n  count int = 3")
+
+	// A second run of the same program gets its own session.
+	var second DebugRunResult
+	text, failed = callStructured(t, mds, "debug_run", map[string]any{
+		"program": "/src/cmd/app",
+	}, &second)
+	require.False(t, failed, text)
+	require.Equal(t, "app-2", second.SessionID)
+
+	// A program that doesn't stop in time is left running.
+	fake.stopOnConfigurationDone.Store(nil)
+	var slow DebugRunResult
+	text, failed = callStructured(t, mds, "debug_run", map[string]any{
+		"session_id":      "slow",
+		"program":         "/src/cmd/app",
+		"timeout_seconds": 0.05,
+	}, &slow)
+	require.False(t, failed, text)
+	require.Equal(t, runRunning, slow.Outcome)
+	require.Nil(t, slow.Location)
+	require.Contains(t, text, "pause_execution")
+
+	// Failing to create the session is reported.
+	var errResult ErrorResult
+	_, failed = callStructured(t, mds, "debug_run", map[string]any{
+		"session_id": "slow",
+		"program":    "/src/cmd/app",
+	}, &errResult)
+	require.True(t, failed)
+	require.Contains(t, errResult.Error, "session already exists")
+}
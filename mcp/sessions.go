@@ -0,0 +1,400 @@
+package mcp
+
+import (
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/go-dap"
+	"github.com/lightningnetwork/lnd/actor"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/metrics"
+)
+
+var (
+	// errSessionExists is returned when creating a session under an ID
+	// that is already in use.
+	errSessionExists = errors.New("session already exists")
+
+	// errTooManySessions is returned when the server-wide session limit
+	// has been reached.
+	errTooManySessions = errors.New("too many debug sessions")
+
+	// errTooManyClientSessions is returned when the per-client session
+	// limit has been reached.
+	errTooManyClientSessions = errors.New("too many debug sessions for " +
+		"this client")
+)
+
+// SessionLimits bounds the number and lifetime of debug sessions. Each session
+// runs its own Delve server, so forgotten sessions add up quickly.
+type SessionLimits struct {
+	// MaxSessions is the maximum number of concurrent sessions across all
+	// clients. Zero means unlimited.
+	MaxSessions int
+
+	// MaxSessionsPerClient is the maximum number of concurrent sessions a
+	// single MCP client may own. Zero means unlimited.
+	MaxSessionsPerClient int
+
+	// IdleTimeout is how long a session may go without any tool calls
+	// before it is closed. Zero disables idle reaping.
+	IdleTimeout time.Duration
+}
+
+// DefaultSessionLimits returns the session limits used unless configured
+// otherwise.
+func DefaultSessionLimits() SessionLimits {
+	return SessionLimits{
+		MaxSessions:          16,
+		MaxSessionsPerClient: 4,
+		IdleTimeout:          30 * time.Minute,
+	}
+}
+
+// debugSession holds the state the MCP server keeps for each debug session.
+type debugSession struct {
+	// id is the debugger's identifier for the session, used to close it.
+	id string
+
+	// client is the MCP client session that created the debug session,
+	// which receives its notifications.
+	client string
+
+	ref    actor.ActorRef[*debugger.DAPRequest, *debugger.DAPResponse]
+	events *debugger.EventBus
+
+	// breakpoints tracks the verification status of the session's source
+	// breakpoints, including changes reported asynchronously.
+	breakpoints *debugger.BreakpointTracker
+
+	// watches holds the session's watch expressions and re-evaluates them
+	// every time the program stops.
+	watches *debugger.WatchTracker
+
+	// output collects the recent output of the debugged program.
+	output *debugger.OutputBuffer
+
+	// outputUnread is set once the client has been told about new output
+	// and cleared when it reads the output resource.
+	outputUnread atomic.Bool
+
+	// stopTracking stops tracking the session's state from its events and
+	// sending resource update notifications.
+	stopTracking func()
+
+	// stopEventForwarding stops sending the session's events to the
+	// client.
+	stopEventForwarding func()
+
+	mu sync.Mutex
+
+	// binary is the path of the executable being debugged, once known. It
+	// is recorded on launch or attach and used for symbol lookups.
+	binary string
+
+	// module is the path of the main module of binary, read from its
+	// build info the first time it's needed, and moduleBinary is the
+	// binary it was read from.
+	module       string
+	moduleBinary string
+
+	// launch is the configuration the program was launched with, or nil
+	// if it hasn't been launched. Attached sessions have no launch
+	// configuration and can't be saved as a workspace.
+	launch *debugger.LaunchConfig
+
+	// stopped is the thread that last stopped, or zero if the program is
+	// running or has exited.
+	stopped int
+
+	// state is where the session is in its lifecycle.
+	state SessionState
+
+	// stops counts the program's stops and lastStop is the latest one.
+	// frameStops records the stop at which each frame ID was last handed
+	// to a client, so that IDs from earlier stops can be rejected.
+	stops      int
+	lastStop   *dap.StoppedEvent
+	frameStops map[int]int
+
+	// lastActive is when the last tool call using the session finished,
+	// and inFlight is the number of tool calls currently using it.
+	lastActive time.Time
+	inFlight   int
+}
+
+// setBinary records the path of the executable being debugged.
+func (s *debugSession) setBinary(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.binary = path
+}
+
+// binaryPath returns the path of the executable being debugged, or an empty
+// string if it isn't known yet.
+func (s *debugSession) binaryPath() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.binary
+}
+
+// mainModule returns the path of the main module of the executable being
+// debugged, or an empty string if it isn't known or has no build info.
+func (s *debugSession) mainModule() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.binary == "" || s.binary == s.moduleBinary {
+		return s.module
+	}
+
+	s.module = ""
+	s.moduleBinary = s.binary
+	if info, err := buildinfo.ReadFile(s.binary); err == nil {
+		s.module = info.Main.Path
+	}
+
+	return s.module
+}
+
+// setLaunch records the configuration the program was launched with.
+func (s *debugSession) setLaunch(config debugger.LaunchConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.launch = &config
+}
+
+// launchConfig returns the configuration the program was launched with, or
+// nil if it wasn't launched.
+func (s *debugSession) launchConfig() *debugger.LaunchConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.launch == nil {
+		return nil
+	}
+	config := *s.launch
+
+	return &config
+}
+
+// setStoppedThread records the thread that last stopped, or zero if the
+// program resumed or exited.
+func (s *debugSession) setStoppedThread(threadID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = threadID
+}
+
+// stoppedThread returns the thread that last stopped, or zero if the program
+// isn't stopped.
+func (s *debugSession) stoppedThread() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stopped
+}
+
+// setWatches replaces the session's watch expressions.
+func (s *debugSession) setWatches(watches []string) {
+	s.watches.Set(watches)
+}
+
+// watchList returns the session's watch expressions.
+func (s *debugSession) watchList() []string {
+	return s.watches.Expressions()
+}
+
+// begin marks the start of a tool call using the session.
+func (s *debugSession) begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight++
+}
+
+// end marks the end of a tool call using the session.
+func (s *debugSession) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+	s.lastActive = time.Now()
+}
+
+// lastActivity returns when the last tool call using the session finished.
+func (s *debugSession) lastActivity() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastActive
+}
+
+// idleSince reports whether the session has been idle since before the given
+// time. A session with a tool call in progress is never idle.
+func (s *debugSession) idleSince(t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.inFlight == 0 && s.lastActive.Before(t)
+}
+
+// sessionEntry is a registry slot. The session is nil while the slot is
+// reserved by a create call that is still in progress.
+type sessionEntry struct {
+	// client is the MCP client session that created the debug session.
+	client string
+
+	sess *debugSession
+}
+
+// sessionRegistry owns the set of debug sessions known to the MCP server. Tool
+// calls may be dispatched concurrently, so all access goes through its
+// methods.
+type sessionRegistry struct {
+	mu      sync.RWMutex
+	entries map[string]*sessionEntry
+	limits  SessionLimits
+}
+
+// newSessionRegistry creates an empty session registry enforcing the given
+// limits.
+func newSessionRegistry(limits SessionLimits) *sessionRegistry {
+	return &sessionRegistry{
+		entries: make(map[string]*sessionEntry),
+		limits:  limits,
+	}
+}
+
+// get returns the session with the given ID. Reserved IDs are reported as not
+// found.
+func (r *sessionRegistry) get(id string) (*debugSession, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.entries[id]
+	if !ok || entry.sess == nil {
+		return nil, false
+	}
+
+	return entry.sess, true
+}
+
+// reserve claims an ID on behalf of a client for a session that is about to
+// be created. Reservations count towards the session limits, so concurrent
+// creates can't overshoot them.
+func (r *sessionRegistry) reserve(id, client string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[id]; ok {
+		return errSessionExists
+	}
+
+	if max := r.limits.MaxSessions; max > 0 && len(r.entries) >= max {
+		return fmt.Errorf("%w: limit is %d", errTooManySessions, max)
+	}
+
+	if max := r.limits.MaxSessionsPerClient; max > 0 {
+		var owned int
+		for _, entry := range r.entries {
+			if entry.client == client {
+				owned++
+			}
+		}
+		if owned >= max {
+			return fmt.Errorf("%w: limit is %d",
+				errTooManyClientSessions, max)
+		}
+	}
+
+	r.entries[id] = &sessionEntry{client: client}
+
+	return nil
+}
+
+// release drops a reservation made by reserve if the session could not be
+// created.
+func (r *sessionRegistry) release(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry, ok := r.entries[id]; ok && entry.sess == nil {
+		delete(r.entries, id)
+	}
+}
+
+// insert stores the session for a reserved ID.
+func (r *sessionRegistry) insert(id string, sess *debugSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sess.lastActive = time.Now()
+
+	entry, ok := r.entries[id]
+	if !ok {
+		entry = &sessionEntry{}
+		r.entries[id] = entry
+	}
+	if entry.sess == nil {
+		metrics.ActiveSessions.Inc()
+	}
+	entry.sess = sess
+}
+
+// remove deletes the session with the given ID and returns it. Only one of
+// several concurrent callers removing the same ID gets the session.
+func (r *sessionRegistry) remove(id string) (*debugSession, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[id]
+	if !ok || entry.sess == nil {
+		return nil, false
+	}
+	delete(r.entries, id)
+	metrics.ActiveSessions.Dec()
+
+	return entry.sess, true
+}
+
+// removeIdle deletes and returns all sessions that have had no tool calls in
+// progress or completed since the given time.
+func (r *sessionRegistry) removeIdle(since time.Time) map[string]*debugSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	idle := make(map[string]*debugSession)
+	for id, entry := range r.entries {
+		if entry.sess != nil && entry.sess.idleSince(since) {
+			idle[id] = entry.sess
+			delete(r.entries, id)
+			metrics.ActiveSessions.Dec()
+		}
+	}
+
+	return idle
+}
+
+// snapshot returns a copy of the current sessions, excluding reservations.
+func (r *sessionRegistry) snapshot() map[string]*debugSession {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := make(map[string]*debugSession, len(r.entries))
+	for id, entry := range r.entries {
+		if entry.sess != nil {
+			sessions[id] = entry.sess
+		}
+	}
+
+	return sessions
+}
@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSessionRegistry tests that reservations hide IDs from lookups while
+// preventing duplicates, and that sessions can only be removed once.
+func TestSessionRegistry(t *testing.T) {
+	r := newSessionRegistry(SessionLimits{})
+
+	require.NoError(t, r.reserve("a", "client"))
+	require.ErrorIs(t, r.reserve("a", "client"), errSessionExists)
+
+	// A reserved ID isn't visible until the session is inserted.
+	_, ok := r.get("a")
+	require.False(t, ok)
+	require.Empty(t, r.snapshot())
+	_, ok = r.remove("a")
+	require.False(t, ok)
+
+	sess := &debugSession{id: "session-1"}
+	r.insert("a", sess)
+
+	got, ok := r.get("a")
+	require.True(t, ok)
+	require.Same(t, sess, got)
+	require.Len(t, r.snapshot(), 1)
+
+	// Releasing only drops reservations, not live sessions.
+	r.release("a")
+	_, ok = r.get("a")
+	require.True(t, ok)
+
+	got, ok = r.remove("a")
+	require.True(t, ok)
+	require.Same(t, sess, got)
+	_, ok = r.remove("a")
+	require.False(t, ok)
+
+	// A released reservation frees the ID again.
+	require.NoError(t, r.reserve("b", "client"))
+	r.release("b")
+	require.NoError(t, r.reserve("b", "client"))
+}
+
+// TestSessionIdleness tests that a session with a call in progress is never
+// considered idle.
+func TestSessionIdleness(t *testing.T) {
+	r := newSessionRegistry(SessionLimits{})
+
+	sess := &debugSession{}
+	require.NoError(t, r.reserve("a", "client"))
+	r.insert("a", sess)
+
+	sess.begin()
+	require.Empty(t, r.removeIdle(time.Now().Add(time.Hour)))
+
+	sess.end()
+	require.Empty(t, r.removeIdle(time.Now().Add(-time.Hour)))
+
+	idle := r.removeIdle(time.Now().Add(time.Hour))
+	require.Len(t, idle, 1)
+	require.Same(t, sess, idle["a"])
+
+	_, ok := r.get("a")
+	require.False(t, ok)
+}
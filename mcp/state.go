@@ -0,0 +1,284 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// SessionState is where a debug session is in its lifecycle. Sessions move
+// from Created through Initialized and Launched or Attached to Configured,
+// after which they alternate between Running and Stopped until the program
+// terminates.
+type SessionState int
+
+const (
+	// StateCreated is a new session whose debug adapter hasn't been
+	// initialized yet.
+	StateCreated SessionState = iota
+
+	// StateInitialized is a session that is ready to launch a program or
+	// attach to a process.
+	StateInitialized
+
+	// StateLaunched is a session that launched a program, which doesn't
+	// run until configuration is done.
+	StateLaunched
+
+	// StateAttached is a session attached to a process, which doesn't
+	// run until configuration is done.
+	StateAttached
+
+	// StateConfigured is a session whose configuration is done, so the
+	// program has started, but that hasn't stopped yet.
+	StateConfigured
+
+	// StateRunning is a session whose program was resumed after a stop.
+	StateRunning
+
+	// StateStopped is a session whose program is stopped, e.g. at a
+	// breakpoint, and can be inspected.
+	StateStopped
+
+	// StateTerminated is a session whose program exited or was
+	// terminated.
+	StateTerminated
+)
+
+// String returns the name of the state.
+func (s SessionState) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StateInitialized:
+		return "initialized"
+	case StateLaunched:
+		return "launched"
+	case StateAttached:
+		return "attached"
+	case StateConfigured:
+		return "configured"
+	case StateRunning:
+		return "running"
+	case StateStopped:
+		return "stopped"
+	case StateTerminated:
+		return "terminated"
+	default:
+		return fmt.Sprintf("SessionState(%d)", int(s))
+	}
+}
+
+// ParseSessionState parses the name of a state, as returned by String.
+func ParseSessionState(name string) (SessionState, error) {
+	for s := StateCreated; s <= StateTerminated; s++ {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown session state %q", name)
+}
+
+var (
+	// programLoaded are the states in which a program is loaded and its
+	// breakpoints and threads can be queried.
+	programLoaded = []SessionState{
+		StateLaunched, StateAttached, StateConfigured, StateRunning,
+		StateStopped,
+	}
+
+	// programRunning are the states in which the program is running.
+	programRunning = []SessionState{StateConfigured, StateRunning}
+
+	// programStopped are the states in which the program can be
+	// inspected and resumed.
+	programStopped = []SessionState{StateStopped}
+)
+
+// toolStates lists the session states each tool can be called in. Tools that
+// aren't listed can be called in any state.
+var toolStates = map[string][]SessionState{
+	"initialize_session":   {StateCreated},
+	"launch_program":       {StateInitialized},
+	"launch_configuration": {StateInitialized},
+	"attach_to_process":    {StateInitialized},
+	"configuration_done":   {StateLaunched, StateAttached},
+
+	"set_breakpoints":           programLoaded,
+	"set_function_breakpoints":  programLoaded,
+	"set_exception_breakpoints": programLoaded,
+	"get_threads":               programLoaded,
+
+	"continue_execution": programStopped,
+	"step_next":          programStopped,
+	"step_in":            programStopped,
+	"step_out":           programStopped,
+	"pause_execution":    programRunning,
+	"run_until":          programStopped,
+	"trace_execution":    programStopped,
+	"profile_functions":  programStopped,
+
+	"get_stack_frames":    programStopped,
+	"get_variables":       programStopped,
+	"evaluate_expression": programStopped,
+}
+
+// currentState returns the session's lifecycle state.
+func (s *debugSession) currentState() SessionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state
+}
+
+// setState moves the session to the given state unless its program has
+// already terminated.
+func (s *debugSession) setState(state SessionState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != StateTerminated {
+		s.state = state
+	}
+}
+
+// advance moves the session to the given state if it is in one of the from
+// states, and reports whether it did. Events may have moved the session on
+// while a request was in flight, in which case they take precedence.
+func (s *debugSession) advance(to SessionState,
+	from ...SessionState) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !slices.Contains(from, s.state) {
+		return false
+	}
+	s.state = to
+
+	return true
+}
+
+// resume marks the session as running before a request resumes the program,
+// so that a stop reported before the response isn't overwritten. It returns
+// a function that restores the previous state if the request fails.
+func (s *debugSession) resume() func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.state
+	if prev != StateTerminated {
+		s.state = StateRunning
+	}
+
+	return func() {
+		s.advance(prev, StateRunning)
+	}
+}
+
+// trackEvent updates the session's state, stopped thread and stop count from
+// an event of its debug adapter.
+func (s *debugSession) trackEvent(event dap.EventMessage) {
+	switch e := event.(type) {
+	case *dap.StoppedEvent:
+		s.markStop(e)
+		s.setStoppedThread(e.Body.ThreadId)
+		s.setState(StateStopped)
+
+	case *dap.ContinuedEvent:
+		s.setStoppedThread(0)
+		s.setState(StateRunning)
+
+	case *dap.ExitedEvent, *dap.TerminatedEvent:
+		s.setStoppedThread(0)
+		s.setState(StateTerminated)
+	}
+}
+
+// checkState returns an error explaining what to call next if the tool can't
+// be called in the session's current state.
+func (s *debugSession) checkState(sessionID, tool string) error {
+	allowed, ok := toolStates[tool]
+	if !ok {
+		return nil
+	}
+
+	state := s.currentState()
+	if slices.Contains(allowed, state) {
+		return nil
+	}
+
+	return fmt.Errorf("session %s is %s: %s", sessionID, state,
+		nextStep(tool, state))
+}
+
+// nextStep tells a client what to do instead of calling a tool in a state
+// that doesn't allow it.
+func nextStep(tool string, state SessionState) string {
+	switch {
+	case state == StateTerminated:
+		return "the program has exited; close the session with " +
+			"close_debug_session and start a new one"
+
+	case tool == "initialize_session":
+		return "it is already initialized"
+
+	case toolStates[tool][0] == StateInitialized &&
+		state > StateInitialized:
+
+		return "a program is already loaded; create a new session " +
+			"to debug another one"
+
+	case tool == "configuration_done" && state > StateAttached:
+		return "configuration is already done"
+	}
+
+	switch state {
+	case StateCreated:
+		return "call initialize_session first"
+
+	case StateInitialized:
+		return "call launch_program, launch_configuration or " +
+			"attach_to_process first"
+
+	case StateLaunched, StateAttached:
+		return "set breakpoints, then call configuration_done to " +
+			"start the program"
+
+	case StateConfigured, StateRunning:
+		return "the program is running; wait for it to stop at a " +
+			"breakpoint or call pause_execution"
+
+	default:
+		return "the program is stopped; resume it with " +
+			"continue_execution or a step tool"
+	}
+}
+
+// enforceSessionState is a tool handler middleware that rejects calls the
+// session's lifecycle state doesn't allow, such as inspecting a program
+// before it stopped, before they reach the debug adapter. Calls naming
+// unknown sessions are left to the handler.
+func (mds *MCPDebugServer) enforceSessionState(
+	next server.ToolHandlerFunc) server.ToolHandlerFunc {
+
+	return func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		id, _ := request.GetArguments()["session_id"].(string)
+		if sess, ok := mds.sessions.get(id); ok {
+			err := sess.checkState(id, request.Params.Name)
+			if err != nil {
+				return errorResult("Cannot call %s: %v",
+					request.Params.Name, err), nil
+			}
+		}
+
+		return next(ctx, request)
+	}
+}
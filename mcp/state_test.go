@@ -0,0 +1,146 @@
+package mcp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/stretchr/testify/require"
+)
+
+// stopSession launches a program in an initialized session, completes
+// configuration and reports a stop of thread 1, as if it hit a breakpoint.
+func stopSession(t *testing.T, mds *MCPDebugServer, id string) {
+	t.Helper()
+
+	requireTool(t, mds, "launch_program", map[string]any{
+		"session_id": id,
+		"program":    "/usr/bin/true",
+	})
+	requireTool(t, mds, "configuration_done", map[string]any{
+		"session_id": id,
+	})
+
+	sess, ok := mds.sessions.get(id)
+	require.True(t, ok)
+	sess.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{Reason: "breakpoint", ThreadId: 1},
+	})
+	require.Equal(t, StateStopped, sess.currentState())
+}
+
+// requireState tests that a tool call is rejected because of the session's
+// state, with a message saying what to do instead.
+func requireState(t *testing.T, mds *MCPDebugServer, name string,
+	args map[string]any, state SessionState, next string) {
+
+	t.Helper()
+
+	var result ErrorResult
+	_, failed := callStructured(t, mds, name, args, &result)
+	require.True(t, failed, "%s wasn't rejected", name)
+	require.Contains(t, result.Error, "is "+state.String()+": ")
+	require.Contains(t, result.Error, next)
+}
+
+// TestSessionLifecycle tests that sessions move through their lifecycle as
+// tools are called and events arrive, and that tools called out of order are
+// rejected with the next step to take.
+func TestSessionLifecycle(t *testing.T) {
+	mds, _ := newTestServer(t)
+	id := map[string]any{"session_id": "app"}
+	thread := map[string]any{"session_id": "app", "thread_id": 1}
+
+	requireTool(t, mds, "create_debug_session", id)
+	sess, ok := mds.sessions.get("app")
+	require.True(t, ok)
+	require.Equal(t, StateCreated, sess.currentState())
+
+	requireState(t, mds, "launch_program", map[string]any{
+		"session_id": "app",
+		"program":    "/usr/bin/true",
+	}, StateCreated, "call initialize_session first")
+	requireState(t, mds, "get_variables", map[string]any{
+		"session_id": "app",
+		"frame_id":   1000,
+	}, StateCreated, "call initialize_session first")
+
+	requireTool(t, mds, "initialize_session", map[string]any{
+		"session_id": "app",
+		"client_id":  "test",
+	})
+	require.Equal(t, StateInitialized, sess.currentState())
+	requireState(t, mds, "initialize_session", map[string]any{
+		"session_id": "app",
+		"client_id":  "test",
+	}, StateInitialized, "already initialized")
+	requireState(t, mds, "set_breakpoints", map[string]any{
+		"session_id": "app",
+		"file":       "/src/main.go",
+		"lines":      []int{42},
+	}, StateInitialized, "call launch_program")
+
+	requireTool(t, mds, "launch_program", map[string]any{
+		"session_id": "app",
+		"program":    "/usr/bin/true",
+	})
+	require.Equal(t, StateLaunched, sess.currentState())
+	requireState(t, mds, "launch_program", map[string]any{
+		"session_id": "app",
+		"program":    "/usr/bin/true",
+	}, StateLaunched, "create a new session")
+	requireState(t, mds, "continue_execution", thread, StateLaunched,
+		"call configuration_done")
+
+	requireTool(t, mds, "set_breakpoints", map[string]any{
+		"session_id": "app",
+		"file":       "/src/main.go",
+		"lines":      []int{42},
+	})
+	requireTool(t, mds, "configuration_done", id)
+	require.Equal(t, StateConfigured, sess.currentState())
+	requireState(t, mds, "get_stack_frames", thread, StateConfigured,
+		"call pause_execution")
+
+	// Stops and resumes move the session between stopped and running.
+	sess.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{Reason: "breakpoint", ThreadId: 1},
+	})
+	require.Equal(t, StateStopped, sess.currentState())
+	requireTool(t, mds, "get_stack_frames", thread)
+	requireState(t, mds, "pause_execution", thread, StateStopped,
+		"continue_execution")
+
+	requireTool(t, mds, "continue_execution", thread)
+	require.Equal(t, StateRunning, sess.currentState())
+	requireTool(t, mds, "pause_execution", thread)
+
+	// A failed resume leaves the session stopped.
+	sess.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{Reason: "pause", ThreadId: 1},
+	})
+	restore := sess.resume()
+	require.Equal(t, StateRunning, sess.currentState())
+	restore()
+	require.Equal(t, StateStopped, sess.currentState())
+
+	sess.events.Publish(&dap.ExitedEvent{})
+	sess.events.Publish(&dap.TerminatedEvent{})
+	require.Equal(t, StateTerminated, sess.currentState())
+	requireState(t, mds, "get_threads", id, StateTerminated,
+		"close_debug_session")
+
+	// The state is listed with the session.
+	infos := mds.SessionInfos()
+	require.Len(t, infos, 1)
+	require.Equal(t, "app", infos[0].ID)
+	require.Equal(t, StateTerminated, infos[0].State)
+	require.Equal(t, "/usr/bin/true", infos[0].Program)
+	require.Equal(t, 1, infos[0].Breakpoints)
+
+	var summaries []sessionSummary
+	require.NoError(t, json.Unmarshal(
+		[]byte(readResource(t, mds, sessionsResourceURI)), &summaries,
+	))
+	require.Equal(t, "terminated", summaries[0].State)
+}
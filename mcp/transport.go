@@ -0,0 +1,292 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+)
+
+// Transport selects how MCP clients connect to the server.
+type Transport string
+
+const (
+	// TransportStdio serves a single client over stdin and stdout.
+	TransportStdio Transport = "stdio"
+
+	// TransportSSE serves any number of clients over HTTP with server-sent
+	// events, at /sse and /message.
+	TransportSSE Transport = "sse"
+
+	// TransportStreamableHTTP serves any number of clients over the
+	// streamable HTTP transport, at /mcp.
+	TransportStreamableHTTP Transport = "http"
+)
+
+const (
+	// DefaultListenAddress is the address HTTP transports listen on if
+	// none is given. It is only reachable from the local machine.
+	DefaultListenAddress = "localhost:8765"
+
+	// unixAddressPrefix marks listen addresses that are Unix socket
+	// paths.
+	unixAddressPrefix = "unix:"
+
+	// shutdownTimeout is how long open HTTP connections are given to
+	// finish once the server is stopped.
+	shutdownTimeout = 5 * time.Second
+
+	// allowOriginHeader is the CORS header naming the origins that may
+	// read a response.
+	allowOriginHeader = "Access-Control-Allow-Origin"
+)
+
+// ParseTransport parses the name of a transport.
+func ParseTransport(name string) (Transport, error) {
+	switch transport := Transport(name); transport {
+	case TransportStdio, TransportSSE, TransportStreamableHTTP:
+		return transport, nil
+
+	default:
+		return "", fmt.Errorf("unknown transport %q, expected %q, %q "+
+			"or %q", name, TransportStdio, TransportSSE,
+			TransportStreamableHTTP)
+	}
+}
+
+// Listen opens a listener for an HTTP transport. Addresses of the form
+// unix:<path> listen on a Unix socket that only the current user can connect
+// to, replacing a stale socket left at the path. Any other address is a TCP
+// host:port.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixAddressPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	// A socket that nothing listens on anymore is left behind by a
+	// server that didn't shut down cleanly.
+	if info, err := os.Stat(path); err == nil &&
+		info.Mode()&os.ModeSocket != 0 {
+
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another "+
+				"server", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("unable to remove stale "+
+				"socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("unable to restrict socket "+
+			"permissions: %w", err)
+	}
+
+	return ln, nil
+}
+
+// localOnly rejects requests that a web page could make to a server on the
+// local machine. Browsers send the Origin of the page making a request, which
+// must be local, and pages that rebound their own domain name to a local
+// address still send that name as the Host, so for TCP listeners the Host
+// must be localhost or an IP address the server listens on. The SSE server
+// allows every origin to read its responses, so that header is narrowed to
+// the request's local origin.
+func localOnly(next http.Handler, addr net.Addr) http.Handler {
+	tcp, _ := addr.(*net.TCPAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && !localOrigin(origin) {
+			http.Error(w, "forbidden origin", http.StatusForbidden)
+			return
+		}
+		if tcp != nil && !localHost(r.Host, tcp) {
+			http.Error(w, "forbidden host", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(&originWriter{ResponseWriter: w, origin: origin}, r)
+	})
+}
+
+// localOrigin reports whether an Origin header names a page served from the
+// local machine.
+func localOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	return loopbackHost(u.Hostname())
+}
+
+// localHost reports whether a Host header names the server rather than a
+// domain that merely resolves to it. IP addresses can't be rebound, so the
+// address the server listens on is accepted as well, and any address if it
+// listens on all of them.
+func localHost(host string, addr *net.TCPAddr) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+	if loopbackHost(host) {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	return addr.IP.IsUnspecified() || addr.IP.Equal(ip)
+}
+
+// loopbackHost reports whether a host name or IP address refers to the local
+// machine.
+func loopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// originWriter replaces an Access-Control-Allow-Origin header allowing every
+// origin with the origin of the request, or drops it if the request had none.
+type originWriter struct {
+	http.ResponseWriter
+
+	origin      string
+	wroteHeader bool
+}
+
+// WriteHeader narrows the allowed origin before sending the headers.
+func (w *originWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+
+		header := w.Header()
+		if header.Get(allowOriginHeader) == "*" {
+			header.Del(allowOriginHeader)
+			if w.origin != "" {
+				header.Set(allowOriginHeader, w.origin)
+				header.Add("Vary", "Origin")
+			}
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write sends the headers if they weren't sent yet, then writes the body.
+func (w *originWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush sends buffered data to the client, which the SSE and streamable HTTP
+// servers rely on to stream events.
+func (w *originWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *originWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ServeListener serves MCP clients over an HTTP transport on the given
+// listener until the context is cancelled. All clients share the server's
+// debug sessions. Requests from web pages and under host names other than
+// localhost are rejected, so that pages open in a browser can't drive the
+// debugger.
+func (mds *MCPDebugServer) ServeListener(ctx context.Context,
+	ln net.Listener, transport Transport) error {
+
+	srv := &http.Server{}
+
+	var shutdown func(context.Context) error
+	switch transport {
+	case TransportSSE:
+		sse := server.NewSSEServer(mds.server,
+			server.WithHTTPServer(srv),
+			server.WithKeepAlive(true),
+		)
+		srv.Handler = sse
+		shutdown = sse.Shutdown
+
+	case TransportStreamableHTTP:
+		streamable := server.NewStreamableHTTPServer(mds.server,
+			server.WithStreamableHTTPServer(srv),
+		)
+		mux := http.NewServeMux()
+		mux.Handle("/mcp", streamable)
+		srv.Handler = mux
+		shutdown = streamable.Shutdown
+
+	default:
+		return fmt.Errorf("%s is not an HTTP transport", transport)
+	}
+	srv.Handler = localOnly(srv.Handler, ln.Addr())
+
+	logging.Component("mcp").Info("Serving MCP", "transport", string(transport),
+		"address", ln.Addr().String())
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(), shutdownTimeout,
+	)
+	defer cancel()
+
+	// Clients that keep a stream open are cut off once the timeout
+	// expires.
+	if err := shutdown(shutdownCtx); err != nil {
+		logging.Component("mcp").Warn("Closing remaining MCP connections", "err", err)
+		srv.Close()
+	}
+
+	if err := <-errChan; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// serveTransport serves the server over an HTTP transport on a local port and
+// returns its base URL.
+func serveTransport(t *testing.T, mds *MCPDebugServer,
+	transport Transport) string {
+
+	t.Helper()
+
+	ln, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- mds.ServeListener(ctx, ln, transport)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	return "http://" + ln.Addr().String()
+}
+
+// connectClient initializes an MCP client and closes it when the test ends.
+func connectClient(t *testing.T, c *client.Client) {
+	t.Helper()
+
+	t.Cleanup(func() { c.Close() })
+
+	// The SSE stream lives as long as the context passed to Start.
+	require.NoError(t, c.Start(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: t.Name()}
+	_, err := c.Initialize(ctx, init)
+	require.NoError(t, err)
+}
+
+// callClientTool calls a tool through an MCP client and returns its text.
+func callClientTool(t *testing.T, c *client.Client, name string,
+	args map[string]any) string {
+
+	t.Helper()
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+	result, err := c.CallTool(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, "%s failed: %+v", name, result)
+
+	text, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+
+	return text.Text
+}
+
+// TestHTTPTransports tests that clients connected over the HTTP transports
+// share the server's sessions.
+func TestHTTPTransports(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	sseURL := serveTransport(t, mds, TransportSSE)
+	httpURL := serveTransport(t, mds, TransportStreamableHTTP)
+
+	sseClient, err := client.NewSSEMCPClient(sseURL + "/sse")
+	require.NoError(t, err)
+	connectClient(t, sseClient)
+
+	httpClient, err := client.NewStreamableHttpClient(httpURL + "/mcp")
+	require.NoError(t, err)
+	connectClient(t, httpClient)
+
+	callClientTool(t, sseClient, "create_debug_session", map[string]any{
+		"session_id": "shared",
+	})
+
+	// The session created over SSE is visible to the other client.
+	text := callClientTool(t, httpClient, "list_watches", map[string]any{
+		"session_id": "shared",
+	})
+	require.Equal(t, "No watch expressions", text)
+
+	callClientTool(t, httpClient, "close_debug_session", map[string]any{
+		"session_id": "shared",
+	})
+	_, exists := mds.sessions.get("shared")
+	require.False(t, exists)
+}
+
+// TestHTTPTransportOrigins tests that the HTTP transports reject requests
+// from web pages and under host names other than localhost, and only allow
+// local origins to read SSE responses.
+func TestHTTPTransportOrigins(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	sseURL := serveTransport(t, mds, TransportSSE)
+	httpURL := serveTransport(t, mds, TransportStreamableHTTP)
+
+	get := func(url, host, origin string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		if host != "" {
+			req.Host = host
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+
+		return resp
+	}
+
+	for _, url := range []string{sseURL + "/sse", httpURL + "/mcp"} {
+		resp := get(url, "", "https://evil.example")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, url)
+
+		resp = get(url, "rebound.example:8765", "")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, url)
+	}
+
+	// Local pages may connect, and are the only origin allowed to read
+	// the stream.
+	resp := get(sseURL+"/sse", "localhost:8765", "http://localhost:3000")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "http://localhost:3000",
+		resp.Header.Get(allowOriginHeader))
+
+	resp = get(sseURL+"/sse", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get(allowOriginHeader))
+}
+
+// TestListenUnix tests that Unix socket listeners replace stale sockets but
+// not ones that are in use.
+func TestListenUnix(t *testing.T) {
+	_, err := ParseTransport("websocket")
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	addr := unixAddressPrefix + path
+
+	ln, err := Listen(addr)
+	require.NoError(t, err)
+
+	_, err = Listen(addr)
+	require.ErrorContains(t, err, "in use")
+
+	// Leave the socket file behind as a crashed server would.
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, ln.Close())
+
+	ln, err = Listen(addr)
+	require.NoError(t, err)
+	require.NoError(t, ln.Close())
+}
@@ -0,0 +1,272 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/roasbeef/mcp-debug/debugger"
+)
+
+// WatchArgs represents the arguments for adding or removing watch
+// expressions.
+type WatchArgs struct {
+	SessionID   string   `json:"session_id"`
+	Expressions []string `json:"expressions"`
+}
+
+// ListWatchesArgs represents the arguments for listing watch expressions.
+type ListWatchesArgs struct {
+	SessionID string `json:"session_id"`
+}
+
+// WatchHistoryArgs represents the arguments for fetching the history of a
+// watch expression.
+type WatchHistoryArgs struct {
+	SessionID  string `json:"session_id"`
+	Expression string `json:"expression"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+// registerAddWatchTool registers the add watch tool.
+func (mds *MCPDebugServer) registerAddWatchTool() {
+	tool := mcp.NewTool("add_watch",
+		mcp.WithDescription("Add watch expressions that are evaluated in the top frame of the stopped thread every time the program stops. The values are included in the stopped event notification and kept as a history, see list_watches and get_watch_history"),
+		mcp.WithOutputSchema[WatchesResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithArray("expressions", mcp.Required(),
+			mcp.Description("Go expressions to watch, e.g. ['len(queue)', 'w.state']"),
+			mcp.Items(map[string]any{"type": "string"})),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args WatchArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		added := sess.watches.Add(args.Expressions...)
+
+		text := "No new watch expressions"
+		if len(added) > 0 {
+			text = fmt.Sprintf("Watching %s",
+				strings.Join(added, ", "))
+		}
+
+		return mcp.NewToolResultStructured(
+			WatchesResult{
+				SessionID: args.SessionID,
+				Added:     added,
+				Watches:   watchesOrEmpty(sess.watchList()),
+			},
+			text,
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerRemoveWatchTool registers the remove watch tool.
+func (mds *MCPDebugServer) registerRemoveWatchTool() {
+	tool := mcp.NewTool("remove_watch",
+		mcp.WithDescription("Stop watching expressions and discard their history"),
+		mcp.WithOutputSchema[WatchesResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithArray("expressions", mcp.Required(),
+			mcp.Description("Watch expressions to remove, exactly as they were added"),
+			mcp.Items(map[string]any{"type": "string"})),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args WatchArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		removed := sess.watches.Remove(args.Expressions...)
+		if len(removed) == 0 {
+			return errorResult(
+				"None of the expressions are "+
+					"watched, current watches: %s",
+				strings.Join(
+					sess.watchList(), ", ")), nil
+		}
+
+		return mcp.NewToolResultStructured(
+			WatchesResult{
+				SessionID: args.SessionID,
+				Removed:   removed,
+				Watches:   watchesOrEmpty(sess.watchList()),
+			},
+			fmt.Sprintf("Removed %s", strings.Join(removed, ", ")),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerListWatchesTool registers the list watches tool.
+func (mds *MCPDebugServer) registerListWatchesTool() {
+	tool := mcp.NewTool("list_watches",
+		mcp.WithDescription("List the session's watch expressions with the values recorded at the most recent stop"),
+		mcp.WithOutputSchema[WatchListResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args ListWatchesArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		result := WatchListResult{
+			SessionID: args.SessionID,
+			Watches:   []WatchStatus{},
+		}
+
+		expressions := sess.watchList()
+		if len(expressions) == 0 {
+			return mcp.NewToolResultStructured(
+				result, "No watch expressions",
+			), nil
+		}
+
+		latest := make(map[string]debugger.WatchSample)
+		for _, sample := range sess.watches.Latest() {
+			latest[sample.Expression] = sample
+		}
+
+		var text strings.Builder
+		text.WriteString("Watches:\n")
+		for _, expr := range expressions {
+			sample, ok := latest[expr]
+			status := WatchStatus{Expression: expr}
+			if ok {
+				status.Latest = &sample
+			}
+			result.Watches = append(result.Watches, status)
+
+			if !ok {
+				fmt.Fprintf(&text, "- %s: not evaluated yet\n",
+					expr)
+				continue
+			}
+
+			fmt.Fprintf(&text, "- %s = %s (stop %d%s)\n", expr,
+				formatWatchValue(sample.WatchValue), sample.Stop,
+				formatWatchLocation(sample.Location))
+		}
+
+		return mcp.NewToolResultStructured(
+			result, strings.TrimRight(text.String(), "\n"),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerGetWatchHistoryTool registers the watch history tool.
+func (mds *MCPDebugServer) registerGetWatchHistoryTool() {
+	tool := mcp.NewTool("get_watch_history",
+		mcp.WithDescription("Get the values a watch expression had at each stop, oldest first"),
+		mcp.WithOutputSchema[WatchHistoryResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithString("expression", mcp.Required(),
+			mcp.Description("Watch expression, exactly as it was added")),
+		mcp.WithNumber("limit",
+			mcp.Description("Only return the most recent values (default: all recorded, up to 100)")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args WatchHistoryArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		history, ok := sess.watches.History(args.Expression)
+		if !ok {
+			return errorResult(
+				"%q is not watched", args.Expression), nil
+		}
+
+		result := WatchHistoryResult{
+			SessionID:  args.SessionID,
+			Expression: args.Expression,
+			History:    []debugger.WatchSample{},
+		}
+		if len(history) == 0 {
+			return mcp.NewToolResultStructured(result, fmt.Sprintf(
+				"%s has not been evaluated yet",
+				args.Expression,
+			)), nil
+		}
+
+		if args.Limit > 0 && len(history) > args.Limit {
+			history = history[len(history)-args.Limit:]
+		}
+		result.History = history
+
+		var text strings.Builder
+		fmt.Fprintf(&text, "History of %s:\n", args.Expression)
+		for _, sample := range history {
+			fmt.Fprintf(&text, "- stop %d at %s%s: %s\n",
+				sample.Stop, sample.Time.Format(time.TimeOnly),
+				formatWatchLocation(sample.Location),
+				formatWatchValue(sample.WatchValue))
+		}
+
+		return mcp.NewToolResultStructured(
+			result, strings.TrimRight(text.String(), "\n"),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// watchesOrEmpty returns the watch expressions, or an empty list rather than
+// nil so that they are never encoded as null.
+func watchesOrEmpty(watches []string) []string {
+	if watches == nil {
+		return []string{}
+	}
+
+	return watches
+}
+
+// formatWatchValue renders a watch value or the reason it is unavailable.
+func formatWatchValue(value debugger.WatchValue) string {
+	if value.Error != "" {
+		return "<error: " + value.Error + ">"
+	}
+
+	if value.Type != "" {
+		return fmt.Sprintf("%s (%s)", value.Value, value.Type)
+	}
+
+	return value.Value
+}
+
+// formatWatchLocation renders the location a watch was evaluated at as a
+// suffix, or nothing if it isn't known.
+func formatWatchLocation(loc *debugger.StopLocation) string {
+	if loc == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(", %s:%d", filepath.Base(loc.File), loc.Line)
+}
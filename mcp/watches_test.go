@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-dap"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWatches tests that watch expressions are evaluated when a session stops,
+// reported with the stop and kept as a history.
+func TestWatches(t *testing.T) {
+	mds, _ := newTestServer(t)
+
+	client := &fakeClient{
+		id:            "agent",
+		notifications: make(chan mcp.JSONRPCNotification, 10),
+	}
+	require.NoError(t, mds.server.RegisterSession(
+		context.Background(), client,
+	))
+	ctx := mds.server.WithContext(context.Background(), client)
+
+	result, err := callTool(ctx, mds, "create_debug_session",
+		map[string]any{"session_id": "watched"})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	text := requireTool(t, mds, "add_watch", map[string]any{
+		"session_id":  "watched",
+		"expressions": []string{"queue", "items"},
+	})
+	require.Equal(t, "Watching queue, items", text)
+
+	text = requireTool(t, mds, "list_watches", map[string]any{
+		"session_id": "watched",
+	})
+	require.Contains(t, text, "- queue: not evaluated yet")
+
+	sess, ok := mds.sessions.get("watched")
+	require.True(t, ok)
+	sess.events.Publish(&dap.StoppedEvent{
+		Body: dap.StoppedEventBody{Reason: "breakpoint", ThreadId: 1},
+	})
+
+	data := client.nextEvent(t, "stopped")
+	require.Equal(t, "watched", data["session_id"])
+	require.Equal(t, "breakpoint", data["reason"])
+	require.Equal(t, 1, data["thread_id"])
+	require.Len(t, data["watches"], 2)
+
+	text = requireTool(t, mds, "list_watches", map[string]any{
+		"session_id": "watched",
+	})
+	require.Contains(t, text, "- queue = len(queue) (int) (stop 1, "+
+		"main.go:42)")
+
+	text = requireTool(t, mds, "get_watch_history", map[string]any{
+		"session_id": "watched",
+		"expression": "items",
+	})
+	require.Contains(t, text, "main.go:42: len(items) (int)")
+
+	requireTool(t, mds, "remove_watch", map[string]any{
+		"session_id":  "watched",
+		"expressions": []string{"items"},
+	})
+	result, err = callTool(context.Background(), mds, "get_watch_history",
+		map[string]any{"session_id": "watched", "expression": "items"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	result, err = callTool(context.Background(), mds, "remove_watch",
+		map[string]any{
+			"session_id":  "watched",
+			"expressions": []string{"items"},
+		})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
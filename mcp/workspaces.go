@@ -0,0 +1,373 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/logging"
+	"github.com/roasbeef/mcp-debug/internal/workspace"
+)
+
+// defaultWorkspaceClientID is the DAP client ID used when loading a workspace
+// without one.
+const defaultWorkspaceClientID = "dlv-mcp-server"
+
+// SaveWorkspaceArgs represents the arguments for saving a workspace.
+type SaveWorkspaceArgs struct {
+	SessionID string   `json:"session_id"`
+	Name      string   `json:"name"`
+	Watches   []string `json:"watches,omitempty"`
+}
+
+// LoadWorkspaceArgs represents the arguments for loading a workspace.
+type LoadWorkspaceArgs struct {
+	Name      string `json:"name"`
+	SessionID string `json:"session_id,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+}
+
+// WithWorkspaceStore overrides the store workspaces are saved in, which
+// defaults to ~/.dlv-mcp-server/workspaces.
+func WithWorkspaceStore(store *workspace.Store) ServerOption {
+	return func(mds *MCPDebugServer) {
+		mds.workspaces = store
+	}
+}
+
+// workspaceStore returns the store workspaces are saved in.
+func (mds *MCPDebugServer) workspaceStore() (*workspace.Store, error) {
+	if mds.workspaces != nil {
+		return mds.workspaces, nil
+	}
+
+	return workspace.DefaultStore()
+}
+
+// registerSaveWorkspaceTool registers the save workspace tool.
+func (mds *MCPDebugServer) registerSaveWorkspaceTool() {
+	tool := mcp.NewTool("save_workspace",
+		mcp.WithDescription("Save a launched session's setup as a named workspace: the launch configuration, source breakpoints (with conditions and logpoints), function breakpoints, exception filters and watch expressions. Restore it later with load_workspace"),
+		mcp.WithOutputSchema[WorkspaceResult](),
+		mcp.WithString("session_id", mcp.Required(),
+			mcp.Description("Session identifier")),
+		mcp.WithString("name", mcp.Required(),
+			mcp.Description("Workspace name (letters, digits, '.', '_' and '-'). An existing workspace with the same name is replaced")),
+		mcp.WithArray("watches",
+			mcp.Description("Watch expressions to save, replacing the session's"),
+			mcp.Items(map[string]any{"type": "string"})),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args SaveWorkspaceArgs) (*mcp.CallToolResult, error) {
+
+		sess, exists := mds.sessions.get(args.SessionID)
+		if !exists {
+			return sessionNotFound(args.SessionID), nil
+		}
+
+		launch := sess.launchConfig()
+		if launch == nil {
+			return errorResult(
+				"Session %s has not launched a program; "+
+					"only launched sessions can be "+
+					"saved", args.SessionID), nil
+		}
+
+		watches := args.Watches
+		if watches == nil {
+			watches = sess.watchList()
+		}
+
+		ws := &workspace.Workspace{
+			Name:                args.Name,
+			Launch:              *launch,
+			Breakpoints:         sess.breakpoints.SourceBreakpoints(),
+			FunctionBreakpoints: sess.breakpoints.FunctionBreakpoints(),
+			ExceptionFilters:    sess.breakpoints.ExceptionFilters(),
+			Watches:             watches,
+			SavedAt:             time.Now(),
+		}
+
+		store, err := mds.workspaceStore()
+		if err == nil {
+			err = store.Save(ws)
+		}
+		if err != nil {
+			return errorResult(
+				"Failed to save workspace: %v", err), nil
+		}
+		sess.setWatches(watches)
+
+		return mcp.NewToolResultStructured(
+			WorkspaceResult{Workspace: redactWorkspace(ws)},
+			fmt.Sprintf("Saved workspace %s: %s", ws.Name,
+				describeWorkspace(ws)),
+		), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// registerLoadWorkspaceTool registers the load workspace tool.
+func (mds *MCPDebugServer) registerLoadWorkspaceTool() {
+	tool := mcp.NewTool("load_workspace",
+		mcp.WithDescription("Create a new session from a saved workspace: initializes it, launches the program, reapplies all breakpoints, exception filters and watches, and completes configuration so the program starts running"),
+		mcp.WithOutputSchema[LoadWorkspaceResult](),
+		mcp.WithString("name", mcp.Required(),
+			mcp.Description("Workspace name")),
+		mcp.WithString("session_id",
+			mcp.Description("Identifier for the new session. Defaults to the workspace name")),
+		mcp.WithString("client_id",
+			mcp.Description("Client identifier sent when initializing the session")),
+	)
+
+	handler := mcp.NewTypedToolHandler(func(ctx context.Context,
+		request mcp.CallToolRequest, args LoadWorkspaceArgs) (*mcp.CallToolResult, error) {
+
+		store, err := mds.workspaceStore()
+		if err != nil {
+			return errorResult(
+				"Failed to load workspace: %v", err), nil
+		}
+
+		ws, err := store.Load(args.Name)
+		if err != nil {
+			return errorResult(
+				"Failed to load workspace: %v", err), nil
+		}
+
+		sessionID := getStringOrDefault(args.SessionID, ws.Name)
+		sess, err := mds.createSession(ctx, sessionID)
+		if err != nil {
+			return errorResult(
+				"Cannot create session %s: %v",
+				sessionID, err), nil
+		}
+
+		clientID := getStringOrDefault(
+			args.ClientID, defaultWorkspaceClientID,
+		)
+		result, report, err := mds.applyWorkspace(
+			ctx, sessionID, sess, clientID, ws,
+		)
+		if err != nil {
+			// Don't leave a half configured session behind, so the
+			// workspace can simply be loaded again.
+			if sess, ok := mds.sessions.remove(sessionID); ok {
+				cerr := mds.closeSession(ctx, sessionID, sess, true)
+				if cerr != nil {
+					logging.Component("mcp").Error("Failed to close "+
+						"session", logging.KeySession,
+						sessionID, "err", cerr)
+				}
+			}
+
+			return errorResult(
+				"Failed to load workspace %s: %v",
+				ws.Name, err), nil
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf(
+			"Loaded workspace %s into session %s\n%s", ws.Name,
+			sessionID, report,
+		)), nil
+	})
+
+	mds.server.AddTool(tool, handler)
+}
+
+// applyWorkspace initializes and launches a new session and applies the
+// workspace's breakpoints and watches, returning the resulting breakpoint
+// statuses along with a report of what was applied.
+func (mds *MCPDebugServer) applyWorkspace(ctx context.Context,
+	sessionID string, sess *debugSession, clientID string,
+	ws *workspace.Workspace) (*LoadWorkspaceResult, string, error) {
+
+	// Workspace files can be edited by hand, so their watches are checked
+	// like those added by a client.
+	for _, watch := range ws.Watches {
+		if err := mds.policy.checkExpression(watch); err != nil {
+			return nil, "", err
+		}
+	}
+
+	_, err := mds.initializeSession(ctx, sess, clientID)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to initialize session: %w", err)
+	}
+
+	_, err = mds.launchSession(ctx, sessionID, sess, ws.Launch)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to launch program: %w", err)
+	}
+
+	result := &LoadWorkspaceResult{
+		SessionID:           sessionID,
+		Workspace:           redactWorkspace(ws),
+		Breakpoints:         []debugger.BreakpointStatus{},
+		FunctionBreakpoints: []FunctionBreakpointStatus{},
+	}
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "Launched %s\n", ws.Launch.Program)
+
+	// Breakpoints are set one file at a time, keeping the saved order.
+	var (
+		files  []string
+		byFile = make(map[string][]debugger.BreakpointLocation)
+	)
+	for _, bp := range ws.Breakpoints {
+		if _, ok := byFile[bp.File]; !ok {
+			files = append(files, bp.File)
+		}
+		byFile[bp.File] = append(byFile[bp.File], bp)
+	}
+
+	statuses := result.Breakpoints
+	for _, file := range files {
+		fileStatuses, err := sess.breakpoints.SetBreakpoints(
+			ctx, sess.ref, byFile[file],
+		)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to set breakpoints in %s: "+
+				"%w", file, err)
+		}
+		statuses = append(statuses, fileStatuses...)
+	}
+	result.Breakpoints = statuses
+	if len(statuses) > 0 {
+		report.WriteString(formatBreakpointStatuses(
+			"Breakpoints:", statuses))
+		report.WriteString("\n")
+	}
+
+	if len(ws.FunctionBreakpoints) > 0 {
+		resp, err := sess.breakpoints.SetFunctionBreakpoints(
+			ctx, sess.ref, ws.FunctionBreakpoints,
+		)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to set function "+
+				"breakpoints: %w", err)
+		}
+		result.FunctionBreakpoints = functionBreakpointStatuses(
+			ws.FunctionBreakpoints, resp.Body.Breakpoints,
+		)
+		report.WriteString(formatFunctionBreakpoints(
+			result.FunctionBreakpoints))
+		report.WriteString("\n")
+	}
+
+	if len(ws.ExceptionFilters) > 0 {
+		err := sess.breakpoints.SetExceptionBreakpoints(
+			ctx, sess.ref, ws.ExceptionFilters,
+		)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to set exception "+
+				"breakpoints: %w", err)
+		}
+		fmt.Fprintf(&report, "Exception filters: %s\n",
+			strings.Join(ws.ExceptionFilters, ", "))
+	}
+
+	sess.setWatches(ws.Watches)
+	if len(ws.Watches) > 0 {
+		fmt.Fprintf(&report, "Watches: %s\n",
+			strings.Join(ws.Watches, ", "))
+	}
+
+	if err := mds.configureSession(ctx, sess); err != nil {
+		return nil, "", fmt.Errorf("unable to complete configuration: %w",
+			err)
+	}
+	report.WriteString("Configuration done")
+
+	return result, report.String(), nil
+}
+
+// registerListWorkspacesTool registers the list workspaces tool.
+func (mds *MCPDebugServer) registerListWorkspacesTool() {
+	tool := mcp.NewTool("list_workspaces",
+		mcp.WithDescription("List the saved workspaces"),
+		mcp.WithOutputSchema[WorkspacesResult](),
+	)
+
+	handler := func(ctx context.Context,
+		request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+
+		store, err := mds.workspaceStore()
+		var names []string
+		if err == nil {
+			names, err = store.List()
+		}
+		if err != nil {
+			return errorResult(
+				"Failed to list workspaces: %v", err), nil
+		}
+
+		result := WorkspacesResult{
+			Workspaces: []*workspace.Workspace{},
+		}
+		if len(names) == 0 {
+			return mcp.NewToolResultStructured(
+				result, "No saved workspaces",
+			), nil
+		}
+
+		var text strings.Builder
+		text.WriteString("Workspaces:\n")
+		for _, name := range names {
+			ws, err := store.Load(name)
+			switch {
+			case errors.Is(err, workspace.ErrNotFound):
+				// Deleted since it was listed.
+				continue
+
+			case err != nil:
+				if result.Unreadable == nil {
+					result.Unreadable = make(map[string]string)
+				}
+				result.Unreadable[name] = err.Error()
+
+				fmt.Fprintf(&text, "- %s: unreadable (%v)\n", name,
+					err)
+				continue
+			}
+
+			result.Workspaces = append(
+				result.Workspaces, redactWorkspace(ws),
+			)
+			fmt.Fprintf(&text, "- %s: %s (saved %s)\n", name,
+				describeWorkspace(ws),
+				ws.SavedAt.Format(time.RFC3339))
+		}
+
+		return mcp.NewToolResultStructured(
+			result, strings.TrimRight(text.String(), "\n"),
+		), nil
+	}
+
+	mds.server.AddTool(tool, handler)
+}
+
+// describeWorkspace summarises the content of a workspace in one line.
+func describeWorkspace(ws *workspace.Workspace) string {
+	return fmt.Sprintf("%s with %d breakpoints, %d function breakpoints, "+
+		"%d exception filters and %d watches", ws.Launch.Program,
+		len(ws.Breakpoints), len(ws.FunctionBreakpoints),
+		len(ws.ExceptionFilters), len(ws.Watches))
+}
+
+// redactWorkspace returns a copy of a workspace to show to clients, with the
+// values of its environment variables redacted like those of launch
+// configurations.
+func redactWorkspace(ws *workspace.Workspace) *workspace.Workspace {
+	shown := *ws
+	shown.Launch = redactEnv(ws.Launch)
+
+	return &shown
+}
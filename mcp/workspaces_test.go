@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/roasbeef/mcp-debug/debugger"
+	"github.com/roasbeef/mcp-debug/internal/workspace"
+	"github.com/stretchr/testify/require"
+)
+
+// requireTool calls a tool and requires it to succeed, returning its text.
+func requireTool(t *testing.T, mds *MCPDebugServer, name string,
+	args map[string]any) string {
+
+	t.Helper()
+
+	result, err := callTool(context.Background(), mds, name, args)
+	require.NoError(t, err)
+
+	text := result.Content[0].(mcp.TextContent).Text
+	require.False(t, result.IsError, "%s failed: %s", name, text)
+
+	return text
+}
+
+// requireSession creates and initializes a session, ready to launch a
+// program.
+func requireSession(t *testing.T, mds *MCPDebugServer, id string) {
+	t.Helper()
+
+	requireTool(t, mds, "create_debug_session", map[string]any{
+		"session_id": id,
+	})
+	requireTool(t, mds, "initialize_session", map[string]any{
+		"session_id": id,
+		"client_id":  "test",
+	})
+}
+
+// TestWorkspaceSaveLoad tests that a session's setup saved as a workspace is
+// restored in a new session by load_workspace.
+func TestWorkspaceSaveLoad(t *testing.T) {
+	store := workspace.NewStore(t.TempDir())
+	mds, fake := newTestServer(t, WithWorkspaceStore(store))
+
+	requireSession(t, mds, "original")
+
+	// Sessions that haven't launched a program can't be saved.
+	result, err := callTool(context.Background(), mds, "save_workspace",
+		map[string]any{"session_id": "original", "name": "svc"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+
+	requireTool(t, mds, "launch_program", map[string]any{
+		"session_id": "original",
+		"program":    "/usr/bin/true",
+		"args":       []string{"-v"},
+	})
+	requireTool(t, mds, "set_breakpoints", map[string]any{
+		"session_id": "original",
+		"file":       "/src/main.go",
+		"lines":      []int{10},
+		"breakpoints": []map[string]any{
+			{"line": 20, "condition": "x > 1"},
+			{"line": 30, "log_message": "x={x}"},
+		},
+	})
+	requireTool(t, mds, "set_function_breakpoints", map[string]any{
+		"session_id": "original",
+		"breakpoints": []map[string]any{
+			{"name": "main.handle", "hit_condition": "3"},
+		},
+	})
+	requireTool(t, mds, "set_exception_breakpoints", map[string]any{
+		"session_id": "original",
+		"filters":    []string{"panic"},
+	})
+	requireTool(t, mds, "save_workspace", map[string]any{
+		"session_id": "original",
+		"name":       "svc",
+		"watches":    []string{"len(queue)"},
+	})
+
+	saved, err := store.Load("svc")
+	require.NoError(t, err)
+	require.Equal(t, "/usr/bin/true", saved.Launch.Program)
+	require.Equal(t, []string{"-v"}, saved.Launch.Args)
+	require.Empty(t, saved.Launch.Output)
+	require.Equal(t, []debugger.BreakpointLocation{
+		{File: "/src/main.go", Line: 10},
+		{File: "/src/main.go", Line: 20, Condition: "x > 1"},
+		{File: "/src/main.go", Line: 30, LogMessage: "x={x}"},
+	}, saved.Breakpoints)
+	require.Equal(t, []debugger.FunctionBreakpoint{
+		{Name: "main.handle", HitCondition: "3"},
+	}, saved.FunctionBreakpoints)
+	require.Equal(t, []string{"panic"}, saved.ExceptionFilters)
+	require.Equal(t, []string{"len(queue)"}, saved.Watches)
+
+	text := requireTool(t, mds, "list_workspaces", nil)
+	require.Contains(t, text, "svc: /usr/bin/true with 3 breakpoints")
+
+	// Loading the workspace creates a new session with everything
+	// reapplied.
+	text = requireTool(t, mds, "load_workspace", map[string]any{
+		"name": "svc",
+	})
+	require.Contains(t, text, "into session svc")
+	require.Contains(t, text, "- main.handle: verified")
+	require.Contains(t, text, "Configuration done")
+
+	sess, ok := mds.sessions.get("svc")
+	require.True(t, ok)
+	require.Equal(t, saved.Breakpoints, sess.breakpoints.SourceBreakpoints())
+	require.Equal(t, saved.FunctionBreakpoints,
+		sess.breakpoints.FunctionBreakpoints())
+	require.Equal(t, saved.ExceptionFilters,
+		sess.breakpoints.ExceptionFilters())
+	require.Equal(t, saved.Watches, sess.watchList())
+	require.Equal(t, saved.Launch, *sess.launchConfig())
+	require.EqualValues(t, 2, fake.created.Load())
+
+	// Loading it again under the same session ID fails without closing
+	// the existing session.
+	result, err = callTool(context.Background(), mds, "load_workspace",
+		map[string]any{"name": "svc"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	_, ok = mds.sessions.get("svc")
+	require.True(t, ok)
+
+	result, err = callTool(context.Background(), mds, "load_workspace",
+		map[string]any{"name": "missing"})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+}
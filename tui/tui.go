@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -13,6 +14,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/lightningnetwork/lnd/actor"
+	"github.com/roasbeef/mcp-debug/internal/metrics"
 	"github.com/roasbeef/mcp-debug/mcp"
 )
 
@@ -26,6 +28,9 @@ const (
 	ServerError
 )
 
+// remoteTimeout bounds how long a refresh waits for a remote server.
+const remoteTimeout = 2 * time.Second
+
 // LogEntry represents a log entry
 type LogEntry struct {
 	Timestamp time.Time
@@ -74,11 +79,16 @@ type ImprovedTUIModel struct {
 	// Server references
 	mcpServer   *mcp.MCPDebugServer
 	actorSystem *actor.ActorSystem
+
+	// remote is the server being monitored when the TUI is connected to
+	// one running in another process instead of mcpServer.
+	remote *mcp.RemoteServer
+
+	// sessions are the server's sessions as of the last refresh.
+	sessions []mcp.SessionInfo
 	
 	// Server metrics tracking
-	startTime     time.Time
-	totalRequests int
-	errorCount    int
+	startTime time.Time
 	
 	// Key bindings
 	keys keyMap
@@ -229,8 +239,6 @@ func NewTUIModel(mcpServer *mcp.MCPDebugServer, actorSystem *actor.ActorSystem)
 		mcpServer:      mcpServer,
 		actorSystem:    actorSystem,
 		startTime:      time.Now(),
-		totalRequests:  0,
-		errorCount:     0,
 		keys:           keys,
 	}
 }
@@ -333,11 +341,6 @@ func (m *ImprovedTUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case CommandResultMsg:
 		m.commandResponse = string(msg)
 		
-		// Track if this was an error response
-		if strings.Contains(string(msg), "error") || strings.Contains(string(msg), "failed") {
-			m.errorCount++
-		}
-		
 		// Add to logs
 		logEntry := LogEntry{
 			Timestamp: time.Now(),
@@ -566,36 +569,41 @@ func (m ImprovedTUIModel) getUptime() string {
 	}
 }
 
+// getTotalRequests returns the number of MCP tool calls the server has
+// handled.
 func (m ImprovedTUIModel) getTotalRequests() int {
-	// Count total requests from command history + any tracked requests
-	return len(m.commandHistory) + m.totalRequests
+	return int(metrics.ToolCalls.Sum(nil))
 }
 
+// getErrorCount returns the number of MCP tool calls that failed.
 func (m ImprovedTUIModel) getErrorCount() int {
-	return m.errorCount
+	ok := metrics.ToolCalls.Sum(map[string]string{"outcome": "ok"})
+	return m.getTotalRequests() - int(ok)
 }
 
 func (m ImprovedTUIModel) getSessionRows() []table.Row {
-	// TODO: Get real session data from MCP server
-	// For now, check if there are actual sessions registered
-	if m.mcpServer != nil && len(m.mcpServer.GetSessions()) > 0 {
-		var rows []table.Row
-		for sessionID, _ := range m.mcpServer.GetSessions() {
-			// Extract basic info - we'd need to extend the MCP server to track more metadata
-			rows = append(rows, []string{
-				sessionID,
-				"connected", // TODO: Get actual client ID
-				"unknown",   // TODO: Get program path from session state
-				"active",    // TODO: Get actual session status
-				"0",         // TODO: Get breakpoint count
-				"active",    // TODO: Get last activity time
-			})
+	rows := []table.Row{}
+	for _, info := range m.sessions {
+		program := info.Program
+		if program == "" {
+			program = "-"
 		}
-		return rows
+		
+		lastActive := "-"
+		if !info.LastActive.IsZero() {
+			lastActive = info.LastActive.Format("15:04:05")
+		}
+		
+		rows = append(rows, []string{
+			info.ID,
+			info.Client,
+			program,
+			info.State.String(),
+			fmt.Sprintf("%d", info.Breakpoints),
+			lastActive,
+		})
 	}
-	
-	// Return empty if no real sessions
-	return []table.Row{}
+	return rows
 }
 
 func (m ImprovedTUIModel) getClientRows() []table.Row {
@@ -608,14 +616,49 @@ func (m ImprovedTUIModel) getClientRows() []table.Row {
 }
 
 func (m *ImprovedTUIModel) updateServerData() {
+	// Update server status
+	m.serverStatus = ServerRunning
+	if err := m.loadSessions(); err != nil {
+		m.serverStatus = ServerError
+		m.logEntries = append(m.logEntries, LogEntry{
+			Timestamp: time.Now(),
+			Level:     "ERROR",
+			Component: "Server",
+			Message:   fmt.Sprintf("Unable to list sessions: %v", err),
+		})
+		m.updateLogsViewport()
+	}
+	
 	// Update sessions table with real data
 	m.sessionsTable.SetRows(m.getSessionRows())
 	
 	// Update clients table with real data
 	m.clientsTable.SetRows(m.getClientRows())
-	
-	// Update server status
-	m.serverStatus = ServerRunning
+}
+
+// loadSessions fetches the sessions of the in-process or remote server.
+func (m *ImprovedTUIModel) loadSessions() error {
+	switch {
+	case m.remote != nil:
+		ctx, cancel := context.WithTimeout(
+			context.Background(), remoteTimeout,
+		)
+		defer cancel()
+
+		sessions, err := m.remote.SessionInfos(ctx)
+		if err != nil {
+			return err
+		}
+		m.sessions = sessions
+
+	case m.mcpServer != nil:
+		m.sessions = m.mcpServer.SessionInfos()
+
+	default:
+		m.sessions = nil
+	}
+
+	return nil
 }
 
 func (m *ImprovedTUIModel) updateLogsViewport() {
@@ -686,7 +729,7 @@ Try: create_debug_session {"session_id": "test1"}`)
 			}
 			
 			// Actually create a session using the MCP server
-			if m.mcpServer != nil {
+			if m.mcpServer != nil || m.remote != nil {
 				// This would execute the actual MCP tool
 				return CommandResultMsg(fmt.Sprintf("Debug session '%s' created successfully.\nUse 'initialize_session {\"session_id\": \"%s\", \"client_id\": \"tui_client\"}' next.", sessionID, sessionID))
 			}
@@ -694,8 +737,8 @@ Try: create_debug_session {"session_id": "test1"}`)
 		}
 		
 		if strings.Contains(command, "get_sessions") {
-			if m.mcpServer != nil {
-				sessionCount := len(m.mcpServer.GetSessions())
+			if m.mcpServer != nil || m.remote != nil {
+				sessionCount := len(m.sessions)
 				return CommandResultMsg(fmt.Sprintf("Active sessions: %d", sessionCount))
 			}
 			return CommandResultMsg("MCP server not available")
@@ -712,6 +755,7 @@ func (m ImprovedTUIModel) GetTabs() []string { return m.tabs }
 func (m ImprovedTUIModel) GetCurrentView() int { return m.activeTab }
 func (m ImprovedTUIModel) GetMCPServer() *mcp.MCPDebugServer { return m.mcpServer }
 func (m ImprovedTUIModel) GetActorSystem() *actor.ActorSystem { return m.actorSystem }
+func (m ImprovedTUIModel) GetRemoteServer() *mcp.RemoteServer { return m.remote }
 
 // Message types for the improved TUI
 type (
@@ -719,10 +763,28 @@ type (
 	CommandResultMsg string
 )
 
+// NewRemoteTUIModel creates a TUI model that monitors a server running in
+// another process.
+func NewRemoteTUIModel(remote *mcp.RemoteServer) ImprovedTUIModel {
+	model := NewTUIModel(nil, nil)
+	model.remote = remote
+
+	return model
+}
+
 // RunTUI starts the TUI application
 func RunTUI(mcpServer *mcp.MCPDebugServer, actorSystem *actor.ActorSystem) error {
-	model := NewTUIModel(mcpServer, actorSystem)
-	
+	return runModel(NewTUIModel(mcpServer, actorSystem))
+}
+
+// RunRemoteTUI starts the TUI application connected to a server running in
+// another process.
+func RunRemoteTUI(remote *mcp.RemoteServer) error {
+	return runModel(NewRemoteTUIModel(remote))
+}
+
+// runModel runs the TUI application with the given model.
+func runModel(model ImprovedTUIModel) error {
 	// Initialize with real data (will be empty initially)
 	model.updateServerData()
 	